@@ -5,28 +5,165 @@
 package app
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
 	"sber/internal/cache"
 	"sber/internal/config"
+	"sber/internal/grpcapi"
 	"sber/internal/handlers"
+	"sber/internal/metrics"
 	"sber/internal/server"
+	"sber/internal/webhook"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
-// Run is the main function for running the application. It loads the configuration from the specified YML file,
-// initializes the storage system, creates handler instances, and starts the server with the configured handlers.
-func Run() {
-	// Load the application configuration from the YML file
-	cfg, err := config.LoadConfig("config.yml")
+// configCheckInterval is how often the configuration file is checked for changes.
+const configCheckInterval = 5 * time.Second
+
+// gauges are the metrics of the running application. They are registered in the process-wide registry once and
+// read the storage and the dispatcher of the latest Run, so that Run can be called more than once in a process.
+var gauges struct {
+	once       sync.Once
+	storage    atomic.Pointer[cache.Storage]
+	dispatcher atomic.Pointer[webhook.Dispatcher]
+}
+
+// registerGauges exposes the state of the storage and the dispatcher, which is nil without webhooks, as metrics.
+func registerGauges(storage *cache.Storage, dispatcher *webhook.Dispatcher) {
+	gauges.storage.Store(storage)
+	gauges.dispatcher.Store(dispatcher)
+	gauges.once.Do(func() {
+		metrics.Default.MustRegister(
+			metrics.NewGaugeFunc("mortgage_cache_entries", "Number of calculations stored in the cache.", func() float64 {
+				return float64(gauges.storage.Load().Len())
+			}),
+			metrics.NewGaugeFunc("mortgage_cache_stream_subscribers", "Number of open /cache/stream event streams.", func() float64 {
+				return float64(gauges.storage.Load().Subscribers())
+			}),
+			metrics.NewGaugeFunc("mortgage_webhook_pending_events", "Number of webhook events waiting to be delivered.", func() float64 {
+				if dispatcher := gauges.dispatcher.Load(); dispatcher != nil {
+					return float64(dispatcher.Pending())
+				}
+				return 0
+			}),
+		)
+	})
+}
+
+// Run is the main function for running the application. It configures structured JSON logging, loads the
+// configuration from the YML file given with the --config flag (or the default location) and watches it for
+// changes, initializes the storage system, creates handler instances, and runs the server with the configured
+// handlers (and the gRPC API, when enabled) until SIGINT or SIGTERM is received. It returns an error instead of
+// exiting, so that the caller decides how to report it.
+func Run(args []string) error {
+	// Write structured JSON logs to stdout
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	// Parse the command-line flags
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to the YML configuration file (default: "+
+		"./internal/config/config.yml if present, otherwise built-in defaults)")
+	if err := flags.Parse(args); err != nil {
+		// The usage has already been printed for -h
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	// Load the application configuration from the YML file and the environment
+	path := config.Locate(*configPath)
+	cfg, err := config.Load(path)
 	if err != nil {
-		log.Fatalf("Failed to load config from yml file with err: %v", err)
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	current := config.NewCurrent(cfg)
+
+	// Stop on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Reload the configuration when the file changes or on SIGHUP
+	if path != "" {
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		defer signal.Stop(reload)
+		go config.NewWatcher(path, current, configCheckInterval).Run(ctx, reload)
+	}
+
+	// Initialize the cache storage system with memoization and the request history, when configured
+	var cacheOpts []cache.Option
+	if cfg.Cache.Memoize {
+		cacheOpts = append(cacheOpts, cache.WithMemoization())
+	}
+	if cfg.Cache.History {
+		cacheOpts = append(cacheOpts, cache.WithHistory(cfg.Cache.HistorySize))
+	}
+	storage := cache.New(cacheOpts...)
+
+	// Notify the webhook endpoints of the new calculations when webhooks are enabled
+	handlerOpts := []handlers.Option{handlers.WithConfig(current)}
+	dispatched := make(chan struct{})
+	var dispatcher *webhook.Dispatcher
+	if cfg.Webhooks.Enabled {
+		if dispatcher, err = webhook.New(cfg.Webhooks); err != nil {
+			return fmt.Errorf("failed to initialize webhooks: %w", err)
+		}
+		handlerOpts = append(handlerOpts, handlers.WithWebhooks(dispatcher))
+		go func() {
+			dispatcher.Run(ctx, storage)
+			close(dispatched)
+		}()
+	} else {
+		close(dispatched)
 	}
 
-	// Initialize the cache storage system
-	storage := cache.New()
+	// Expose the number of cached calculations, open streams and pending webhook events as metrics
+	registerGauges(storage, dispatcher)
 
 	// Create the handlers using the initialized storage
-	h := handlers.NewHandlers(storage)
+	h := handlers.NewHandlers(storage, handlerOpts...)
+
+	// Serve the gRPC API from the same storage when it is enabled
+	var opts []server.Option
+	if cfg.GRPC.Enabled {
+		opts = append(opts, server.WithGRPC(grpcapi.NewService(storage, current)))
+	}
+
+	// Build and start the server with the configured handlers and loaded configuration
+	srv, err := server.New(h, cfg, opts...)
+	if err != nil {
+		return err
+	}
+	if err := srv.Start(ctx); err != nil {
+		return err
+	}
+
+	// Wait for a signal to stop the server or for the server to fail
+	var serveErr error
+	select {
+	case <-ctx.Done():
+	case err = <-srv.Err():
+		serveErr = fmt.Errorf("server failed: %w", err)
+	}
+
+	// Stop the webhook dispatcher and the other listeners even if one of them failed
+	stop()
+
+	// Attempt to gracefully shut down the server within the configured timeout, after the pre-stop delay
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.PreStopDelay+cfg.Server.ShutdownTimeout)
+	defer cancel()
+	err = srv.Shutdown(shutdownCtx)
 
-	// Start the server with the configured handlers and loaded configuration
-	server.New(h, cfg)
+	// Wait for the webhook deliveries in progress to stop, the undelivered events stay in the state file
+	<-dispatched
+	return errors.Join(serveErr, err)
 }
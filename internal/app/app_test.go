@@ -0,0 +1,30 @@
+package app
+
+import (
+	"bytes"
+	"sber/internal/cache"
+	"sber/internal/metrics"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+)
+
+// TestRegisterGaugesTwice verifies that the gauges can be registered by every Run in a process and report the
+// storage of the latest one.
+func TestRegisterGaugesTwice(t *testing.T) {
+	registerGauges(cache.New(), nil)
+
+	storage := cache.New()
+	storage.Load(models.Result{})
+	registerGauges(storage, nil)
+
+	var buf bytes.Buffer
+	if _, err := metrics.Default.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write metrics: %v", err)
+	}
+	for _, expected := range []string{"mortgage_cache_entries 1\n", "mortgage_webhook_pending_events 0\n"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %q in metrics, got:\n%s", expected, buf.String())
+		}
+	}
+}
@@ -1,12 +1,22 @@
 // Package cache provides an in-memory storage system for caching data in the application.
 // It includes operations for loading, reading, and checking data in the cache, using synchronization mechanisms
 // to ensure thread-safe access to the cached data.
+//
+// With memoization enabled, the storage is content-addressed: a calculation identical to a stored one
+// returns the stored entry and increments its hit counter instead of being stored again. The request history
+// optionally records the latest calculation requests, whether they were stored or answered from the cache.
+// Subscriptions deliver the new entries as they are stored, for streaming them to clients.
+// Snapshots dump the entries with the ID counter and restore them, to carry the cache over a redeployment.
 package cache
 
 import (
+	errs "sber/pkg/errors"
 	"sber/pkg/models"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Storage represents the in-memory cache storage system. It contains a map for storing cached data
@@ -19,41 +29,208 @@ type Storage struct {
 	mu sync.Mutex
 	// IDCounter is an atomic counter used to generate unique IDs for cache entries.
 	IDCounter int32
+	// keys indexes the entries by their memoization key, nil when memoization is disabled.
+	keys map[memoKey]int32
+	// history is a ring buffer of the recorded calculation requests, when recordHistory is set.
+	history []models.HistoryRecord
+	// historyStart is the position of the oldest record in history once it is full.
+	historyStart int
+	// historyLimit is the number of records kept in the history, the oldest ones are dropped beyond it.
+	historyLimit int
+	// recordHistory is whether calculation requests are recorded in the history.
+	recordHistory bool
+	// now returns the current time, used to timestamp the entries and the history.
+	now func() time.Time
+	// subscribers are the active subscriptions to the new entries.
+	subscribers map[*Subscription]struct{}
+}
+
+// memoKey identifies a calculation by the client that made it and its normalized parameters. The rate and
+// the last payment date are part of the key, since the result changes with the configured rates and the day of
+// the calculation, so that a memoized entry never returns a result that would be calculated differently now.
+type memoKey struct {
+	clientID        string
+	params          models.Params
+	program         models.Program
+	rate            uint8
+	lastPaymentDate string
+}
+
+// keyOf returns the memoization key of a calculation made by the client.
+func keyOf(value models.Result, clientID string) memoKey {
+	return memoKey{
+		clientID:        clientID,
+		params:          value.Params,
+		program:         value.Program,
+		rate:            value.Aggregates.Rate,
+		lastPaymentDate: value.Aggregates.LastPaymentDate,
+	}
+}
+
+// Option configures optional behavior of the Storage.
+type Option func(s *Storage)
+
+// WithMemoization makes Store return the stored entry for a calculation identical to one made before by the
+// same client, instead of storing it again.
+func WithMemoization() Option {
+	return func(s *Storage) {
+		s.keys = map[memoKey]int32{}
+	}
+}
+
+// WithHistory makes the storage record the calculation requests in the history, including the requests
+// answered from the cache. Only the latest limit requests are kept, so that the history does not exhaust memory.
+func WithHistory(limit int) Option {
+	return func(s *Storage) {
+		s.recordHistory = limit > 0
+		s.historyLimit = limit
+	}
+}
+
+// WithClock makes the storage timestamp the entries and the history with the given clock instead of time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *Storage) {
+		s.now = now
+	}
 }
 
 // New creates and returns a new instance of the Storage struct with an empty cache map.
-func New() *Storage {
-	return &Storage{
+func New(opts ...Option) *Storage {
+	s := &Storage{
 		str: map[int32]models.CacheStorageFormat{},
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // Load adds a new entry to the cache with a unique ID and the given value. It increments the ID counter atomically
 // to ensure that each entry gets a unique ID.
 func (s *Storage) Load(value models.Result) {
-	// Load the current value of IDCounter atomically to generate a unique ID.
-	id := atomic.LoadInt32(&s.IDCounter)
-	// Increment the IDCounter atomically.
-	atomic.AddInt32(&s.IDCounter, 1)
+	s.LoadWithMeta(value, models.RecordMeta{})
+}
+
+// LoadWithMeta adds a new entry to the cache like Load, recording the request metadata and the current time
+// alongside the result. It returns the ID of the new entry.
+func (s *Storage) LoadWithMeta(value models.Result, meta models.RecordMeta) int32 {
+	// Lock the mutex to ensure thread-safe access to the cache while modifying it.
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	return s.insert(value, meta).ID
+}
+
+// Store stores the result of a calculation request like LoadWithMeta. With memoization enabled, a calculation
+// identical to one stored before for the same client is not stored again: the stored entry is returned with
+// its hit counter incremented, and hit is true.
+func (s *Storage) Store(value models.Result, meta models.RecordMeta) (entry models.CacheStorageFormat, hit bool) {
 	// Lock the mutex to ensure thread-safe access to the cache while modifying it.
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// Reuse the stored entry of an identical calculation
+	if s.keys != nil {
+		if id, ok := s.keys[keyOf(value, meta.ClientID)]; ok {
+			entry = s.str[id]
+			entry.Hits++
+			s.str[id] = entry
+			s.record(entry.ID, meta, true)
+			return entry, true
+		}
+	}
+
+	return s.insert(value, meta), false
+}
+
+// maxUserAgentLength limits the length of the stored user agents, which are sent by the clients.
+const maxUserAgentLength = 256
+
+// insert stores a new entry with a unique ID. The caller must hold the mutex.
+func (s *Storage) insert(value models.Result, meta models.RecordMeta) models.CacheStorageFormat {
+	// Load the current value of IDCounter atomically to generate a unique ID.
+	id := atomic.LoadInt32(&s.IDCounter)
+	// Increment the IDCounter atomically.
+	atomic.AddInt32(&s.IDCounter, 1)
+
 	// Store the new cache entry with the generated ID.
 	cacheData := models.CacheStorageFormat{
 		ID:         id,
 		Params:     value.Params,
 		Program:    value.Program,
 		Aggregates: value.Aggregates,
+		ClientID:   meta.ClientID,
+		CreatedAt:  s.now().UTC(),
+		Source:     meta.Source,
+		RequestID:  meta.RequestID,
+		UserAgent:  truncate(meta.UserAgent, maxUserAgentLength),
 	}
 
-	cacheData.MarshalJSON()
 	s.str[id] = cacheData
+	if s.keys != nil {
+		s.keys[keyOf(value, meta.ClientID)] = id
+	}
+	s.record(id, meta, false)
+	s.publish(cacheData)
+	return cacheData
+}
+
+// record appends a calculation request to the history, if the history is enabled, replacing the oldest record
+// once the history is full. The caller must hold the mutex.
+func (s *Storage) record(entryID int32, meta models.RecordMeta, hit bool) {
+	if !s.recordHistory {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	record := models.HistoryRecord{
+		CreatedAt: s.now().UTC(),
+		EntryID:   entryID,
+		ClientID:  meta.ClientID,
+		RequestID: meta.RequestID,
+		Cache:     result,
+	}
+	if len(s.history) < s.historyLimit {
+		s.history = append(s.history, record)
+		return
+	}
+	s.history[s.historyStart] = record
+	s.historyStart = (s.historyStart + 1) % len(s.history)
 }
 
-// ReadAll returns all entries from the cache as a slice of CacheStorageFormat. It locks the cache before reading
-// to ensure thread-safety.
+// resetHistory drops the recorded requests. The caller must hold the mutex.
+func (s *Storage) resetHistory() {
+	s.history = nil
+	s.historyStart = 0
+}
+
+// RecordsHistory reports whether the storage records the calculation requests in the history.
+func (s *Storage) RecordsHistory() bool {
+	return s.recordHistory
+}
+
+// History returns the recorded calculation requests of the given client in the order they were made, or of
+// all clients if clientID is empty. It returns nil when the history is disabled.
+func (s *Storage) History(clientID string) []models.HistoryRecord {
+	// Lock the mutex to ensure thread-safe access to the cache while reading it.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []models.HistoryRecord
+	for i := range s.history {
+		record := s.history[(s.historyStart+i)%len(s.history)]
+		if clientID == "" || record.ClientID == clientID {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// ReadAll returns all entries from the cache as a slice of CacheStorageFormat ordered by ID. It locks the cache
+// before reading to ensure thread-safety.
 func (s *Storage) ReadAll() []models.CacheStorageFormat {
 	// Lock the mutex to ensure thread-safe access to the cache while reading it.
 	s.mu.Lock()
@@ -69,12 +246,73 @@ func (s *Storage) ReadAll() []models.CacheStorageFormat {
 		i++
 	}
 
+	// Sort the entries by ID so that the response order is stable
+	sort.Slice(strArr, func(i, j int) bool { return strArr[i].ID < strArr[j].ID })
+
 	// Return the slice containing all cache entries.
 	return strArr
 }
 
+// Get returns the entry with the given ID and whether it exists.
+func (s *Storage) Get(id int32) (models.CacheStorageFormat, bool) {
+	// Lock the mutex to ensure thread-safe access to the cache while reading it.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.str[id]
+	return entry, ok
+}
+
+// ReadByClient returns the entries made by the given client, ordered by ID.
+func (s *Storage) ReadByClient(clientID string) []models.CacheStorageFormat {
+	// Lock the mutex to ensure thread-safe access to the cache while reading it.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Collect the entries that belong to the client
+	var strArr []models.CacheStorageFormat
+	for _, v := range s.str {
+		if v.ClientID == clientID {
+			strArr = append(strArr, v)
+		}
+	}
+
+	// Sort the entries by ID so that the response order is stable
+	sort.Slice(strArr, func(i, j int) bool { return strArr[i].ID < strArr[j].ID })
+
+	return strArr
+}
+
 // HasData checks whether there are any entries in the cache. It returns true if the cache is not empty.
 func (s *Storage) HasData() bool {
 	// Return whether the cache map is empty or not.
 	return len(s.str) != 0
 }
+
+// Len returns the number of entries in the cache.
+func (s *Storage) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.str)
+}
+
+// Ping reports whether the storage is able to serve requests. The in-memory storage is available as soon as it
+// has been created with New, so an error is only returned for an uninitialized Storage.
+func (s *Storage) Ping() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.str == nil {
+		return errs.ErrStorageUnavailable
+	}
+	return nil
+}
+
+// truncate cuts the string to at most n bytes, dropping a rune split by the cut.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
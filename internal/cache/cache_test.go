@@ -1,11 +1,16 @@
 package cache_test
 
 import (
+	"errors"
+	"fmt"
 	"sber/internal/cache"
+	errs "sber/pkg/errors"
 	"sber/pkg/models"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // TestNew verifies that the New function creates a new Storage instance with an empty cache.
@@ -134,6 +139,21 @@ func TestHasData(t *testing.T) {
 	}
 }
 
+// TestLen verifies that the Len method returns the number of entries in the cache.
+func TestLen(t *testing.T) {
+	storage := cache.New()
+	if storage.Len() != 0 {
+		t.Errorf("Expected empty cache, got %d entries", storage.Len())
+	}
+
+	storage.Load(models.Result{Params: models.Params{ObjectCost: 100000}})
+	storage.Load(models.Result{Params: models.Params{ObjectCost: 200000}})
+
+	if storage.Len() != 2 {
+		t.Errorf("Expected 2 entries in cache, got %d", storage.Len())
+	}
+}
+
 // TestConcurrentAccess verifies that the cache works correctly in a concurrent environment.
 func TestConcurrentAccess(t *testing.T) {
 	storage := cache.New()
@@ -163,3 +183,276 @@ func TestConcurrentAccess(t *testing.T) {
 		t.Errorf("Expected IDCounter to be %d, got %d", numGoroutines, atomic.LoadInt32(&storage.IDCounter))
 	}
 }
+
+// TestPing verifies that Ping succeeds for an initialized storage and fails for a zero-value one.
+func TestPing(t *testing.T) {
+	if err := cache.New().Ping(); err != nil {
+		t.Errorf("Expected initialized storage to be available, got %v", err)
+	}
+
+	var storage cache.Storage
+	if err := storage.Ping(); !errors.Is(err, errs.ErrStorageUnavailable) {
+		t.Errorf("Expected ErrStorageUnavailable, got %v", err)
+	}
+}
+
+// TestReadByClient verifies that ReadByClient returns only the entries recorded for the given client.
+func TestReadByClient(t *testing.T) {
+	storage := cache.New()
+	storage.LoadWithMeta(models.Result{Params: models.Params{ObjectCost: 100000}}, models.RecordMeta{ClientID: "bank-a"})
+	id := storage.LoadWithMeta(models.Result{Params: models.Params{ObjectCost: 200000}}, models.RecordMeta{ClientID: "bank-b"})
+	storage.Load(models.Result{Params: models.Params{ObjectCost: 300000}})
+
+	// LoadWithMeta returns the ID of the new entry
+	if entry, ok := storage.Get(id); !ok || id != 1 || entry.ClientID != "bank-b" {
+		t.Errorf("Expected entry 1 of bank-b, got %d: %+v", id, entry)
+	}
+
+	entries := storage.ReadByClient("bank-a")
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry for bank-a, got %d", len(entries))
+	}
+	if entries[0].ClientID != "bank-a" || entries[0].Params.ObjectCost != 100000 {
+		t.Errorf("Unexpected entry for bank-a: %+v", entries[0])
+	}
+	if len(storage.ReadByClient("bank-c")) != 0 {
+		t.Error("Expected no entries for unknown client")
+	}
+}
+
+// TestGet verifies that the Get method returns stored entries by ID.
+func TestGet(t *testing.T) {
+	storage := cache.New()
+	storage.Load(models.Result{Params: models.Params{ObjectCost: 100000}})
+
+	entry, ok := storage.Get(0)
+	if !ok || entry.Params.ObjectCost != 100000 {
+		t.Errorf("Expected entry 0 with ObjectCost=100000, got %+v (found: %v)", entry, ok)
+	}
+	if _, ok := storage.Get(1); ok {
+		t.Error("Expected entry 1 to be missing")
+	}
+}
+
+// TestStoreMemoization verifies that an identical calculation of the same client returns the stored entry and
+// increments its hit counter, while other clients, parameters and rates are stored as new entries.
+func TestStoreMemoization(t *testing.T) {
+	storage := cache.New(cache.WithMemoization())
+	result := models.Result{
+		Params:     models.Params{ObjectCost: 100000, InitialPayment: 20000, Months: 12},
+		Program:    models.Program{Base: true},
+		Aggregates: models.Aggregates{Rate: 10, LoanSum: 80000, MonthlyPayment: 7033, LastPaymentDate: "2025-01-01"},
+	}
+	bankA := models.RecordMeta{ClientID: "bank-a"}
+
+	first, hit := storage.Store(result, bankA)
+	if hit || first.ID != 0 || first.Hits != 0 {
+		t.Fatalf("Expected new entry 0, got %+v (hit: %v)", first, hit)
+	}
+
+	// The repeated calculation is answered with the stored entry
+	for i := int32(1); i <= 2; i++ {
+		entry, hit := storage.Store(result, bankA)
+		if !hit || entry.ID != 0 || entry.Hits != i {
+			t.Errorf("Expected hit %d of entry 0, got %+v (hit: %v)", i, entry, hit)
+		}
+	}
+	if entry, _ := storage.Get(0); entry.Hits != 2 {
+		t.Errorf("Expected stored hit counter 2, got %d", entry.Hits)
+	}
+
+	changedRate := result
+	changedRate.Aggregates.Rate = 9
+	nextDay := result
+	nextDay.Aggregates.LastPaymentDate = "2025-01-02"
+	changedParams := result
+	changedParams.Params.Months = 24
+
+	tests := []struct {
+		name  string
+		value models.Result
+		meta  models.RecordMeta
+	}{
+		{"Other client", result, models.RecordMeta{ClientID: "bank-b"}},
+		{"Changed rate", changedRate, bankA},
+		{"Other day", nextDay, bankA},
+		{"Changed parameters", changedParams, bankA},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if entry, hit := storage.Store(tt.value, tt.meta); hit || entry.ID == 0 {
+				t.Errorf("Expected a new entry, got %+v (hit: %v)", entry, hit)
+			}
+		})
+	}
+	if storage.Len() != 5 {
+		t.Errorf("Expected 5 entries, got %d", storage.Len())
+	}
+}
+
+// TestStoreWithoutMemoization verifies that without memoization every calculation is stored as a new entry.
+func TestStoreWithoutMemoization(t *testing.T) {
+	storage := cache.New()
+	result := models.Result{Params: models.Params{ObjectCost: 100000}}
+
+	for i := int32(0); i < 2; i++ {
+		if entry, hit := storage.Store(result, models.RecordMeta{}); hit || entry.ID != i {
+			t.Errorf("Expected new entry %d, got %+v (hit: %v)", i, entry, hit)
+		}
+	}
+}
+
+// TestHistory verifies that the history records stored and memoized requests in order, scoped by client.
+func TestHistory(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	storage := cache.New(cache.WithMemoization(), cache.WithHistory(100), cache.WithClock(func() time.Time { return now }))
+	result := models.Result{Params: models.Params{ObjectCost: 100000}}
+
+	storage.Store(result, models.RecordMeta{ClientID: "bank-a", RequestID: "req-1"})
+	storage.Store(result, models.RecordMeta{ClientID: "bank-a", RequestID: "req-2"})
+	storage.Store(result, models.RecordMeta{ClientID: "bank-b", RequestID: "req-3"})
+
+	expected := []models.HistoryRecord{
+		{CreatedAt: now, EntryID: 0, ClientID: "bank-a", RequestID: "req-1", Cache: "miss"},
+		{CreatedAt: now, EntryID: 0, ClientID: "bank-a", RequestID: "req-2", Cache: "hit"},
+		{CreatedAt: now, EntryID: 1, ClientID: "bank-b", RequestID: "req-3", Cache: "miss"},
+	}
+	all := storage.History("")
+	if len(all) != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), len(all))
+	}
+	for i := range expected {
+		if all[i] != expected[i] {
+			t.Errorf("Record %d: expected %+v, got %+v", i, expected[i], all[i])
+		}
+	}
+	if records := storage.History("bank-b"); len(records) != 1 || records[0].EntryID != 1 {
+		t.Errorf("Expected the record of bank-b, got %+v", records)
+	}
+
+	// Without the option nothing is recorded
+	disabled := cache.New()
+	disabled.Store(result, models.RecordMeta{})
+	if disabled.RecordsHistory() || disabled.History("") != nil {
+		t.Error("Expected no history without WithHistory")
+	}
+}
+
+// TestHistoryLimit verifies that the history keeps the latest requests in order once it is full.
+func TestHistoryLimit(t *testing.T) {
+	storage := cache.New(cache.WithHistory(3))
+	for i := 1; i <= 5; i++ {
+		storage.Store(models.Result{Params: models.Params{ObjectCost: int32(i)}}, models.RecordMeta{RequestID: fmt.Sprint("req-", i)})
+	}
+
+	records := storage.History("")
+	var ids []string
+	for _, record := range records {
+		ids = append(ids, record.RequestID)
+	}
+	if strings.Join(ids, ",") != "req-3,req-4,req-5" {
+		t.Errorf("Expected the latest 3 requests in order, got %v", ids)
+	}
+}
+
+// TestLoadWithMetaAudit verifies that entries record the time they were stored and the request metadata.
+func TestLoadWithMetaAudit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 15, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	storage := cache.New(cache.WithClock(func() time.Time { return now }))
+
+	id := storage.LoadWithMeta(models.Result{Params: models.Params{ObjectCost: 100000}}, models.RecordMeta{
+		ClientID:  "bank-a",
+		Source:    models.SourceHTTP,
+		RequestID: "req-1",
+		UserAgent: "crm/1.0",
+	})
+
+	entry, _ := storage.Get(id)
+	if !entry.CreatedAt.Equal(now) || entry.CreatedAt.Location() != time.UTC {
+		t.Errorf("Expected creation time %v in UTC, got %v", now, entry.CreatedAt)
+	}
+	if entry.ClientID != "bank-a" || entry.Source != "http" || entry.RequestID != "req-1" || entry.UserAgent != "crm/1.0" {
+		t.Errorf("Unexpected metadata %+v", entry)
+	}
+}
+
+// TestSubscribe verifies that subscriptions receive the new entries in order, but not the memoized ones,
+// and that closing a subscription closes its channel.
+func TestSubscribe(t *testing.T) {
+	storage := cache.New(cache.WithMemoization())
+	storage.Load(models.Result{Params: models.Params{ObjectCost: 100000}})
+
+	sub := storage.Subscribe("", 4)
+	if storage.Subscribers() != 1 {
+		t.Errorf("Expected 1 subscriber, got %d", storage.Subscribers())
+	}
+	if sub.After() != 0 {
+		t.Errorf("Expected the subscription to start after entry 0, got %d", sub.After())
+	}
+
+	storage.Store(models.Result{Params: models.Params{ObjectCost: 200000}}, models.RecordMeta{})
+	storage.Store(models.Result{Params: models.Params{ObjectCost: 200000}}, models.RecordMeta{})
+	storage.Store(models.Result{Params: models.Params{ObjectCost: 300000}}, models.RecordMeta{})
+
+	for _, expected := range []int32{1, 2} {
+		if entry := <-sub.C(); entry.ID != expected {
+			t.Errorf("Expected entry %d, got %d", expected, entry.ID)
+		}
+	}
+
+	sub.Close()
+	sub.Close()
+	if _, ok := <-sub.C(); ok {
+		t.Error("Expected the channel to be closed")
+	}
+	if sub.Overflowed() || storage.Subscribers() != 0 {
+		t.Errorf("Expected a closed subscription without overflow, got %v and %d subscribers", sub.Overflowed(), storage.Subscribers())
+	}
+}
+
+// TestSubscribeOverflow verifies that a subscriber that does not keep up is unsubscribed instead of blocking
+// the storage, and can catch up with ReadAfter.
+func TestSubscribeOverflow(t *testing.T) {
+	storage := cache.New()
+	sub := storage.Subscribe("", 2)
+	defer sub.Close()
+
+	for i := range 5 {
+		storage.Load(models.Result{Params: models.Params{ObjectCost: int32(i)}})
+	}
+
+	var received []int32
+	for entry := range sub.C() {
+		received = append(received, entry.ID)
+	}
+	if len(received) != 2 || !sub.Overflowed() {
+		t.Fatalf("Expected 2 entries and an overflow, got %v (overflowed: %v)", received, sub.Overflowed())
+	}
+
+	missed := storage.ReadAfter(received[len(received)-1])
+	if len(missed) != 3 || missed[0].ID != 2 || missed[2].ID != 4 {
+		t.Errorf("Expected entries 2 to 4 after the last received one, got %+v", missed)
+	}
+}
+
+// TestSubscribeClient verifies that a subscription to a client only queues the entries of that client, so that
+// the traffic of other clients does not make it overflow.
+func TestSubscribeClient(t *testing.T) {
+	storage := cache.New()
+	sub := storage.Subscribe("bank-a", 1)
+	defer sub.Close()
+
+	for i := range 5 {
+		storage.LoadWithMeta(models.Result{Params: models.Params{ObjectCost: int32(i)}}, models.RecordMeta{ClientID: "bank-b"})
+	}
+	storage.LoadWithMeta(models.Result{Params: models.Params{ObjectCost: 100000}}, models.RecordMeta{ClientID: "bank-a"})
+
+	if sub.Overflowed() || storage.Subscribers() != 1 {
+		t.Fatalf("Expected the subscription to survive the entries of other clients, got %v and %d subscribers",
+			sub.Overflowed(), storage.Subscribers())
+	}
+	if entry := <-sub.C(); entry.ID != 5 || entry.ClientID != "bank-a" {
+		t.Errorf("Expected entry 5 of bank-a, got %+v", entry)
+	}
+}
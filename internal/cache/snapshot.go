@@ -0,0 +1,246 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	errs "sber/pkg/errors"
+	"sber/pkg/models"
+	"sort"
+	"sync/atomic"
+)
+
+// SnapshotVersion is the version of the snapshot format, increased on incompatible changes.
+const SnapshotVersion = 1
+
+// MaxSnapshotSize is the largest snapshot ReadSnapshot accepts once decompressed, so that a small compressed
+// snapshot cannot exhaust memory.
+const MaxSnapshotSize = 256 << 20
+
+// gzipMagic are the first bytes of gzip streams, by which compressed snapshots are recognized.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Snapshot returns a dump of the storage: the entries ordered by ID and the ID counter. The request history
+// is not included.
+func (s *Storage) Snapshot() models.Snapshot {
+	// Lock the mutex, so that the entries and the counter are consistent.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]models.CacheStorageFormat, 0, len(s.str))
+	for _, v := range s.str {
+		entries = append(entries, v)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	return models.Snapshot{
+		Version:   SnapshotVersion,
+		CreatedAt: s.now().UTC(),
+		IDCounter: atomic.LoadInt32(&s.IDCounter),
+		Entries:   entries,
+	}
+}
+
+// Restore stores the entries of the snapshot after validating it with ValidateSnapshot.
+//
+// With models.RestoreReplace, the stored entries and the request history are dropped first. With
+// models.RestoreMerge, the entries are added to the stored ones: an entry whose ID is free keeps it, an entry
+// identical to a stored one is skipped, so that restoring the same snapshot twice changes nothing, and an entry
+// whose ID is taken by another calculation is stored with a new ID. The ID counter never decreases,
+// so that the IDs already handed out are not reused. The restored entries are not delivered to the subscribers.
+func (s *Storage) Restore(snap models.Snapshot, mode string) (models.SnapshotImport, error) {
+	if mode != models.RestoreMerge && mode != models.RestoreReplace {
+		return models.SnapshotImport{}, fmt.Errorf("unknown restore mode %q, use merge or replace", mode)
+	}
+	if err := ValidateSnapshot(snap); err != nil {
+		return models.SnapshotImport{}, err
+	}
+
+	// Lock the mutex to ensure thread-safe access to the cache while modifying it.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Sort the entries: the ones with free IDs keep them, the ones stored already are skipped, and the others get
+	// new IDs above all the known ones. Replacing frees all the IDs.
+	result := models.SnapshotImport{Mode: mode}
+	free := snap.Entries
+	var renumbered []models.CacheStorageFormat
+	if mode == models.RestoreMerge {
+		free, renumbered = s.sortMerged(snap.Entries, &result)
+	}
+
+	// Check that the new IDs fit before changing anything
+	counter := max(atomic.LoadInt32(&s.IDCounter), snap.IDCounter)
+	if int64(counter)+int64(len(renumbered)) > math.MaxInt32 {
+		return models.SnapshotImport{}, fmt.Errorf("%w: id_counter %d leaves no room for %d renumbered entries",
+			errs.ErrInvalidSnapshot, counter, len(renumbered))
+	}
+
+	if mode == models.RestoreReplace {
+		s.str = make(map[int32]models.CacheStorageFormat, len(snap.Entries))
+		if s.keys != nil {
+			s.keys = map[memoKey]int32{}
+		}
+		s.resetHistory()
+	}
+	for _, entry := range free {
+		s.restore(entry)
+		result.Imported++
+	}
+	for _, entry := range renumbered {
+		entry.ID = counter
+		counter++
+		s.restore(entry)
+		result.Renumbered++
+	}
+
+	atomic.StoreInt32(&s.IDCounter, counter)
+	result.IDCounter = counter
+	return result, nil
+}
+
+// sortMerged splits the entries merged into the storage into the ones whose IDs are free and the ones to
+// renumber, counting the entries stored already, under their own ID or, by an earlier merge, under another one, as
+// skipped. The caller must hold the mutex.
+func (s *Storage) sortMerged(entries []models.CacheStorageFormat, result *models.SnapshotImport) (free, renumbered []models.CacheStorageFormat) {
+	var collided []models.CacheStorageFormat
+	for _, entry := range entries {
+		stored, ok := s.str[entry.ID]
+		switch {
+		case !ok:
+			free = append(free, entry)
+		case sameCalculation(stored, entry):
+			result.Skipped++
+		default:
+			collided = append(collided, entry)
+		}
+	}
+	if len(collided) == 0 {
+		return free, nil
+	}
+
+	known := make(map[calculation]struct{}, len(s.str)+len(free))
+	for _, entry := range s.str {
+		known[calculationOf(entry)] = struct{}{}
+	}
+	for _, entry := range free {
+		known[calculationOf(entry)] = struct{}{}
+	}
+	for _, entry := range collided {
+		if _, ok := known[calculationOf(entry)]; ok {
+			result.Skipped++
+			continue
+		}
+		renumbered = append(renumbered, entry)
+	}
+	return free, renumbered
+}
+
+// restore stores a restored entry under its ID and indexes it for memoization, unless an identical calculation
+// is indexed already. The caller must hold the mutex.
+func (s *Storage) restore(entry models.CacheStorageFormat) {
+	s.str[entry.ID] = entry
+	if s.keys == nil {
+		return
+	}
+	key := keyOf(models.Result{Params: entry.Params, Program: entry.Program, Aggregates: entry.Aggregates}, entry.ClientID)
+	if _, ok := s.keys[key]; !ok {
+		s.keys[key] = entry.ID
+	}
+}
+
+// calculation identifies a stored calculation regardless of its ID and hit counter.
+type calculation struct {
+	clientID   string
+	createdAt  int64
+	requestID  string
+	params     models.Params
+	program    models.Program
+	aggregates models.Aggregates
+}
+
+// calculationOf returns the calculation of the entry.
+func calculationOf(entry models.CacheStorageFormat) calculation {
+	return calculation{entry.ClientID, entry.CreatedAt.UnixNano(), entry.RequestID, entry.Params, entry.Program, entry.Aggregates}
+}
+
+// sameCalculation reports whether two entries describe the same stored calculation, ignoring the hit counter.
+func sameCalculation(a, b models.CacheStorageFormat) bool {
+	return calculationOf(a) == calculationOf(b)
+}
+
+// ValidateSnapshot checks that the snapshot has a supported version and consistent IDs: every entry ID is
+// unique, not negative and below the ID counter. The problems are reported wrapping errs.ErrInvalidSnapshot.
+func ValidateSnapshot(snap models.Snapshot) error {
+	if snap.Version != SnapshotVersion {
+		return fmt.Errorf("%w: unsupported version %d, expected %d", errs.ErrInvalidSnapshot, snap.Version, SnapshotVersion)
+	}
+	if snap.IDCounter < 0 {
+		return fmt.Errorf("%w: negative id_counter %d", errs.ErrInvalidSnapshot, snap.IDCounter)
+	}
+
+	ids := make(map[int32]struct{}, len(snap.Entries))
+	for _, entry := range snap.Entries {
+		if entry.ID < 0 || entry.ID >= snap.IDCounter {
+			return fmt.Errorf("%w: entry ID %d is not between 0 and id_counter %d", errs.ErrInvalidSnapshot, entry.ID, snap.IDCounter)
+		}
+		if _, ok := ids[entry.ID]; ok {
+			return fmt.Errorf("%w: duplicate entry ID %d", errs.ErrInvalidSnapshot, entry.ID)
+		}
+		ids[entry.ID] = struct{}{}
+	}
+	return nil
+}
+
+// WriteSnapshot writes the snapshot as JSON, compressed with gzip if compress is set.
+func WriteSnapshot(w io.Writer, snap models.Snapshot, compress bool) error {
+	if !compress {
+		return json.NewEncoder(w).Encode(snap)
+	}
+
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(snap); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot, recognizing gzip compression by its first bytes, and
+// validates it with ValidateSnapshot. Unknown fields and snapshots larger than MaxSnapshotSize are rejected.
+// All errors wrap errs.ErrInvalidSnapshot, along with the read error, if any.
+func ReadSnapshot(r io.Reader) (models.Snapshot, error) {
+	br := bufio.NewReader(r)
+	src := io.Reader(br)
+	if magic, _ := br.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return models.Snapshot{}, fmt.Errorf("%w: %w", errs.ErrInvalidSnapshot, err)
+		}
+		defer zr.Close()
+		src = zr
+	}
+
+	data, err := io.ReadAll(io.LimitReader(src, MaxSnapshotSize+1))
+	if err != nil {
+		return models.Snapshot{}, fmt.Errorf("%w: %w", errs.ErrInvalidSnapshot, err)
+	}
+	if len(data) > MaxSnapshotSize {
+		return models.Snapshot{}, fmt.Errorf("%w: larger than %d bytes", errs.ErrInvalidSnapshot, MaxSnapshotSize)
+	}
+
+	var snap models.Snapshot
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&snap); err != nil {
+		return models.Snapshot{}, fmt.Errorf("%w: %w", errs.ErrInvalidSnapshot, err)
+	}
+	if err = ValidateSnapshot(snap); err != nil {
+		return models.Snapshot{}, err
+	}
+	return snap, nil
+}
@@ -0,0 +1,188 @@
+package cache_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"math"
+	"sber/internal/cache"
+	errs "sber/pkg/errors"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSnapshotStorage returns a memoizing storage with calculations of two clients, stored at fixed times.
+func newSnapshotStorage() *cache.Storage {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	storage := cache.New(cache.WithMemoization(), cache.WithClock(func() time.Time { return now }))
+	for i, clientID := range []string{"bank-a", "bank-b", "bank-a"} {
+		storage.Store(models.Result{Params: models.Params{ObjectCost: int32(i+1) * 1_000_000}}, models.RecordMeta{ClientID: clientID, RequestID: "req"})
+	}
+	return storage
+}
+
+// TestSnapshotRoundTrip verifies that a snapshot written as JSON or gzip is read back unchanged.
+func TestSnapshotRoundTrip(t *testing.T) {
+	snap := newSnapshotStorage().Snapshot()
+	if snap.Version != cache.SnapshotVersion || snap.IDCounter != 3 || len(snap.Entries) != 3 {
+		t.Fatalf("Unexpected snapshot %+v", snap)
+	}
+
+	for _, compress := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := cache.WriteSnapshot(&buf, snap, compress); err != nil {
+			t.Fatalf("WriteSnapshot: %v", err)
+		}
+		if compressed := bytes.HasPrefix(buf.Bytes(), []byte{0x1f, 0x8b}); compressed != compress {
+			t.Errorf("Expected compressed %v, got %v", compress, compressed)
+		}
+
+		read, err := cache.ReadSnapshot(&buf)
+		if err != nil {
+			t.Fatalf("ReadSnapshot: %v", err)
+		}
+		if read.IDCounter != snap.IDCounter || len(read.Entries) != len(snap.Entries) {
+			t.Fatalf("Expected %+v, got %+v", snap, read)
+		}
+		for i, entry := range read.Entries {
+			if entry.ID != snap.Entries[i].ID || !entry.CreatedAt.Equal(snap.Entries[i].CreatedAt) || entry.ClientID != snap.Entries[i].ClientID {
+				t.Errorf("Entry %d: expected %+v, got %+v", i, snap.Entries[i], entry)
+			}
+		}
+	}
+}
+
+// TestRestore verifies that merging keeps free IDs, skips the entries stored already and renumbers the
+// colliding ones, while replacing drops the stored entries.
+func TestRestore(t *testing.T) {
+	snap := newSnapshotStorage().Snapshot()
+
+	tests := []struct {
+		name     string
+		prepare  func(storage *cache.Storage)
+		mode     string
+		expected models.SnapshotImport
+		entries  int
+	}{
+		{"Merge into an empty cache", func(*cache.Storage) {}, models.RestoreMerge,
+			models.SnapshotImport{Mode: models.RestoreMerge, Imported: 3, IDCounter: 3}, 3},
+		{"Merge the same snapshot again", func(storage *cache.Storage) {
+			storage.Restore(snap, models.RestoreMerge)
+		}, models.RestoreMerge, models.SnapshotImport{Mode: models.RestoreMerge, Skipped: 3, IDCounter: 3}, 3},
+		{"Merge with colliding IDs", func(storage *cache.Storage) {
+			storage.Load(models.Result{Params: models.Params{ObjectCost: 9_000_000}})
+			storage.Load(models.Result{Params: models.Params{ObjectCost: 8_000_000}})
+		}, models.RestoreMerge, models.SnapshotImport{Mode: models.RestoreMerge, Imported: 1, Renumbered: 2, IDCounter: 5}, 5},
+		{"Replace", func(storage *cache.Storage) {
+			for i := 0; i < 5; i++ {
+				storage.Load(models.Result{Params: models.Params{ObjectCost: int32(i)}})
+			}
+		}, models.RestoreReplace, models.SnapshotImport{Mode: models.RestoreReplace, Imported: 3, IDCounter: 5}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := cache.New(cache.WithMemoization())
+			tt.prepare(storage)
+
+			result, err := storage.Restore(snap, tt.mode)
+			if err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, result)
+			}
+			if storage.Len() != tt.entries {
+				t.Errorf("Expected %d entries, got %d", tt.entries, storage.Len())
+			}
+
+			// New entries get IDs after the restored ones
+			if id := storage.LoadWithMeta(models.Result{Params: models.Params{ObjectCost: 1}}, models.RecordMeta{ClientID: "new"}); id != tt.expected.IDCounter {
+				t.Errorf("Expected the next ID %d, got %d", tt.expected.IDCounter, id)
+			}
+		})
+	}
+}
+
+// TestRestoreMemoization verifies that the restored entries answer identical calculations.
+func TestRestoreMemoization(t *testing.T) {
+	snap := newSnapshotStorage().Snapshot()
+	storage := cache.New(cache.WithMemoization())
+	if _, err := storage.Restore(snap, models.RestoreReplace); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	entry, hit := storage.Store(models.Result{Params: models.Params{ObjectCost: 2_000_000}}, models.RecordMeta{ClientID: "bank-b"})
+	if !hit || entry.ID != 1 || entry.Hits != 1 {
+		t.Errorf("Expected a hit on entry 1, got %+v (hit %v)", entry, hit)
+	}
+}
+
+// TestReadSnapshotInvalid verifies that malformed and inconsistent snapshots are rejected.
+func TestReadSnapshotInvalid(t *testing.T) {
+	var truncated bytes.Buffer
+	zw := gzip.NewWriter(&truncated)
+	zw.Write([]byte(`{"version": 1, "id_counter": 0, "entries": []}`))
+	zw.Close()
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"Not JSON", []byte("id,cost\n1,100"), "invalid character"},
+		{"Unsupported version", []byte(`{"version": 2, "id_counter": 0}`), "unsupported version 2"},
+		{"Unknown field", []byte(`{"version": 1, "id_counter": 0, "records": []}`), "unknown field"},
+		{"Duplicate ID", []byte(`{"version": 1, "id_counter": 2, "entries": [{"id": 1}, {"id": 1}]}`), "duplicate entry ID 1"},
+		{"ID above the counter", []byte(`{"version": 1, "id_counter": 1, "entries": [{"id": 1}]}`), "entry ID 1"},
+		{"Negative ID", []byte(`{"version": 1, "id_counter": 1, "entries": [{"id": -1}]}`), "entry ID -1"},
+		{"Truncated gzip", truncated.Bytes()[:truncated.Len()-4], "unexpected EOF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cache.ReadSnapshot(bytes.NewReader(tt.data))
+			if !errors.Is(err, errs.ErrInvalidSnapshot) || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected invalid snapshot error mentioning %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// TestRestoreUnknownMode verifies that Restore rejects unknown modes without changing the storage.
+func TestRestoreUnknownMode(t *testing.T) {
+	storage := newSnapshotStorage()
+	if _, err := storage.Restore(storage.Snapshot(), "append"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+	if storage.Len() != 3 {
+		t.Errorf("Expected the storage to be unchanged, got %d entries", storage.Len())
+	}
+}
+
+// TestRestoreIDOverflow verifies that a merge is rejected without changing the storage when the renumbered
+// entries would not fit below the largest ID.
+func TestRestoreIDOverflow(t *testing.T) {
+	storage := cache.New()
+	storage.Load(models.Result{Params: models.Params{ObjectCost: 9_000_000}})
+
+	snap := models.Snapshot{Version: cache.SnapshotVersion, IDCounter: math.MaxInt32, Entries: []models.CacheStorageFormat{
+		{ID: 0, Params: models.Params{ObjectCost: 1_000_000}},
+		{ID: 1, Params: models.Params{ObjectCost: 2_000_000}},
+	}}
+	if _, err := storage.Restore(snap, models.RestoreMerge); !errors.Is(err, errs.ErrInvalidSnapshot) {
+		t.Fatalf("Expected an invalid snapshot error, got %v", err)
+	}
+	if storage.Len() != 1 || storage.IDCounter != 1 {
+		t.Errorf("Expected the storage to be unchanged, got %d entries and the next ID %d", storage.Len(), storage.IDCounter)
+	}
+
+	// Without collisions the counter is kept
+	snap.Entries = snap.Entries[1:]
+	result, err := storage.Restore(snap, models.RestoreMerge)
+	if err != nil || result.IDCounter != math.MaxInt32 || result.Imported != 1 {
+		t.Errorf("Expected one imported entry and the next ID %d, got %+v (%v)", int32(math.MaxInt32), result, err)
+	}
+}
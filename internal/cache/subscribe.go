@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"sber/pkg/models"
+	"sort"
+	"sync/atomic"
+)
+
+// Subscription delivers the entries stored after it was created, in the order of their IDs, optionally only the
+// ones of a single client. Entries are queued without blocking the storage: a subscriber whose queue is full is
+// unsubscribed and its channel closed, so that a slow consumer never delays the requests that store
+// calculations. It can then catch up with ReadAfter.
+type Subscription struct {
+	ch         chan models.CacheStorageFormat // Queue of the stored entries
+	storage    *Storage                       // Storage the subscription belongs to
+	clientID   string                         // Client whose entries are delivered, all entries if empty
+	after      int32                          // ID of the last entry stored before the subscription, -1 if none
+	overflowed atomic.Bool                    // Whether the subscription was closed because the queue was full
+}
+
+// Subscribe creates a subscription to the entries stored from now on, queueing up to buffer entries. With a
+// client ID, only the entries of that client are queued, so that the traffic of other clients neither fills the
+// queue nor makes the subscription overflow; with an empty one, all entries are. The subscription must be closed
+// with Close when it is no longer needed.
+func (s *Storage) Subscribe(clientID string, buffer int) *Subscription {
+	sub := &Subscription{ch: make(chan models.CacheStorageFormat, max(buffer, 1)), storage: s, clientID: clientID}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscribers == nil {
+		s.subscribers = map[*Subscription]struct{}{}
+	}
+	s.subscribers[sub] = struct{}{}
+	sub.after = atomic.LoadInt32(&s.IDCounter) - 1
+	return sub
+}
+
+// After returns the ID of the last entry stored before the subscription was created, or -1 if there was none.
+// The subscription delivers the entries with greater IDs.
+func (sub *Subscription) After() int32 {
+	return sub.after
+}
+
+// C returns the channel delivering the stored entries. It is closed when the subscription is closed or
+// overflowed.
+func (sub *Subscription) C() <-chan models.CacheStorageFormat {
+	return sub.ch
+}
+
+// Overflowed reports whether the subscription was closed because the subscriber did not keep up.
+func (sub *Subscription) Overflowed() bool {
+	return sub.overflowed.Load()
+}
+
+// Close ends the subscription. It is safe to call more than once and after an overflow.
+func (sub *Subscription) Close() {
+	sub.storage.mu.Lock()
+	defer sub.storage.mu.Unlock()
+
+	sub.storage.unsubscribe(sub)
+}
+
+// Subscribers returns the number of active subscriptions.
+func (s *Storage) Subscribers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.subscribers)
+}
+
+// ReadAfter returns the entries with an ID greater than the given one, ordered by ID. Subscribers use it to
+// catch up with the entries stored while they were not subscribed.
+func (s *Storage) ReadAfter(id int32) []models.CacheStorageFormat {
+	// Lock the mutex to ensure thread-safe access to the cache while reading it.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var strArr []models.CacheStorageFormat
+	for _, v := range s.str {
+		if v.ID > id {
+			strArr = append(strArr, v)
+		}
+	}
+
+	// Sort the entries by ID so that they are replayed in the order they were stored
+	sort.Slice(strArr, func(i, j int) bool { return strArr[i].ID < strArr[j].ID })
+
+	return strArr
+}
+
+// publish queues a new entry for every subscriber to its client, unsubscribing the ones whose queue is full.
+// The caller must hold the mutex.
+func (s *Storage) publish(entry models.CacheStorageFormat) {
+	for sub := range s.subscribers {
+		if sub.clientID != "" && sub.clientID != entry.ClientID {
+			continue
+		}
+		select {
+		case sub.ch <- entry:
+		default:
+			sub.overflowed.Store(true)
+			s.unsubscribe(sub)
+		}
+	}
+}
+
+// unsubscribe removes the subscription and closes its channel, if it is still subscribed.
+// The caller must hold the mutex.
+func (s *Storage) unsubscribe(sub *Subscription) {
+	if _, ok := s.subscribers[sub]; !ok {
+		return
+	}
+	delete(s.subscribers, sub)
+	close(sub.ch)
+}
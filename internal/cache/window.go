@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"sber/pkg/models"
+	"time"
+)
+
+// Window is a time window of stored entries. From is inclusive and To is exclusive; a zero bound leaves the
+// window open on that side, so the zero Window contains every entry.
+type Window struct {
+	From time.Time // Start of the window, inclusive
+	To   time.Time // End of the window, exclusive
+}
+
+// Valid reports whether the start of the window is before its end, when both are given.
+func (w Window) Valid() bool {
+	return w.From.IsZero() || w.To.IsZero() || w.From.Before(w.To)
+}
+
+// Contains reports whether the time is within the window.
+func (w Window) Contains(t time.Time) bool {
+	if !w.From.IsZero() && t.Before(w.From) {
+		return false
+	}
+	if !w.To.IsZero() && !t.Before(w.To) {
+		return false
+	}
+	return true
+}
+
+// Filter returns the entries created within the window, keeping their order.
+func (w Window) Filter(entries []models.CacheStorageFormat) []models.CacheStorageFormat {
+	if w.From.IsZero() && w.To.IsZero() {
+		return entries
+	}
+	var filtered []models.CacheStorageFormat
+	for _, entry := range entries {
+		if w.Contains(entry.CreatedAt) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sber/internal/config"
+	"sber/pkg/models"
+	"sber/pkg/mortgage"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats of the calculation commands.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// programNames lists the loan programs in the order they are compared.
+var programNames = []string{"base", "military", "salary"}
+
+// calcFlags holds the flags shared by the calculation commands.
+type calcFlags struct {
+	cost       int
+	initial    int
+	months     int
+	program    string
+	format     string
+	configPath string
+}
+
+// newCalcFlags creates the flag set of a calculation command. The program flag is only registered for
+// commands that calculate a single program.
+func newCalcFlags(name string, withProgram bool, stderr io.Writer) (*flag.FlagSet, *calcFlags) {
+	f := &calcFlags{}
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.IntVar(&f.cost, "cost", 0, "cost of the object")
+	flags.IntVar(&f.initial, "initial", 0, "initial payment, at least 20% of the cost")
+	flags.IntVar(&f.months, "months", 0, fmt.Sprintf("loan term in months, at most %d", mortgage.MaxMonths))
+	if withProgram {
+		flags.StringVar(&f.program, "program", "", "loan program: "+strings.Join(programNames, ", "))
+	}
+	flags.StringVar(&f.format, "format", formatTable, "output format: table or json")
+	flags.StringVar(&f.configPath, "config", "", "path to the YML configuration file with the program rates")
+	return flags, f
+}
+
+// validate checks the flags that the calculation code does not validate itself.
+func (f *calcFlags) validate() error {
+	if f.format != formatTable && f.format != formatJSON {
+		return fmt.Errorf("unsupported format %q, use table or json", f.format)
+	}
+	values := []struct {
+		name  string
+		value int
+		min   int
+	}{
+		{"cost", f.cost, 0},
+		{"initial", f.initial, 0},
+		{"months", f.months, 1},
+	}
+	for _, v := range values {
+		if v.value < v.min || v.value > math.MaxInt32 {
+			return fmt.Errorf("-%s must be between %d and %d, got %d", v.name, v.min, math.MaxInt32, v.value)
+		}
+	}
+	return nil
+}
+
+// request builds the calculation request for the given program names. Several names select several
+// programs, which the calculation rejects like /execute does.
+func (f *calcFlags) request(programs ...string) (models.ExecuteReqeust, error) {
+	req := models.ExecuteReqeust{
+		ObjectCost:     int32(f.cost),
+		InitialPayment: int32(f.initial),
+		Months:         int32(f.months),
+	}
+	for _, name := range programs {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "base":
+			req.Program.Base = true
+		case "military":
+			req.Program.Military = true
+		case "salary":
+			req.Program.Salary = true
+		default:
+			return req, fmt.Errorf("unknown program %q, use %s", name, strings.Join(programNames, ", "))
+		}
+	}
+	return req, nil
+}
+
+// rates loads the program rates from the configuration, as the server does.
+func (f *calcFlags) rates() (config.Programs, error) {
+	cfg, err := config.Load(config.Locate(f.configPath))
+	if err != nil {
+		return config.Programs{}, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg.Programs, nil
+}
+
+// prepare parses the flags and the request of a calculation command. It returns the exit code to stop
+// with, or -1 to continue.
+func prepare(flags *flag.FlagSet, f *calcFlags, args []string, stderr io.Writer) (models.ExecuteReqeust, config.Programs, int) {
+	if code := parseFlags(flags, args); code >= 0 {
+		return models.ExecuteReqeust{}, config.Programs{}, code
+	}
+	if err := f.validate(); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return models.ExecuteReqeust{}, config.Programs{}, ExitUsage
+	}
+
+	req, err := f.request(strings.Split(f.program, ",")...)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return models.ExecuteReqeust{}, config.Programs{}, ExitUsage
+	}
+
+	rates, err := f.rates()
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return models.ExecuteReqeust{}, config.Programs{}, ExitError
+	}
+	return req, rates, -1
+}
+
+// calculate runs the calculation engine shared with /execute, reporting validation failures on stderr.
+// It returns the exit code to stop with, or -1 to continue.
+func calculate(req models.ExecuteReqeust, rates config.Programs, stderr io.Writer) (mortgage.Result, int) {
+	input, err := mortgage.InputFromRequest(req)
+	if err != nil {
+		return validationFailed(err, stderr)
+	}
+	result, err := mortgage.New(rates.Rates()).Calculate(input)
+	if err != nil {
+		return validationFailed(err, stderr)
+	}
+	return result, -1
+}
+
+// validationFailed reports a validation error of the calculation engine on stderr.
+func validationFailed(err error, stderr io.Writer) (mortgage.Result, int) {
+	fmt.Fprintf(stderr, "validation failed: %v\n", err)
+	return mortgage.Result{}, ExitValidation
+}
+
+// runCalc calculates a mortgage for one program.
+func runCalc(args []string, stdout, stderr io.Writer) int {
+	flags, f := newCalcFlags("calc", true, stderr)
+	req, rates, code := prepare(flags, f, args, stderr)
+	if code >= 0 {
+		return code
+	}
+
+	calculated, code := calculate(req, rates, stderr)
+	if code >= 0 {
+		return code
+	}
+
+	result := calculated.Model()
+	if f.format == formatJSON {
+		return writeJSON(stdout, stderr, models.ExecuteResponse{Result: result})
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	rows := [][2]string{
+		{"Program", programName(result.Program)},
+		{"Rate, %", fmt.Sprint(result.Aggregates.Rate)},
+		{"Object cost", fmt.Sprint(result.Params.ObjectCost)},
+		{"Initial payment", fmt.Sprint(result.Params.InitialPayment)},
+		{"Loan sum", fmt.Sprint(result.Aggregates.LoanSum)},
+		{"Term, months", fmt.Sprint(result.Params.Months)},
+		{"Monthly payment", fmt.Sprint(result.Aggregates.MonthlyPayment)},
+		{"Overpayment", fmt.Sprint(result.Aggregates.Overpayment)},
+		{"Last payment date", result.Aggregates.LastPaymentDate},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\n", row[0], row[1])
+	}
+	return flush(tw, stderr)
+}
+
+// runSchedule prints the payment schedule of a mortgage for one program.
+func runSchedule(args []string, stdout, stderr io.Writer) int {
+	flags, f := newCalcFlags("schedule", true, stderr)
+	req, rates, code := prepare(flags, f, args, stderr)
+	if code >= 0 {
+		return code
+	}
+
+	result, code := calculate(req, rates, stderr)
+	if code >= 0 {
+		return code
+	}
+
+	schedule, err := result.Schedule()
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return ExitError
+	}
+
+	if f.format == formatJSON {
+		return writeJSON(stdout, stderr, schedule)
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "No.\tDate\tPayment\tPrincipal\tInterest\tBalance\t")
+	for _, p := range schedule {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%d\t\n", p.Number, p.Date, p.Amount, p.Principal, p.Interest, p.Balance)
+	}
+	return flush(tw, stderr)
+}
+
+// runCompare calculates a mortgage for every program side by side.
+func runCompare(args []string, stdout, stderr io.Writer) int {
+	flags, f := newCalcFlags("compare", false, stderr)
+	base, rates, code := prepare(flags, f, args, stderr)
+	if code >= 0 {
+		return code
+	}
+
+	// Every program is calculated with the same parameters, so the program of the request is not needed
+	calculated, err := mortgage.New(rates.Rates()).Compare(mortgage.Input{
+		ObjectCost:     base.ObjectCost,
+		InitialPayment: base.InitialPayment,
+		Months:         base.Months,
+	})
+	if err != nil {
+		_, code = validationFailed(err, stderr)
+		return code
+	}
+	results := make([]models.Result, len(calculated))
+	for i, result := range calculated {
+		results[i] = result.Model()
+	}
+
+	if f.format == formatJSON {
+		return writeJSON(stdout, stderr, results)
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Program\tRate, %\tMonthly payment\tOverpayment\tLast payment date")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", programName(r.Program), r.Aggregates.Rate,
+			r.Aggregates.MonthlyPayment, r.Aggregates.Overpayment, r.Aggregates.LastPaymentDate)
+	}
+	return flush(tw, stderr)
+}
+
+// programName returns the name of the selected loan program.
+func programName(program models.Program) string {
+	switch {
+	case program.Base:
+		return "base"
+	case program.Military:
+		return "military"
+	case program.Salary:
+		return "salary"
+	default:
+		return ""
+	}
+}
+
+// writeJSON prints the value as indented JSON.
+func writeJSON(stdout, stderr io.Writer, v any) int {
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+// flush writes the buffered table.
+func flush(tw *tabwriter.Writer, stderr io.Writer) int {
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
@@ -0,0 +1,113 @@
+// Package cli implements the command-line interface of the mortgage calculation service binary.
+//
+// Besides running the HTTP server, the binary calculates mortgages directly in a terminal or a shell
+// script, reusing the same validation and calculation code as the /execute endpoint and the program
+// rates from the same configuration. Results are printed as a table or as JSON.
+//
+// Commands:
+//   - calc: Calculates a mortgage for one program.
+//   - schedule: Prints the payment schedule of a mortgage.
+//   - compare: Calculates a mortgage for every program side by side.
+//   - dump: Downloads a snapshot of the cache of a running service.
+//   - restore: Restores a snapshot into the cache of a running service.
+//   - serve: Runs the HTTP server (the default when no command is given).
+//
+// The exit code reflects the outcome, so that scripts can react to validation failures:
+// ExitOK, ExitError, ExitUsage and ExitValidation.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sber/internal/app"
+	"strings"
+)
+
+// Exit codes of the binary.
+const (
+	ExitOK         = 0 // The command succeeded
+	ExitError      = 1 // The command failed, e.g. the configuration could not be loaded
+	ExitUsage      = 2 // The command line is invalid
+	ExitValidation = 3 // The mortgage parameters failed validation
+)
+
+// command is a subcommand of the binary.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+// commands returns the subcommands in the order they are listed in the usage.
+func commands() []command {
+	return []command{
+		{"calc", "calculate a mortgage for one program", runCalc},
+		{"schedule", "print the payment schedule of a mortgage", runSchedule},
+		{"compare", "calculate a mortgage for every program side by side", runCompare},
+		{"dump", "download a snapshot of the cache of a running service", runDump},
+		{"restore", "restore a snapshot into the cache of a running service", runRestore},
+		{"serve", "run the HTTP server (default)", runServe},
+	}
+}
+
+// Run executes the command given by args (without the program name) and returns the exit code. Without
+// a command, or when the arguments start with a flag, the HTTP server is run for compatibility with
+// existing deployments.
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 && (args[0] == "help" || args[0] == "-h" || args[0] == "--help") {
+		usage(stdout)
+		return ExitOK
+	}
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runServe(args, stdout, stderr)
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout, stderr)
+		}
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+	usage(stderr)
+	return ExitUsage
+}
+
+// usage prints the list of commands.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: mortgage <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "mortgage <command> -h" for the flags of a command.`)
+}
+
+// runServe runs the HTTP server until it is stopped by a signal.
+func runServe(args []string, _, stderr io.Writer) int {
+	if err := app.Run(args); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+// parseFlags parses the flags of a command. It returns the exit code to stop with, or -1 to continue.
+func parseFlags(flags *flag.FlagSet, args []string) int {
+	if err := flags.Parse(args); err != nil {
+		// The usage has already been printed by the flag set
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(flags.Output(), "unexpected arguments: %s\n", strings.Join(flags.Args(), " "))
+		return ExitUsage
+	}
+	return -1
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+)
+
+// writeTestConfig writes a configuration file with custom program rates and returns its path.
+func writeTestConfig(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("programs:\n  base: 12\n  military: 9\n  salary: 8\n"), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	configPath := writeTestConfig(t)
+	params := []string{"-cost", "5000000", "-initial", "1000000", "-months", "240", "-config", configPath}
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{"Calculation table", append([]string{"calc", "-program", "salary"}, params...), ExitOK, "Monthly payment    33458", ""},
+		{"Rates from the configuration", append([]string{"calc", "-program", "base"}, params...), ExitOK, "Rate, %            12", ""},
+		{"Comparison table", append([]string{"compare"}, params...), ExitOK, "military  9        35990", ""},
+		{"Schedule table", append([]string{"schedule", "-program", "salary"}, params...), ExitOK, "240  ", ""},
+		{"No program", append([]string{"calc"}, params...), ExitValidation, "", "validation failed: choose program"},
+		{"Several programs", append([]string{"calc", "-program", "base,salary"}, params...), ExitValidation, "",
+			"validation failed: choose only 1 program"},
+		{"Initial payment too small", []string{"compare", "-cost", "100", "-initial", "1", "-months", "12", "-config", configPath},
+			ExitValidation, "", "validation failed: the initial payment should be more"},
+		{"Unknown program", append([]string{"calc", "-program", "student"}, params...), ExitUsage, "", `unknown program "student"`},
+		{"Invalid term", []string{"calc", "-program", "base", "-cost", "100", "-initial", "50", "-months", "0"}, ExitUsage, "",
+			"-months must be between 1"},
+		{"Term too long for a schedule", []string{"schedule", "-program", "base", "-cost", "5000000", "-initial", "1000000",
+			"-months", "2147483647", "-config", configPath}, ExitValidation, "", "the loan term should be at most 600 months"},
+		{"Term too long", []string{"calc", "-program", "base", "-cost", "5000000", "-initial", "1000000", "-months", "601",
+			"-config", configPath}, ExitValidation, "", "the loan term should be at most 600 months"},
+		{"Loan too large", []string{"calc", "-program", "base", "-cost", "2000000000", "-initial", "400000000", "-months", "600",
+			"-config", configPath}, ExitValidation, "", "the loan is too large"},
+		{"Invalid format", append([]string{"calc", "-program", "base", "-format", "xml"}, params...), ExitUsage, "", "unsupported format"},
+		{"Unknown flag", []string{"calc", "-rate", "5"}, ExitUsage, "", "flag provided but not defined"},
+		{"Unexpected argument", []string{"calc", "extra"}, ExitUsage, "", "unexpected arguments: extra"},
+		{"Missing config", append([]string{"calc", "-program", "base"}, append(params, "-config", "missing.yml")...), ExitError, "",
+			"failed to load config"},
+		{"Unknown command", []string{"refinance"}, ExitUsage, "", `unknown command "refinance"`},
+		{"Help", []string{"help"}, ExitOK, "compare", ""},
+		{"Command help", []string{"calc", "-h"}, ExitOK, "", "-program"},
+		{"Serve with invalid flag", []string{"serve", "-port", "80"}, ExitError, "", "flag provided but not defined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := Run(tt.args, &stdout, &stderr)
+
+			if code != tt.expectedCode {
+				t.Errorf("Expected exit code %d, got %d (stderr: %s)", tt.expectedCode, code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.expectedStdout) {
+				t.Errorf("Expected %q in stdout, got:\n%s", tt.expectedStdout, stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tt.expectedStderr) {
+				t.Errorf("Expected %q in stderr, got:\n%s", tt.expectedStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	params := []string{"-cost", "5000000", "-initial", "1000000", "-months", "240", "-format", "json", "-config", writeTestConfig(t)}
+
+	// calc prints the same document as /execute
+	var stdout, stderr bytes.Buffer
+	if code := Run(append([]string{"calc", "-program", "salary"}, params...), &stdout, &stderr); code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr.String())
+	}
+	var response models.ExecuteResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if response.Result.Aggregates.MonthlyPayment != 33458 || response.Result.Aggregates.Overpayment != 4029920 {
+		t.Errorf("Unexpected result %+v", response.Result)
+	}
+
+	// compare prints one result per program
+	stdout.Reset()
+	if code := Run(append([]string{"compare"}, params...), &stdout, &stderr); code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr.String())
+	}
+	var results []models.Result
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if len(results) != 3 || !results[0].Program.Base || results[0].Aggregates.Rate != 12 {
+		t.Errorf("Unexpected comparison %+v", results)
+	}
+
+	// schedule prints the payments
+	stdout.Reset()
+	if code := Run(append([]string{"schedule", "-program", "military"}, params...), &stdout, &stderr); code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr.String())
+	}
+	var schedule []models.Payment
+	if err := json.Unmarshal(stdout.Bytes(), &schedule); err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if len(schedule) != 240 || schedule[239].Balance != 0 {
+		t.Errorf("Unexpected schedule of %d payments", len(schedule))
+	}
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sber/internal/cache"
+	"sber/pkg/models"
+	"strings"
+	"time"
+)
+
+// adminKeyEnv is the environment variable with the admin key of the snapshot commands, so that the key does not
+// have to be passed on the command line, where other users can see it.
+const adminKeyEnv = "MORTGAGE_ADMIN_KEY"
+
+// stdio is the file name standing for the standard input or output.
+const stdio = "-"
+
+// maxErrorSize is how much of an error response of the service is read.
+const maxErrorSize = 64 << 10
+
+// adminFlags holds the flags shared by the commands calling the administrative API of a running service.
+type adminFlags struct {
+	url     string
+	key     string
+	timeout time.Duration
+}
+
+// newAdminFlags creates the flag set of a command calling the administrative API.
+func newAdminFlags(name string, stderr io.Writer) (*flag.FlagSet, *adminFlags) {
+	f := &adminFlags{}
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&f.url, "url", "http://localhost:8080", "base URL of the running service")
+	flags.StringVar(&f.key, "key", os.Getenv(adminKeyEnv), "admin key of the service (default: $"+adminKeyEnv+")")
+	flags.DurationVar(&f.timeout, "timeout", 5*time.Minute, "time allowed for the transfer")
+	return flags, f
+}
+
+// snapshot sends a request to the snapshot endpoint of the service. It returns the response, or an error with the
+// message of the service for statuses other than 200 OK.
+func (f *adminFlags) snapshot(method, query string, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequest(method, strings.TrimSuffix(f.url, "/")+"/admin/snapshot"+query, body)
+	if err != nil {
+		return nil, err
+	}
+	if f.key != "" {
+		req.Header.Set("Authorization", "Bearer "+f.key)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: f.timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+
+	// Report the error message of the service, if it sent one
+	defer resp.Body.Close()
+	var msg models.ErrorMessage
+	if json.NewDecoder(io.LimitReader(resp.Body, maxErrorSize)).Decode(&msg) == nil && msg.Error != "" {
+		return nil, fmt.Errorf("service answered %s: %s", resp.Status, msg.Error)
+	}
+	return nil, fmt.Errorf("service answered %s", resp.Status)
+}
+
+// runDump downloads a snapshot of the cache of a running service into a file or to the standard output.
+func runDump(args []string, stdout, stderr io.Writer) int {
+	flags, f := newAdminFlags("dump", stderr)
+	file := flags.String("file", stdio, "snapshot file to write, - for the standard output")
+	compress := flags.Bool("gzip", false, "compress the snapshot with gzip (the default for file names ending with .gz)")
+	if code := parseFlags(flags, args); code >= 0 {
+		return code
+	}
+
+	query := ""
+	if *compress || strings.HasSuffix(*file, ".gz") {
+		query = "?compress=gzip"
+	}
+	resp, err := f.snapshot(http.MethodGet, query, nil, "")
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return ExitError
+	}
+	defer resp.Body.Close()
+
+	if *file == stdio {
+		if _, err = io.Copy(stdout, resp.Body); err != nil {
+			fmt.Fprintf(stderr, "error: failed to download snapshot: %v\n", err)
+			return ExitError
+		}
+		return ExitOK
+	}
+	if err = writeFile(*file, resp.Body); err != nil {
+		fmt.Fprintf(stderr, "error: failed to write snapshot: %v\n", err)
+		return ExitError
+	}
+	fmt.Fprintf(stderr, "snapshot written to %s\n", *file)
+	return ExitOK
+}
+
+// runRestore uploads a snapshot from a file or the standard input into the cache of a running service.
+func runRestore(args []string, stdout, stderr io.Writer) int {
+	flags, f := newAdminFlags("restore", stderr)
+	file := flags.String("file", "", "snapshot file to restore, - for the standard input")
+	mode := flags.String("mode", models.RestoreMerge, "merge the snapshot into the stored calculations or replace them")
+	if code := parseFlags(flags, args); code >= 0 {
+		return code
+	}
+	if *file == "" {
+		fmt.Fprintln(stderr, "-file is required")
+		return ExitUsage
+	}
+	if *mode != models.RestoreMerge && *mode != models.RestoreReplace {
+		fmt.Fprintf(stderr, "unsupported mode %q, use merge or replace\n", *mode)
+		return ExitUsage
+	}
+
+	data, err := readFile(*file)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: failed to read snapshot: %v\n", err)
+		return ExitError
+	}
+
+	// Check the snapshot before uploading it, so that a broken file is reported without a request
+	if _, err = cache.ReadSnapshot(bytes.NewReader(data)); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return ExitError
+	}
+	contentType := "application/json"
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		contentType = "application/gzip"
+	}
+
+	resp, err := f.snapshot(http.MethodPost, "?mode="+*mode, bytes.NewReader(data), contentType)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return ExitError
+	}
+	defer resp.Body.Close()
+
+	var result models.SnapshotImport
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		fmt.Fprintf(stderr, "error: invalid response of the service: %v\n", err)
+		return ExitError
+	}
+	fmt.Fprintf(stdout, "Snapshot restored (%s): %d imported, %d renumbered, %d skipped, next ID %d\n",
+		result.Mode, result.Imported, result.Renumbered, result.Skipped, result.IDCounter)
+	return ExitOK
+}
+
+// readFile reads the file, or the standard input for stdio.
+func readFile(name string) ([]byte, error) {
+	if name == stdio {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(filepath.Clean(name))
+}
+
+// writeFile writes the content to the file through a temporary file in the same directory, so that a failed
+// download does not overwrite an existing snapshot.
+func writeFile(name string, content io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = os.Rename(tmp.Name(), name); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
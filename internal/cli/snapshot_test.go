@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sber/internal/cache"
+	"sber/internal/handlers"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+)
+
+// newSnapshotServer starts a server handling /admin/snapshot for the storage with the admin key "secret".
+func newSnapshotServer(t *testing.T, storage *cache.Storage) *httptest.Server {
+	t.Helper()
+
+	h := handlers.NewHandlers(storage)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "invalid API key"}`))
+			return
+		}
+		h.Snapshot(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDumpRestore(t *testing.T) {
+	source := cache.New()
+	for _, cost := range []int32{1_000_000, 2_000_000} {
+		source.LoadWithMeta(models.Result{Params: models.Params{ObjectCost: cost}}, models.RecordMeta{ClientID: "bank"})
+	}
+	target := cache.New()
+	target.Load(models.Result{Params: models.Params{ObjectCost: 9_000_000}})
+	sourceURL := newSnapshotServer(t, source).URL
+	targetURL := newSnapshotServer(t, target).URL
+
+	dir := t.TempDir()
+	for _, name := range []string{"snapshot.json", "snapshot.json.gz"} {
+		path := filepath.Join(dir, name)
+		var stdout, stderr bytes.Buffer
+		if code := Run([]string{"dump", "-url", sourceURL, "-key", "secret", "-file", path}, &stdout, &stderr); code != ExitOK {
+			t.Fatalf("dump %s: expected exit code %d, got %d: %s", name, ExitOK, code, stderr.String())
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read snapshot: %v", err)
+		}
+		if compressed := bytes.HasPrefix(data, []byte{0x1f, 0x8b}); compressed != strings.HasSuffix(name, ".gz") {
+			t.Errorf("%s: unexpected compression %v", name, compressed)
+		}
+	}
+
+	// Merging into the target renumbers the colliding entry, merging again skips everything
+	for _, expected := range []string{"1 imported, 1 renumbered, 0 skipped, next ID 3", "0 imported, 0 renumbered, 2 skipped, next ID 3"} {
+		var stdout, stderr bytes.Buffer
+		args := []string{"restore", "-url", targetURL, "-key", "secret", "-file", filepath.Join(dir, "snapshot.json.gz")}
+		if code := Run(args, &stdout, &stderr); code != ExitOK {
+			t.Fatalf("restore: expected exit code %d, got %d: %s", ExitOK, code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected %q, got %q", expected, stdout.String())
+		}
+	}
+	if target.Len() != 3 {
+		t.Errorf("Expected 3 entries, got %d", target.Len())
+	}
+}
+
+func TestDumpRestoreErrors(t *testing.T) {
+	url := newSnapshotServer(t, cache.New()).URL
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"version": 2, "id_counter": 0}`), 0600); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedCode   int
+		expectedStderr string
+	}{
+		{"Invalid key", []string{"dump", "-url", url, "-key", "wrong"}, ExitError, "service answered 401 Unauthorized: invalid API key"},
+		{"Service unavailable", []string{"dump", "-url", "http://127.0.0.1:1", "-key", "secret"}, ExitError, "connection refused"},
+		{"No file", []string{"restore", "-url", url, "-key", "secret"}, ExitUsage, "-file is required"},
+		{"Invalid mode", []string{"restore", "-url", url, "-file", invalid, "-mode", "append"}, ExitUsage, `unsupported mode "append"`},
+		{"Missing file", []string{"restore", "-url", url, "-key", "secret", "-file", filepath.Join(dir, "missing.json")}, ExitError,
+			"failed to read snapshot"},
+		{"Invalid snapshot", []string{"restore", "-url", url, "-key", "secret", "-file", invalid}, ExitError,
+			"invalid snapshot: unsupported version 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := Run(tt.args, &stdout, &stderr); code != tt.expectedCode {
+				t.Errorf("Expected exit code %d, got %d (stderr: %s)", tt.expectedCode, code, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.expectedStderr) {
+				t.Errorf("Expected %q in stderr, got:\n%s", tt.expectedStderr, stderr.String())
+			}
+		})
+	}
+}
@@ -1,63 +1,361 @@
 // Package config provides functionality to load and parse the application's configuration
 // from a YAML file. It defines a Config structure that maps to the configuration file
-// and includes a function to load the configuration and return it as a Config object.
+// and includes functions to load the configuration and return it as a Config object.
+//
+// The configuration is built in layers: the defaults, then the YAML file (if any), then the
+// MORTGAGE_* environment variables. The result is validated before it is returned.
 package config
 
 import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
+	"sber/pkg/mortgage"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultFilename is the name of the configuration file looked up when no path is given.
+const DefaultFilename = "config.yml"
+
+// basePath is the directory containing the configuration files, relative to the working directory.
+const basePath = "internal/config"
+
 // Config represents the application's configuration structure. It contains settings for various
 // parts of the application, such as the server configuration.
 type Config struct {
 	// Server contains configuration settings related to the server, such as the port number.
-	Server struct {
-		// Port is the port number on which the server will listen for incoming requests.
-		Port int `yaml:"port"`
-	} `yaml:"server"`
+	Server Server `yaml:"server"`
+
+	// GRPC contains the settings of the gRPC API served next to the HTTP server.
+	GRPC GRPC `yaml:"grpc"`
+
+	// Auth contains the API key authentication settings.
+	Auth Auth `yaml:"auth"`
+
+	// RateLimit contains the request rate limiting settings.
+	RateLimit RateLimit `yaml:"rate_limit"`
+
+	// Cache contains the settings of the calculation cache.
+	Cache Cache `yaml:"cache"`
+
+	// Stream contains the settings of the /cache/stream event stream.
+	Stream Stream `yaml:"stream"`
+
+	// Idempotency contains the settings of the Idempotency-Key support of /execute.
+	Idempotency Idempotency `yaml:"idempotency"`
+
+	// Webhooks contains the settings of the webhook notifications of new calculations.
+	Webhooks Webhooks `yaml:"webhooks"`
+
+	// Admin contains the settings of the administrative endpoints.
+	Admin Admin `yaml:"admin"`
+
+	// CORS contains the cross-origin resource sharing settings.
+	CORS CORS `yaml:"cors"`
+
+	// Programs contains the annual interest rates of the loan programs.
+	Programs Programs `yaml:"programs"`
+
+	// Offer contains the branding of the printable offer documents.
+	Offer Offer `yaml:"offer"`
+}
+
+// Offer contains the branding of the printable PDF offer documents. The values are read for every document,
+// so that reloaded values apply immediately.
+type Offer struct {
+	// Title is printed in the header of every page.
+	Title string `yaml:"title"`
+	// LogoFile is an optional path to a JPEG or PNG logo printed in the header next to the title.
+	LogoFile string `yaml:"logo_file"`
+	// Disclaimer is printed in the footer of every page.
+	Disclaimer string `yaml:"disclaimer"`
+}
+
+// Programs contains the annual interest rates of the loan programs in percent.
+// The rates are applied to new calculations as soon as the configuration is reloaded.
+type Programs struct {
+	// Base is the rate of the base program.
+	Base uint8 `yaml:"base"`
+	// Military is the rate of the military program.
+	Military uint8 `yaml:"military"`
+	// Salary is the rate of the salary (corporate) program.
+	Salary uint8 `yaml:"salary"`
+}
+
+// CORS contains the cross-origin resource sharing settings for browser-based clients.
+type CORS struct {
+	// Enabled turns CORS handling on, including answering OPTIONS preflight requests.
+	Enabled bool `yaml:"enabled"`
+	// AllowedOrigins is the list of origins allowed to call the service, "*" allows any origin.
+	AllowedOrigins []string `yaml:"allowed_origins"`
+	// AllowedMethods is the list of methods allowed in cross-origin requests.
+	AllowedMethods []string `yaml:"allowed_methods"`
+	// AllowedHeaders is the list of request headers allowed in cross-origin requests.
+	AllowedHeaders []string `yaml:"allowed_headers"`
+	// MaxAge is how long browsers may cache the result of a preflight request.
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+// RateLimit contains the token bucket rate limiting settings. Each client (identified by its API key
+// or, without authentication, by its IP address) gets its own bucket.
+type RateLimit struct {
+	// Enabled turns rate limiting on for the calculation and cache endpoints.
+	Enabled bool `yaml:"enabled"`
+	// RequestsPerSecond is the rate at which tokens are added to a client's bucket.
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	// Burst is the capacity of a client's bucket, i.e. the number of requests allowed at once.
+	Burst int `yaml:"burst"`
+	// IdleTimeout is the time after which the bucket of an inactive client is dropped.
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+}
+
+// Cache contains the settings of the calculation cache. They apply on restart.
+type Cache struct {
+	// Memoize makes a calculation identical to one made before by the same client return the stored entry
+	// instead of storing it again.
+	Memoize bool `yaml:"memoize"`
+	// History records every calculation request, including the ones answered from the cache, in the
+	// request history served by /cache/history.
+	History bool `yaml:"history"`
+	// HistorySize is the number of the latest requests kept in the history, the older ones are dropped.
+	HistorySize int `yaml:"history_size"`
+}
+
+// Stream contains the settings of the /cache/stream event stream. They apply to the streams opened after
+// a reload.
+type Stream struct {
+	// Buffer is how many calculations are queued for a stream. A client that falls further behind is
+	// disconnected, and catches up by reconnecting with the Last-Event-ID header.
+	Buffer int `yaml:"buffer"`
+	// HeartbeatInterval is how often a comment is sent on idle streams, so that proxies keep them open.
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
+}
+
+// Idempotency contains the settings of the Idempotency-Key header of /execute. The responses to keys are
+// kept in memory, so they are lost on restart.
+type Idempotency struct {
+	// TTL is how long the response to a key is kept and replayed; afterwards the key can be reused.
+	TTL time.Duration `yaml:"ttl"`
+	// MaxKeys is the maximum number of stored keys, so that clients cannot exhaust memory with new keys; the
+	// oldest completed keys are dropped for new ones.
+	MaxKeys int `yaml:"max_keys"`
+}
+
+// Webhooks contains the settings of the webhook notifications: every new calculation is posted to the endpoints,
+// signed with their secret, and retried with exponential backoff when an endpoint fails. They apply on restart.
+type Webhooks struct {
+	// Enabled turns the webhook notifications on.
+	Enabled bool `yaml:"enabled"`
+	// Endpoints is the list of endpoints receiving the notifications, more can be registered with the
+	// /admin/webhooks endpoint.
+	Endpoints []WebhookEndpoint `yaml:"endpoints"`
+	// StateFile is an optional path to the file keeping the endpoints registered through the API and the
+	// undelivered events across restarts. Without it they are kept in memory only.
+	StateFile string `yaml:"state_file"`
+	// Timeout is the time allowed for an endpoint to answer a notification.
+	Timeout time.Duration `yaml:"timeout"`
+	// InitialBackoff is the delay before retrying a failed notification, doubled after every failure.
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	// MaxBackoff is the longest delay between the retries.
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+	// MaxAttempts is the number of attempts after which an undelivered event is dropped.
+	MaxAttempts int `yaml:"max_attempts"`
+	// MaxQueue is the number of undelivered events kept for all endpoints, the oldest ones are dropped beyond it.
+	MaxQueue int `yaml:"max_queue"`
+}
+
+// WebhookEndpoint describes an endpoint receiving the webhook notifications.
+type WebhookEndpoint struct {
+	// URL is the HTTP or HTTPS URL the events are posted to.
+	URL string `yaml:"url"`
+	// Secret is the key of the HMAC-SHA256 signatures of the events.
+	Secret string `yaml:"secret"`
+	// ClientID limits the notifications to the calculations of a client, all clients are sent when empty.
+	ClientID string `yaml:"client_id"`
+}
+
+// Admin contains the settings of the administrative endpoints under /admin, which are only served when an
+// admin key is configured.
+type Admin struct {
+	// KeyHash is the hex-encoded SHA-256 hash of the admin key, sent like the API keys.
+	KeyHash string `yaml:"key_hash"`
+}
+
+// Server contains configuration settings related to the HTTP server: the listen address, timeouts and TLS.
+type Server struct {
+	// Host is the address the server binds to, empty means all interfaces.
+	Host string `yaml:"host"`
+	// Port is the port number on which the server will listen for incoming requests.
+	Port int `yaml:"port"`
+	// ReadHeaderTimeout is the time allowed to read the request headers.
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	// ReadTimeout is the time allowed to read the entire request, including the body.
+	ReadTimeout time.Duration `yaml:"read_timeout"`
+	// WriteTimeout is the time allowed to write the response.
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	// ShutdownTimeout is the time allowed for in-flight requests to finish on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// PreStopDelay is the time the server keeps serving on shutdown after failing the readiness probe, so that
+	// load balancers stop routing to it before the connections are drained. Zero stops without a delay.
+	PreStopDelay time.Duration `yaml:"pre_stop_delay"`
+	// TLS contains the certificate settings, the server uses plain HTTP when they are empty.
+	TLS TLS `yaml:"tls"`
+}
+
+// GRPC contains the settings of the gRPC API. It is served from the same process on its own port, with the
+// same storage, authentication, rate limiting and TLS certificate as the HTTP server.
+type GRPC struct {
+	// Enabled turns the gRPC API on.
+	Enabled bool `yaml:"enabled"`
+	// Port is the port number on which the gRPC server listens, on the same host as the HTTP server.
+	Port int `yaml:"port"`
+}
+
+// TLS contains the paths of the PEM-encoded certificate chain and private key used to serve HTTPS.
+// The files are watched and reloaded when they change, so that certificates can be rotated without a restart.
+type TLS struct {
+	// CertFile is the path to the certificate chain.
+	CertFile string `yaml:"cert_file"`
+	// KeyFile is the path to the private key.
+	KeyFile string `yaml:"key_file"`
 }
 
-// LoadConfig loads the configuration from the specified YAML file. It reads the file, unmarshals
-// the content into a Config structure, and returns the Config object or an error if something goes wrong.
+// Enabled reports whether HTTPS is configured.
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// Auth contains the API key authentication settings. When authentication is disabled, the API is open
+// to every caller and no client identity is attached to requests.
+type Auth struct {
+	// Enabled turns API key authentication on for the calculation and cache endpoints.
+	Enabled bool `yaml:"enabled"`
+	// KeysFile is an optional path to a YAML file with additional API keys in the same format as Keys.
+	KeysFile string `yaml:"keys_file"`
+	// Keys is the list of API keys accepted by the service.
+	Keys []APIKey `yaml:"keys"`
+}
+
+// APIKey describes a single client API key. Only the SHA-256 hash of the key is stored, so that the
+// configuration does not leak usable credentials.
+type APIKey struct {
+	// ClientID is the identity attached to the requests authenticated with this key.
+	ClientID string `yaml:"client_id"`
+	// KeyHash is the hex-encoded SHA-256 hash of the API key.
+	KeyHash string `yaml:"key_hash"`
+	// DailyQuota is the maximum number of requests per UTC day, zero means unlimited.
+	DailyQuota int `yaml:"daily_quota"`
+}
+
+// LoadConfig loads the configuration from the specified YAML file. It starts from the defaults, overlays the
+// content of the file and the MORTGAGE_* environment variables, and validates the result. The file is looked up
+// as given first and then, for backward compatibility, relative to the ./internal/config/ directory.
+// It returns the Config object or an error if something goes wrong.
 func LoadConfig(filename string) (*Config, error) {
-	// Define the base directory for configuration files
-	basePath := "./internal/config/"
+	// Resolve the file path
+	path := resolvePath(filename)
 
-	// Convert filename to an absolute path
-	absFilename, err := filepath.Abs(filepath.Clean(filename))
+	// Read the configuration file
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to normalize filename: %w", err)
+		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
 	}
 
-	// Ensure the file is inside the base directory
-	// If the file is already in the base directory, use it directly
-	if !strings.HasPrefix(absFilename, basePath) {
-		// If the file is outside basePath, join it with basePath
-		absFilename = filepath.Join(basePath, filename)
+	return parse(data)
+}
+
+// Load loads the configuration from the given path, as passed with the --config flag. When the path is empty,
+// the default locations are tried in order and, if none of them exists, the defaults are used, so that the
+// binary can run without a configuration file. Environment variable overrides are applied in every case.
+func Load(path string) (*Config, error) {
+	if path = Locate(path); path != "" {
+		return LoadConfig(path)
 	}
 
-	// Clean the file path to avoid duplicate basePath entries
-	absFilename = filepath.Clean(absFilename)
+	return parse(nil)
+}
 
-	// Read the configuration file
-	data, err := os.ReadFile(absFilename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+// Locate returns the configuration file to load: the given path if it is not empty, otherwise the first
+// existing default location, or an empty string if there is none.
+func Locate(path string) string {
+	if path != "" {
+		return path
 	}
 
-	// Create an instance of Config to store the parsed data
-	var config Config
+	for _, candidate := range defaultPaths() {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
 
-	// Unmarshal YAML data into the Config structure
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	return ""
+}
+
+// parse builds the configuration from the defaults, the YAML data and the environment, and validates it.
+func parse(data []byte) (*Config, error) {
+	// Create an instance of Config populated with the defaults
+	config := Default()
+
+	// Unmarshal YAML data into the Config structure, keeping the defaults for missing fields
+	if err := yaml.Unmarshal(data, config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
 	}
 
+	// Apply the environment variable overrides
+	if err := ApplyEnv(config, os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	// Validate the resulting configuration
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Return the populated Config object
-	return &config, nil
+	return config, nil
+}
+
+// resolvePath returns the filename itself if it exists, otherwise the filename joined with the base directory.
+func resolvePath(filename string) string {
+	cleaned := filepath.Clean(filename)
+	if _, err := os.Stat(cleaned); err == nil {
+		return cleaned
+	}
+	return filepath.Join(basePath, cleaned)
+}
+
+// defaultPaths returns the locations where the configuration file is looked up when no path is given:
+// the base directory relative to the working directory and relative to the executable.
+func defaultPaths() []string {
+	paths := []string{filepath.Join(basePath, DefaultFilename)}
+	if exe, err := os.Executable(); err == nil {
+		paths = append(paths, filepath.Join(filepath.Dir(exe), basePath, DefaultFilename))
+	}
+	return paths
+}
+
+// LoadKeysFile loads API keys from a YAML file with a top-level "keys" list.
+func LoadKeysFile(filename string) ([]APIKey, error) {
+	// Read the keys file
+	data, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys file %s: %w", filename, err)
+	}
+
+	// Unmarshal YAML data into the keys structure
+	var file struct {
+		Keys []APIKey `yaml:"keys"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal keys YAML: %w", err)
+	}
+
+	return file.Keys, nil
+}
+
+// Rates returns the program rates in the form used by the calculation engine.
+func (p Programs) Rates() mortgage.Rates {
+	return mortgage.Rates{Base: p.Base, Military: p.Military, Salary: p.Salary}
 }
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	errs "sber/pkg/errors"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig_Success(t *testing.T) {
@@ -21,6 +24,11 @@ func TestLoadConfig_Success(t *testing.T) {
 	content := []byte(`
 server:
   port: 8080
+rate_limit:
+  enabled: true
+  requests_per_second: 2.5
+  burst: 5
+  idle_timeout: 10m
 `)
 
 	// Create a file with the provided content
@@ -42,6 +50,12 @@ server:
 	if cfg.Server.Port != 8080 {
 		t.Errorf("expected port 8080, got %d", cfg.Server.Port)
 	}
+	if !cfg.RateLimit.Enabled || cfg.RateLimit.RequestsPerSecond != 2.5 || cfg.RateLimit.Burst != 5 {
+		t.Errorf("unexpected rate limit settings: %+v", cfg.RateLimit)
+	}
+	if cfg.RateLimit.IdleTimeout != 10*time.Minute {
+		t.Errorf("expected idle timeout 10m, got %v", cfg.RateLimit.IdleTimeout)
+	}
 }
 
 func TestLoadConfig_FileNotFound(t *testing.T) {
@@ -56,3 +70,209 @@ func TestLoadConfig_FileNotFound(t *testing.T) {
 		t.Errorf("expected 'file not found' error, got: %v", err)
 	}
 }
+
+func TestLoadKeysFile(t *testing.T) {
+	// Create a temporary keys file
+	keysFile := filepath.Join(t.TempDir(), "keys.yml")
+	content := []byte(`
+keys:
+  - client_id: partner-bank
+    key_hash: 4c806362b613f7496abf284146efd31da90e4b16169fe001841ca17290f427c4
+    daily_quota: 100
+`)
+	if err := os.WriteFile(keysFile, content, 0600); err != nil {
+		t.Fatalf("failed to create keys file: %v", err)
+	}
+
+	keys, err := LoadKeysFile(keysFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Verify that the keys were loaded correctly
+	if len(keys) != 1 {
+		t.Fatalf("expected 1 key, got %d", len(keys))
+	}
+	if keys[0].ClientID != "partner-bank" || keys[0].DailyQuota != 100 {
+		t.Errorf("unexpected key: %+v", keys[0])
+	}
+
+	// Verify that a missing file is reported
+	if _, err := LoadKeysFile(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Error("expected error for missing keys file")
+	}
+}
+
+func TestLoadConfig_AbsolutePath(t *testing.T) {
+	// Create a configuration file outside of the base directory
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("server:\n  port: 9090\n"), 0600); err != nil {
+		t.Fatalf("failed to create config file: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Server.Port != 9090 {
+		t.Errorf("expected port 9090, got %d", cfg.Server.Port)
+	}
+
+	// Fields missing in the file keep their defaults
+	if cfg.RateLimit.Burst != Default().RateLimit.Burst {
+		t.Errorf("expected default burst %d, got %d", Default().RateLimit.Burst, cfg.RateLimit.Burst)
+	}
+}
+
+func TestLoad_DefaultsWithoutFile(t *testing.T) {
+	// Run from an empty directory, so that no default location exists
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("failed to change working directory: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Server.Port != 8080 {
+		t.Errorf("expected default port 8080, got %d", cfg.Server.Port)
+	}
+
+	// An explicitly given file must exist
+	if _, err := Load("missing.yml"); err == nil {
+		t.Error("expected error for missing explicit config file")
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"MORTGAGE_SERVER_PORT":                    "9000",
+		"MORTGAGE_SERVER_SHUTDOWN_TIMEOUT":        "30s",
+		"MORTGAGE_SERVER_PRE_STOP_DELAY":          "3s",
+		"MORTGAGE_SERVER_TLS_CERT_FILE":           "/etc/tls/cert.pem",
+		"MORTGAGE_AUTH_ENABLED":                   "true",
+		"MORTGAGE_AUTH_KEYS":                      "[{client_id: bank, key_hash: abc, daily_quota: 5}]",
+		"MORTGAGE_RATE_LIMIT_REQUESTS_PER_SECOND": "2.5",
+		"MORTGAGE_RATE_LIMIT_IDLE_TIMEOUT":        "1m30s",
+		"MORTGAGE_CORS_ALLOWED_ORIGINS":           "https://a.example, https://b.example",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	cfg := Default()
+	if err := ApplyEnv(cfg, lookup); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Verify that every kind of field was overridden
+	if cfg.Server.Port != 9000 {
+		t.Errorf("expected port 9000, got %d", cfg.Server.Port)
+	}
+	if cfg.Server.ShutdownTimeout != 30*time.Second || cfg.Server.PreStopDelay != 3*time.Second || cfg.Server.TLS.CertFile != "/etc/tls/cert.pem" {
+		t.Errorf("unexpected server settings: %+v", cfg.Server)
+	}
+	if !cfg.Auth.Enabled || len(cfg.Auth.Keys) != 1 || cfg.Auth.Keys[0].ClientID != "bank" || cfg.Auth.Keys[0].DailyQuota != 5 {
+		t.Errorf("unexpected auth settings: %+v", cfg.Auth)
+	}
+	if cfg.RateLimit.RequestsPerSecond != 2.5 || cfg.RateLimit.IdleTimeout != 90*time.Second {
+		t.Errorf("unexpected rate limit settings: %+v", cfg.RateLimit)
+	}
+	if len(cfg.CORS.AllowedOrigins) != 2 || cfg.CORS.AllowedOrigins[1] != "https://b.example" {
+		t.Errorf("unexpected allowed origins: %v", cfg.CORS.AllowedOrigins)
+	}
+
+	// Invalid values are reported with the variable name
+	env = map[string]string{"MORTGAGE_SERVER_PORT": "not-a-number"}
+	err := ApplyEnv(Default(), lookup)
+	if err == nil || !strings.Contains(err.Error(), "MORTGAGE_SERVER_PORT") {
+		t.Errorf("expected error mentioning MORTGAGE_SERVER_PORT, got %v", err)
+	}
+}
+
+func TestEnvNames(t *testing.T) {
+	names := strings.Join(EnvNames(), " ")
+	for _, expected := range []string{"MORTGAGE_SERVER_PORT", "MORTGAGE_GRPC_PORT", "MORTGAGE_CACHE_MEMOIZE", "MORTGAGE_AUTH_KEYS_FILE", "MORTGAGE_CORS_MAX_AGE"} {
+		if !strings.Contains(names, expected) {
+			t.Errorf("expected %s in %s", expected, names)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalidLogo := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(invalidLogo, []byte("not an image"), 0600); err != nil {
+		t.Fatalf("failed to write logo: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		expected string
+	}{
+		{"Defaults are valid", func(cfg *Config) {}, ""},
+		{"Port out of range", func(cfg *Config) { cfg.Server.Port = 70000 }, "server.port"},
+		{"gRPC port out of range", func(cfg *Config) {
+			cfg.GRPC.Enabled = true
+			cfg.GRPC.Port = 0
+		}, "grpc.port"},
+		{"gRPC port shared with HTTP", func(cfg *Config) {
+			cfg.GRPC.Enabled = true
+			cfg.GRPC.Port = cfg.Server.Port
+		}, "grpc.port must differ"},
+		{"Auth without keys", func(cfg *Config) { cfg.Auth.Enabled = true }, "auth.keys"},
+		{"Rate limit without rate", func(cfg *Config) {
+			cfg.RateLimit.Enabled = true
+			cfg.RateLimit.RequestsPerSecond = 0
+		}, "rate_limit.requests_per_second"},
+		{"Zero stream buffer", func(cfg *Config) { cfg.Stream.Buffer = 0 }, "stream.buffer"},
+		{"Zero heartbeat interval", func(cfg *Config) { cfg.Stream.HeartbeatInterval = 0 }, "stream.heartbeat_interval"},
+		{"Zero history size", func(cfg *Config) { cfg.Cache.History, cfg.Cache.HistorySize = true, 0 }, "cache.history_size"},
+		{"Zero idempotency TTL", func(cfg *Config) { cfg.Idempotency.TTL = 0 }, "idempotency.ttl"},
+		{"Zero idempotency keys", func(cfg *Config) { cfg.Idempotency.MaxKeys = 0 }, "idempotency.max_keys"},
+		{"Webhook backoff shorter than initial", func(cfg *Config) {
+			cfg.Webhooks.Enabled = true
+			cfg.Webhooks.MaxBackoff = time.Millisecond
+		}, "webhooks.max_backoff"},
+		{"Relative webhook URL", func(cfg *Config) {
+			cfg.Webhooks.Enabled = true
+			cfg.Webhooks.Endpoints = []WebhookEndpoint{{URL: "/hooks", Secret: "secret"}}
+		}, "webhooks.endpoints[0].url"},
+		{"Webhook without secret", func(cfg *Config) {
+			cfg.Webhooks.Enabled = true
+			cfg.Webhooks.Endpoints = []WebhookEndpoint{{URL: "https://crm.example.com/hooks"}}
+		}, "webhooks.endpoints[0].secret"},
+		{"Negative max age", func(cfg *Config) { cfg.CORS.MaxAge = -time.Second }, "cors.max_age"},
+		{"Zero write timeout", func(cfg *Config) { cfg.Server.WriteTimeout = 0 }, "server.write_timeout"},
+		{"Negative pre-stop delay", func(cfg *Config) { cfg.Server.PreStopDelay = -time.Second }, "server.pre_stop_delay"},
+		{"Certificate without key", func(cfg *Config) { cfg.Server.TLS.CertFile = "cert.pem" }, "server.tls"},
+		{"Empty offer title", func(cfg *Config) { cfg.Offer.Title = " " }, "offer.title"},
+		{"Unsupported logo format", func(cfg *Config) { cfg.Offer.LogoFile = "logo.svg" }, "offer.logo_file"},
+		{"Missing logo", func(cfg *Config) { cfg.Offer.LogoFile = "missing.png" }, "offer.logo_file must be a readable"},
+		{"Invalid logo", func(cfg *Config) { cfg.Offer.LogoFile = invalidLogo }, "offer.logo_file must be a readable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+			err := cfg.Validate()
+
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, errs.ErrInvalidConfig) || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected invalid config error mentioning %s, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register the JPEG decoder for the offer logo
+	_ "image/png"  // Register the PNG decoder for the offer logo
+	"net/url"
+	"os"
+	"path/filepath"
+	errs "sber/pkg/errors"
+	"strings"
+	"time"
+)
+
+// Default returns the configuration used when no configuration file is present.
+func Default() *Config {
+	cfg := &Config{}
+	cfg.Server = Server{
+		Port:              8080,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		ShutdownTimeout:   5 * time.Second,
+	}
+	cfg.GRPC = GRPC{
+		Port: 9090,
+	}
+	cfg.RateLimit = RateLimit{
+		RequestsPerSecond: 10,
+		Burst:             20,
+		IdleTimeout:       10 * time.Minute,
+	}
+	cfg.Cache = Cache{
+		HistorySize: 10000,
+	}
+	cfg.Stream = Stream{
+		Buffer:            64,
+		HeartbeatInterval: 15 * time.Second,
+	}
+	cfg.Idempotency = Idempotency{
+		TTL:     24 * time.Hour,
+		MaxKeys: 10000,
+	}
+	cfg.Webhooks = Webhooks{
+		Timeout:        10 * time.Second,
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Minute,
+		MaxAttempts:    20,
+		MaxQueue:       10000,
+	}
+	cfg.CORS = CORS{
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "X-API-Key", "X-Request-ID", "Idempotency-Key"},
+		MaxAge:         10 * time.Minute,
+	}
+	cfg.Programs = Programs{
+		Base:     10,
+		Military: 9,
+		Salary:   8,
+	}
+	cfg.Offer = Offer{
+		Title: "Mortgage offer",
+		Disclaimer: "This document is provided for information purposes only and is not a public offer. " +
+			"The final terms of the loan are subject to credit approval and may differ from this calculation.",
+	}
+	return cfg
+}
+
+// Validate checks the configuration for values the service cannot run with. All problems are reported
+// at once, each wrapping errs.ErrInvalidConfig.
+func (c *Config) Validate() error {
+	var problems []error
+	invalid := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf("%w: "+format, append([]any{errs.ErrInvalidConfig}, args...)...))
+	}
+
+	// Server settings
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		invalid("server.port must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			invalid("%s must be positive, got %v", timeout.name, timeout.value)
+		}
+	}
+	if c.Server.PreStopDelay < 0 {
+		invalid("server.pre_stop_delay must not be negative, got %v", c.Server.PreStopDelay)
+	}
+	if c.Server.TLS.Enabled() && (c.Server.TLS.CertFile == "" || c.Server.TLS.KeyFile == "") {
+		invalid("server.tls.cert_file and server.tls.key_file must be set together")
+	}
+
+	// gRPC settings
+	if c.GRPC.Enabled {
+		if c.GRPC.Port < 1 || c.GRPC.Port > 65535 {
+			invalid("grpc.port must be between 1 and 65535, got %d", c.GRPC.Port)
+		} else if c.GRPC.Port == c.Server.Port {
+			invalid("grpc.port must differ from server.port, got %d", c.GRPC.Port)
+		}
+	}
+
+	// Authentication settings
+	if c.Auth.Enabled && len(c.Auth.Keys) == 0 && c.Auth.KeysFile == "" {
+		invalid("auth.keys or auth.keys_file must be set when auth is enabled")
+	}
+
+	// Rate limiting settings
+	if c.RateLimit.Enabled {
+		if c.RateLimit.RequestsPerSecond <= 0 {
+			invalid("rate_limit.requests_per_second must be positive, got %v", c.RateLimit.RequestsPerSecond)
+		}
+		if c.RateLimit.Burst < 1 {
+			invalid("rate_limit.burst must be at least 1, got %d", c.RateLimit.Burst)
+		}
+	}
+	if c.RateLimit.IdleTimeout < 0 {
+		invalid("rate_limit.idle_timeout must not be negative, got %v", c.RateLimit.IdleTimeout)
+	}
+
+	// Cache settings
+	if c.Cache.History && c.Cache.HistorySize < 1 {
+		invalid("cache.history_size must be positive, got %d", c.Cache.HistorySize)
+	}
+
+	// Stream settings
+	if c.Stream.Buffer < 1 {
+		invalid("stream.buffer must be positive, got %d", c.Stream.Buffer)
+	}
+	if c.Stream.HeartbeatInterval <= 0 {
+		invalid("stream.heartbeat_interval must be positive, got %v", c.Stream.HeartbeatInterval)
+	}
+
+	// Idempotency settings
+	if c.Idempotency.TTL <= 0 {
+		invalid("idempotency.ttl must be positive, got %v", c.Idempotency.TTL)
+	}
+	if c.Idempotency.MaxKeys <= 0 {
+		invalid("idempotency.max_keys must be positive, got %d", c.Idempotency.MaxKeys)
+	}
+
+	// Webhook settings
+	if c.Webhooks.Enabled {
+		if c.Webhooks.Timeout <= 0 {
+			invalid("webhooks.timeout must be positive, got %v", c.Webhooks.Timeout)
+		}
+		if c.Webhooks.InitialBackoff <= 0 {
+			invalid("webhooks.initial_backoff must be positive, got %v", c.Webhooks.InitialBackoff)
+		}
+		if c.Webhooks.MaxBackoff < c.Webhooks.InitialBackoff {
+			invalid("webhooks.max_backoff must not be less than webhooks.initial_backoff, got %v", c.Webhooks.MaxBackoff)
+		}
+		if c.Webhooks.MaxAttempts < 1 {
+			invalid("webhooks.max_attempts must be at least 1, got %d", c.Webhooks.MaxAttempts)
+		}
+		if c.Webhooks.MaxQueue < 1 {
+			invalid("webhooks.max_queue must be at least 1, got %d", c.Webhooks.MaxQueue)
+		}
+		for i, endpoint := range c.Webhooks.Endpoints {
+			if err := ValidateWebhookURL(endpoint.URL); err != nil {
+				invalid("webhooks.endpoints[%d].url %v", i, err)
+			}
+			if endpoint.Secret == "" {
+				invalid("webhooks.endpoints[%d].secret must be set", i)
+			}
+		}
+	}
+
+	// CORS settings
+	if c.CORS.Enabled && len(c.CORS.AllowedOrigins) == 0 {
+		invalid("cors.allowed_origins must be set when cors is enabled")
+	}
+	if c.CORS.MaxAge < 0 {
+		invalid("cors.max_age must not be negative, got %v", c.CORS.MaxAge)
+	}
+
+	// Program rates
+	rates := []struct {
+		name  string
+		value uint8
+	}{
+		{"programs.base", c.Programs.Base},
+		{"programs.military", c.Programs.Military},
+		{"programs.salary", c.Programs.Salary},
+	}
+	for _, rate := range rates {
+		if rate.value < 1 || rate.value > 100 {
+			invalid("%s must be between 1 and 100, got %d", rate.name, rate.value)
+		}
+	}
+
+	// Offer documents
+	if strings.TrimSpace(c.Offer.Title) == "" {
+		invalid("offer.title must not be empty")
+	}
+	if c.Offer.LogoFile != "" {
+		switch strings.ToLower(filepath.Ext(c.Offer.LogoFile)) {
+		case ".jpg", ".jpeg", ".png":
+			if err := checkImage(c.Offer.LogoFile); err != nil {
+				invalid("offer.logo_file must be a readable JPEG or PNG image: %v", err)
+			}
+		default:
+			invalid("offer.logo_file must be a JPEG or PNG image, got %s", c.Offer.LogoFile)
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// checkImage checks that the file contains a JPEG or PNG image that can be decoded.
+func checkImage(path string) error {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, _, err = image.Decode(f); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// ValidateWebhookURL checks that the URL of a webhook endpoint is an absolute HTTP or HTTPS URL.
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http or https URL, got %q", raw)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables that override configuration fields.
+const EnvPrefix = "MORTGAGE"
+
+// ApplyEnv overrides the configuration fields with the values of the matching environment variables.
+// The variable name is built from the YAML keys of the field path joined with underscores and upper-cased,
+// e.g. server.port is overridden by MORTGAGE_SERVER_PORT and rate_limit.burst by MORTGAGE_RATE_LIMIT_BURST.
+//
+// String values are used as is, string lists are comma-separated, and all other values (numbers, booleans,
+// durations such as "10m", lists of keys) are parsed as YAML, so that e.g. MORTGAGE_AUTH_KEYS can hold
+// a flow sequence like [{client_id: bank, key_hash: ...}].
+func ApplyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	return applyEnv(reflect.ValueOf(cfg).Elem(), EnvPrefix, lookup)
+}
+
+// EnvNames returns the names of all environment variables that override configuration fields.
+func EnvNames() []string {
+	var names []string
+	collectEnvNames(reflect.TypeOf(Config{}), EnvPrefix, &names)
+	return names
+}
+
+// applyEnv walks the struct fields recursively and sets those that have a matching environment variable.
+func applyEnv(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, ok := envName(prefix, t.Field(i))
+		if !ok {
+			continue
+		}
+		field := v.Field(i)
+
+		// Nested sections are walked, their fields get the section name as prefix
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, name, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setField(field, raw); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setField parses the raw environment value into the field.
+func setField(field reflect.Value, raw string) error {
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(raw)
+		return nil
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(raw, "["):
+		var values []string
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+		return nil
+	}
+
+	// Decode into a fresh value, so that a failed parse leaves the field untouched
+	parsed := reflect.New(field.Type())
+	if err := yaml.Unmarshal([]byte(raw), parsed.Interface()); err != nil {
+		return err
+	}
+	field.Set(parsed.Elem())
+	return nil
+}
+
+// collectEnvNames walks the struct type recursively and collects the environment variable names of its fields.
+func collectEnvNames(t reflect.Type, prefix string, names *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		name, ok := envName(prefix, t.Field(i))
+		if !ok {
+			continue
+		}
+		if t.Field(i).Type.Kind() == reflect.Struct {
+			collectEnvNames(t.Field(i).Type, name, names)
+			continue
+		}
+		*names = append(*names, name)
+	}
+}
+
+// envName returns the environment variable name of a struct field, derived from its YAML key.
+func envName(prefix string, field reflect.StructField) (string, bool) {
+	key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if key == "" || key == "-" || !field.IsExported() {
+		return "", false
+	}
+	return prefix + "_" + strings.ToUpper(key), true
+}
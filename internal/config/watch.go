@@ -0,0 +1,109 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Current holds the active configuration and allows it to be swapped atomically while it is being read.
+type Current struct {
+	cfg atomic.Pointer[Config]
+}
+
+// NewCurrent creates a Current holding the given configuration.
+func NewCurrent(cfg *Config) *Current {
+	c := &Current{}
+	c.cfg.Store(cfg)
+	return c
+}
+
+// Load returns the active configuration. The returned value must not be modified.
+func (c *Current) Load() *Config {
+	return c.cfg.Load()
+}
+
+// Store replaces the active configuration.
+func (c *Current) Store(cfg *Config) {
+	c.cfg.Store(cfg)
+}
+
+// Watcher reloads the configuration file into a Current when the file changes or when triggered,
+// e.g. by SIGHUP. Invalid configurations are rejected and logged, and the previous one keeps serving.
+//
+// Only the settings read per request (such as the program rates) take effect on reload; the server
+// address, timeouts, TLS, authentication, rate limiting and CORS settings require a restart.
+type Watcher struct {
+	path     string        // Path to the configuration file
+	current  *Current      // The configuration the reloaded values are stored in
+	interval time.Duration // How often the file is checked for changes
+	mu       sync.Mutex    // Serializes reloads
+	modTime  time.Time     // Modification time of the file at the last reload
+}
+
+// NewWatcher creates a Watcher for the configuration file, checking it for changes at the given interval.
+func NewWatcher(path string, current *Current, interval time.Duration) *Watcher {
+	w := &Watcher{path: path, current: current, interval: interval}
+	if info, err := os.Stat(path); err == nil {
+		w.modTime = info.ModTime()
+	}
+	return w
+}
+
+// Run checks the file for changes and reloads it on every value received from trigger, until ctx is done.
+func (w *Watcher) Run(ctx context.Context, trigger <-chan os.Signal) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-trigger:
+			slog.Info("reloading config on signal", "signal", sig.String(), "path", w.path)
+			w.Reload()
+		case <-ticker.C:
+			if w.changed() {
+				slog.Info("reloading changed config", "path", w.path)
+				w.Reload()
+			}
+		}
+	}
+}
+
+// Reload re-parses and validates the configuration file and swaps it in. On failure the error is logged
+// and returned, and the active configuration is left unchanged.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Remember the modification time first, so that a broken file is not reloaded on every tick
+	if info, err := os.Stat(w.path); err == nil {
+		w.modTime = info.ModTime()
+	}
+
+	cfg, err := LoadConfig(w.path)
+	if err != nil {
+		slog.Error("rejected config reload, keeping the active config", "path", w.path, "error", err)
+		return err
+	}
+
+	w.current.Store(cfg)
+	slog.Info("config reloaded", "path", w.path)
+	return nil
+}
+
+// changed reports whether the file has a different modification time than at the last reload.
+func (w *Watcher) changed() bool {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !info.ModTime().Equal(w.modTime)
+}
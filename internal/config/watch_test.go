@@ -0,0 +1,86 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWatcherReload(t *testing.T) {
+	// Create a configuration file with the default rates
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("programs:\n  base: 10\n  military: 9\n  salary: 8\n"), 0600); err != nil {
+		t.Fatalf("failed to create config file: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	current := NewCurrent(cfg)
+	watcher := NewWatcher(path, current, time.Hour)
+
+	// A valid change is swapped in
+	if err := os.WriteFile(path, []byte("programs:\n  base: 11\n  military: 9\n  salary: 7\n"), 0600); err != nil {
+		t.Fatalf("failed to update config file: %v", err)
+	}
+	if err := watcher.Reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if current.Load().Programs.Salary != 7 || current.Load().Programs.Base != 11 {
+		t.Errorf("expected reloaded rates, got %+v", current.Load().Programs)
+	}
+
+	// An invalid change is rejected and the previous config keeps serving
+	if err := os.WriteFile(path, []byte("programs:\n  base: 0\n"), 0600); err != nil {
+		t.Fatalf("failed to update config file: %v", err)
+	}
+	if err := watcher.Reload(); err == nil {
+		t.Error("expected invalid config to be rejected")
+	}
+	if current.Load().Programs.Salary != 7 {
+		t.Errorf("expected previous rates to be kept, got %+v", current.Load().Programs)
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("server:\n  port: 8080\n"), 0600); err != nil {
+		t.Fatalf("failed to create config file: %v", err)
+	}
+	current := NewCurrent(Default())
+	watcher := NewWatcher(path, current, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	trigger := make(chan os.Signal, 1)
+	go watcher.Run(ctx, trigger)
+
+	// A reload is triggered by a signal
+	initial := current.Load()
+	trigger <- syscall.SIGHUP
+	waitFor(t, func() bool { return current.Load() != initial })
+
+	// A reload is triggered by a file change
+	if err := os.WriteFile(path, []byte("server:\n  port: 9090\n"), 0600); err != nil {
+		t.Fatalf("failed to update config file: %v", err)
+	}
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(path, future, future)
+	waitFor(t, func() bool { return current.Load().Server.Port == 9090 })
+}
+
+// waitFor polls the condition until it is true or the test times out.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
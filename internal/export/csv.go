@@ -0,0 +1,45 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// utf8BOM is written before CSV content, so that spreadsheet applications detect the UTF-8 encoding
+// of localized headers.
+const utf8BOM = "\uFEFF"
+
+// WriteCSV writes the sheets as CSV. Each sheet starts with its header row, and sheets are separated
+// by an empty line.
+func WriteCSV(w io.Writer, sheets []Sheet) error {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	for i, sheet := range sheets {
+		// Separate the sheets with an empty line
+		if i > 0 {
+			cw.Flush()
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+
+		if err := cw.Write(sheet.Header); err != nil {
+			return err
+		}
+		for _, row := range sheet.Rows {
+			record := make([]string, len(row))
+			for j, cell := range row {
+				record[j] = formatCell(cell)
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
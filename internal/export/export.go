@@ -0,0 +1,221 @@
+// Package export renders mortgage calculations and their payment schedules as documents that loan officers
+// can open in spreadsheet applications: CSV files and Excel XLSX workbooks.
+//
+// The documents are described as a list of sheets, each with a header row and data rows, which are then
+// written in the requested format. XLSX workbooks are produced with the standard library only.
+//
+// Types and Functions:
+//   - Format: The document format, negotiated from the format query parameter or the Accept header.
+//   - Language: The language of the column headers, negotiated from the lang query parameter or Accept-Language.
+//   - Sheet: A table with a name, a header row and data rows.
+//   - Calculation: Builds the sheets of a single calculation with its payment schedule.
+//   - Cache: Builds the sheet listing all stored calculations.
+//   - Write: Writes the sheets in the given format.
+package export
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"sber/pkg/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the format of an exported document.
+type Format string
+
+// Supported export formats.
+const (
+	// FormatCSV is a comma-separated values file. Multiple sheets are separated by an empty line.
+	FormatCSV Format = "csv"
+	// FormatXLSX is an Excel workbook with one worksheet per sheet.
+	FormatXLSX Format = "xlsx"
+)
+
+// Media types of the supported export formats.
+const (
+	contentTypeCSV  = "text/csv"
+	contentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// ContentType returns the media type of the format for the Content-Type header.
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return contentTypeXLSX
+	}
+	return contentTypeCSV + "; charset=utf-8"
+}
+
+// NegotiateFormat selects the export format from the format query parameter, which takes precedence,
+// or from the Accept header. CSV is used when neither expresses a preference. It returns false if the
+// requested format is not supported.
+func NegotiateFormat(query, accept string) (Format, bool) {
+	if query != "" {
+		switch Format(strings.ToLower(query)) {
+		case FormatCSV:
+			return FormatCSV, true
+		case FormatXLSX:
+			return FormatXLSX, true
+		}
+		return "", false
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return FormatCSV, true
+	}
+
+	// Use the first supported media range in the order given by the client
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		switch mediaType {
+		case contentTypeCSV, "text/*", "*/*":
+			return FormatCSV, true
+		case contentTypeXLSX:
+			return FormatXLSX, true
+		}
+	}
+	return "", false
+}
+
+// Sheet is a table of an exported document.
+type Sheet struct {
+	Name   string   // Name of the worksheet in XLSX workbooks
+	Header []string // Column headers
+	Rows   [][]any  // Data rows, cells are strings or integers
+}
+
+// Calculation builds the sheets of a single stored calculation: its parameters and aggregates,
+// followed by its payment schedule.
+func Calculation(entry models.CacheStorageFormat, schedule []models.Payment, lang Language) []Sheet {
+	summary := Sheet{
+		Name:   label(lang, "sheet_calculation"),
+		Header: []string{label(lang, "parameter"), label(lang, "value")},
+	}
+	header := calculationHeader(lang)
+	for i, value := range calculationRow(entry, lang) {
+		summary.Rows = append(summary.Rows, []any{header[i], value})
+	}
+
+	payments := Sheet{
+		Name: label(lang, "sheet_schedule"),
+		Header: []string{
+			label(lang, "number"),
+			label(lang, "date"),
+			label(lang, "amount"),
+			label(lang, "principal"),
+			label(lang, "interest"),
+			label(lang, "balance"),
+		},
+	}
+	for _, p := range schedule {
+		payments.Rows = append(payments.Rows, []any{p.Number, p.Date, p.Amount, p.Principal, p.Interest, p.Balance})
+	}
+
+	return []Sheet{summary, payments}
+}
+
+// Cache builds the sheet listing the given stored calculations, one row per calculation.
+func Cache(entries []models.CacheStorageFormat, lang Language) []Sheet {
+	sheet := Sheet{
+		Name:   label(lang, "sheet_cache"),
+		Header: calculationHeader(lang),
+	}
+	for _, entry := range entries {
+		sheet.Rows = append(sheet.Rows, calculationRow(entry, lang))
+	}
+	return []Sheet{sheet}
+}
+
+// calculationHeader returns the column headers describing a calculation.
+func calculationHeader(lang Language) []string {
+	return []string{
+		label(lang, "id"),
+		label(lang, "client"),
+		label(lang, "created_at"),
+		label(lang, "object_cost"),
+		label(lang, "initial_payment"),
+		label(lang, "months"),
+		label(lang, "program"),
+		label(lang, "rate"),
+		label(lang, "loan_sum"),
+		label(lang, "monthly_payment"),
+		label(lang, "overpayment"),
+		label(lang, "last_payment_date"),
+	}
+}
+
+// createdAtLayout is the layout of the creation times of the calculations in the exports.
+const createdAtLayout = "2006-01-02 15:04:05"
+
+// createdAt formats the creation time of a calculation in UTC, or returns an empty cell if it is unknown.
+func createdAt(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(createdAtLayout)
+}
+
+// calculationRow returns the cells describing a calculation, matching calculationHeader.
+func calculationRow(entry models.CacheStorageFormat, lang Language) []any {
+	return []any{
+		entry.ID,
+		entry.ClientID,
+		createdAt(entry.CreatedAt),
+		entry.Params.ObjectCost,
+		entry.Params.InitialPayment,
+		entry.Params.Months,
+		programName(entry.Program, lang),
+		int32(entry.Aggregates.Rate),
+		entry.Aggregates.LoanSum,
+		entry.Aggregates.MonthlyPayment,
+		entry.Aggregates.Overpayment,
+		entry.Aggregates.LastPaymentDate,
+	}
+}
+
+// programName returns the localized name of the selected loan program.
+func programName(program models.Program, lang Language) string {
+	switch {
+	case program.Base:
+		return label(lang, "program_base")
+	case program.Military:
+		return label(lang, "program_military")
+	case program.Salary:
+		return label(lang, "program_salary")
+	default:
+		return ""
+	}
+}
+
+// Write writes the sheets in the given format.
+func Write(w io.Writer, format Format, sheets []Sheet) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, sheets)
+	case FormatXLSX:
+		return WriteXLSX(w, sheets)
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// formatCell renders a cell value as text.
+func formatCell(v any) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case int32:
+		return strconv.FormatInt(int64(value), 10)
+	case int:
+		return strconv.Itoa(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	default:
+		return fmt.Sprint(value)
+	}
+}
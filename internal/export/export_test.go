@@ -0,0 +1,177 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		accept   string
+		expected Format
+		ok       bool
+	}{
+		{"Default", "", "", FormatCSV, true},
+		{"Query CSV", "csv", "", FormatCSV, true},
+		{"Query XLSX ignores case", "XLSX", "", FormatXLSX, true},
+		{"Unsupported query", "pdf", contentTypeXLSX, "", false},
+		{"Accept XLSX", "", contentTypeXLSX, FormatXLSX, true},
+		{"Accept CSV with charset", "", "text/csv; charset=utf-8", FormatCSV, true},
+		{"Accept wildcard", "", "*/*", FormatCSV, true},
+		{"First supported media type wins", "", "application/xml, " + contentTypeXLSX + ", text/csv", FormatXLSX, true},
+		{"Excluded media type", "", contentTypeXLSX + ";q=0", "", false},
+		{"Unsupported Accept", "", "application/json", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, ok := NegotiateFormat(tt.query, tt.accept)
+			if format != tt.expected || ok != tt.ok {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.expected, tt.ok, format, ok)
+			}
+		})
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		acceptLanguage string
+		expected       Language
+	}{
+		{"Default", "", "", English},
+		{"Query", "ru", "en-US", Russian},
+		{"Unsupported query falls back to header", "de", "ru-RU,ru;q=0.9", Russian},
+		{"First supported language wins", "", "de-DE, en;q=0.8, ru;q=0.5", English},
+		{"Unsupported header", "", "fr-FR", English},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if lang := NegotiateLanguage(tt.query, tt.acceptLanguage); lang != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, lang)
+			}
+		})
+	}
+}
+
+func TestLabelsComplete(t *testing.T) {
+	// Every language must translate every key of the default language
+	for lang, texts := range labels {
+		for key := range labels[English] {
+			if texts[key] == "" {
+				t.Errorf("Missing %s label for %s", lang, key)
+			}
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	entry := models.CacheStorageFormat{
+		ID:        3,
+		ClientID:  "bank, \"a\"",
+		CreatedAt: time.Date(2024, 1, 15, 12, 30, 0, 0, time.FixedZone("MSK", 3*60*60)),
+		Params:    models.Params{ObjectCost: 100000, InitialPayment: 20000, Months: 12},
+		Program:   models.Program{Military: true},
+		Aggregates: models.Aggregates{
+			Rate: 9, LoanSum: 80000, MonthlyPayment: 6997, Overpayment: 3964, LastPaymentDate: "2025-01-15",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, Cache([]models.CacheStorageFormat{entry}, Russian)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The content starts with a byte order mark, so that spreadsheets detect UTF-8
+	content, found := strings.CutPrefix(buf.String(), utf8BOM)
+	if !found {
+		t.Error("Expected byte order mark")
+	}
+
+	records, err := csv.NewReader(strings.NewReader(content)).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+	expected := [][]string{
+		calculationHeader(Russian),
+		{"3", "bank, \"a\"", "2024-01-15 09:30:00", "100000", "20000", "12", "Военная ипотека", "9", "80000", "6997", "3964", "2025-01-15"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), len(records))
+	}
+	for i := range expected {
+		if strings.Join(records[i], "|") != strings.Join(expected[i], "|") {
+			t.Errorf("Expected record %v, got %v", expected[i], records[i])
+		}
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	sheets := []Sheet{
+		{Name: "Summary: <1>", Header: []string{"Name", "Value"}, Rows: [][]any{{"a & b", int32(42)}}},
+		{Name: "", Header: []string{"Empty"}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, sheets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("expected a zip archive: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+	}
+
+	// Verify the package structure
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels",
+		"xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("Expected part %s", name)
+		}
+	}
+
+	// Sheet names are sanitized and defaulted, text is escaped and integers are numeric cells
+	if workbook := parts["xl/workbook.xml"]; !strings.Contains(workbook, `name="Summary_ &lt;1&gt;"`) ||
+		!strings.Contains(workbook, `name="Sheet2"`) {
+		t.Errorf("Unexpected workbook %s", workbook)
+	}
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, expected := range []string{
+		`<c r="A1" s="1" t="inlineStr"><is><t>Name</t></is></c>`,
+		`<c r="A2" s="0" t="inlineStr"><is><t>a &amp; b</t></is></c>`,
+		`<c r="B2" s="0"><v>42</v></c>`,
+	} {
+		if !strings.Contains(sheet, expected) {
+			t.Errorf("Expected %s in worksheet %s", expected, sheet)
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for index, expected := range tests {
+		if name := columnName(index); name != expected {
+			t.Errorf("Expected column %d to be %s, got %s", index, expected, name)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"strings"
+)
+
+// Language is the language of the column headers and program names of exported documents.
+type Language string
+
+// Supported languages.
+const (
+	// English is the default language.
+	English Language = "en"
+	// Russian is used when the client prefers it.
+	Russian Language = "ru"
+)
+
+// labels holds the localized texts by language and key.
+var labels = map[Language]map[string]string{
+	English: {
+		"sheet_calculation": "Calculation",
+		"sheet_schedule":    "Schedule",
+		"sheet_cache":       "Calculations",
+		"parameter":         "Parameter",
+		"value":             "Value",
+		"id":                "ID",
+		"client":            "Client",
+		"created_at":        "Created at (UTC)",
+		"object_cost":       "Object cost",
+		"initial_payment":   "Initial payment",
+		"months":            "Term, months",
+		"program":           "Program",
+		"rate":              "Rate, %",
+		"loan_sum":          "Loan sum",
+		"monthly_payment":   "Monthly payment",
+		"overpayment":       "Overpayment",
+		"last_payment_date": "Last payment date",
+		"number":            "No.",
+		"date":              "Date",
+		"amount":            "Payment",
+		"principal":         "Principal",
+		"interest":          "Interest",
+		"balance":           "Balance",
+		"program_base":      "Base",
+		"program_military":  "Military",
+		"program_salary":    "Salary",
+	},
+	Russian: {
+		"sheet_calculation": "Расчет",
+		"sheet_schedule":    "График платежей",
+		"sheet_cache":       "Расчеты",
+		"parameter":         "Параметр",
+		"value":             "Значение",
+		"id":                "ID",
+		"client":            "Клиент",
+		"created_at":        "Дата расчета (UTC)",
+		"object_cost":       "Стоимость объекта",
+		"initial_payment":   "Первоначальный взнос",
+		"months":            "Срок, мес.",
+		"program":           "Программа",
+		"rate":              "Ставка, %",
+		"loan_sum":          "Сумма кредита",
+		"monthly_payment":   "Ежемесячный платеж",
+		"overpayment":       "Переплата",
+		"last_payment_date": "Дата последнего платежа",
+		"number":            "№",
+		"date":              "Дата",
+		"amount":            "Платеж",
+		"principal":         "Основной долг",
+		"interest":          "Проценты",
+		"balance":           "Остаток долга",
+		"program_base":      "Базовая",
+		"program_military":  "Военная ипотека",
+		"program_salary":    "Корпоративная",
+	},
+}
+
+// label returns the localized text for the key, falling back to English.
+func label(lang Language, key string) string {
+	if text, ok := labels[lang][key]; ok {
+		return text
+	}
+	return labels[English][key]
+}
+
+// NegotiateLanguage selects the language from the lang query parameter, which takes precedence,
+// or from the first supported language in the Accept-Language header. English is the default.
+func NegotiateLanguage(query, acceptLanguage string) Language {
+	if lang, ok := parseLanguage(query); ok {
+		return lang
+	}
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if lang, ok := parseLanguage(tag); ok {
+			return lang
+		}
+	}
+	return English
+}
+
+// parseLanguage maps a language tag such as "ru-RU" to a supported language.
+func parseLanguage(tag string) (Language, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	if _, ok := labels[Language(primary)]; ok {
+		return Language(primary), true
+	}
+	return "", false
+}
@@ -0,0 +1,76 @@
+package grpcapi
+
+import (
+	mortgagev1 "sber/pkg/api/mortgage/v1"
+	"sber/pkg/models"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fromProgram converts the program flags of a request, treating a missing message as no program selected.
+func fromProgram(p *mortgagev1.Program) models.Program {
+	return models.Program{Salary: p.GetSalary(), Military: p.GetMilitary(), Base: p.GetBase()}
+}
+
+// toProgram converts the program flags to their protobuf message.
+func toProgram(p models.Program) *mortgagev1.Program {
+	return &mortgagev1.Program{Salary: p.Salary, Military: p.Military, Base: p.Base}
+}
+
+// toParams converts the calculation parameters to their protobuf message.
+func toParams(p models.Params) *mortgagev1.Params {
+	return &mortgagev1.Params{ObjectCost: p.ObjectCost, InitialPayment: p.InitialPayment, Months: p.Months}
+}
+
+// toAggregates converts the calculated aggregates to their protobuf message.
+func toAggregates(a models.Aggregates) *mortgagev1.Aggregates {
+	return &mortgagev1.Aggregates{
+		Rate:            uint32(a.Rate),
+		LoanSum:         a.LoanSum,
+		MonthlyPayment:  a.MonthlyPayment,
+		Overpayment:     a.Overpayment,
+		LastPaymentDate: a.LastPaymentDate,
+	}
+}
+
+// toResult converts a calculation result to its protobuf message.
+func toResult(r models.Result) *mortgagev1.Result {
+	return &mortgagev1.Result{
+		Params:     toParams(r.Params),
+		Program:    toProgram(r.Program),
+		Aggregates: toAggregates(r.Aggregates),
+	}
+}
+
+// toCalculation converts a cached calculation to its protobuf message.
+func toCalculation(c models.CacheStorageFormat) *mortgagev1.Calculation {
+	return &mortgagev1.Calculation{
+		Id:         c.ID,
+		ClientId:   c.ClientID,
+		Params:     toParams(c.Params),
+		Program:    toProgram(c.Program),
+		Aggregates: toAggregates(c.Aggregates),
+		Hits:       c.Hits,
+		CreatedAt:  toTimestamp(c.CreatedAt),
+		Source:     c.Source,
+		RequestId:  c.RequestID,
+		UserAgent:  c.UserAgent,
+	}
+}
+
+// toTimestamp converts a time to its protobuf message, leaving the zero time unset.
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// fromTimestamp converts a timestamp of a request, treating a missing message as the zero time.
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
@@ -0,0 +1,196 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"runtime/debug"
+	"sber/internal/metrics"
+	"sber/internal/middleware"
+	mortgagev1 "sber/pkg/api/mortgage/v1"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys read and written by the interceptors. gRPC metadata keys are lower-case versions of the
+// HTTP headers used by the HTTP API.
+const (
+	requestIDKey     = "x-request-id"  // Request correlation ID, echoed back in the response header
+	apiKeyKey        = "x-api-key"     // API key of the client
+	authorizationKey = "authorization" // API key sent as a bearer token
+	retryAfterKey    = "retry-after"   // Seconds until a rate limited client may retry
+)
+
+// NewServer creates the gRPC server serving the service. The interceptors assign request IDs, log requests
+// and collect metrics, recover from panics and, when auth or limiter are given, authenticate API keys and
+// limit the request rate of the MortgageService RPCs, counting the daily quota only for the requests within the
+// rate. Other services registered on the server, such as the
+// health service, stay open like the HTTP probes. The options are passed to grpc.NewServer.
+func NewServer(svc mortgagev1.MortgageServiceServer, auth *middleware.Authenticator, limiter *middleware.RateLimiter,
+	opts ...grpc.ServerOption) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{requestInfo, recovery}
+	if auth != nil {
+		interceptors = append(interceptors, authenticate(auth))
+	}
+	if limiter != nil {
+		interceptors = append(interceptors, rateLimit(limiter))
+	}
+	if auth != nil {
+		interceptors = append(interceptors, quota(auth))
+	}
+
+	srv := grpc.NewServer(append(opts, grpc.ChainUnaryInterceptor(interceptors...))...)
+	mortgagev1.RegisterMortgageServiceServer(srv, svc)
+	return srv
+}
+
+// requestInfo takes the request ID from the x-request-id metadata or generates a new one, echoes it back in the
+// response header, and records the request in the log and the metrics once it is handled.
+func requestInfo(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now() // Capture the start time of the request
+
+	// Reuse the caller's ID if it is safe to log, otherwise generate a new one
+	id := middleware.RequestID(firstValue(ctx, requestIDKey))
+	ctx = middleware.WithRequestID(ctx, id)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id)); err != nil {
+		middleware.Logger(ctx).Warn("failed to set request id header", "error", err)
+	}
+
+	resp, err := handler(ctx, req)
+
+	// Record the request count and latency by method and status code
+	duration := time.Since(start)
+	code := status.Code(err).String()
+	metrics.GRPCRequestsTotal.Inc(info.FullMethod, code)
+	metrics.GRPCRequestDuration.Observe(duration.Seconds(), info.FullMethod, code)
+
+	// Log the request summary, correlated by the request ID
+	middleware.Logger(ctx).LogAttrs(ctx, slog.LevelInfo, "request handled",
+		slog.String("method", info.FullMethod),
+		slog.String("code", code),
+		slog.Int64("duration_ns", duration.Nanoseconds()),
+		slog.String("client_ip", peerIP(ctx)),
+	)
+	return resp, err
+}
+
+// recovery recovers from panics in the RPCs. It logs the panic with its stack trace and the request ID and
+// responds with an INTERNAL status, so that a single faulty request does not take the process down.
+func recovery(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+		middleware.Logger(ctx).Error("panic recovered",
+			"panic", fmt.Sprint(rec),
+			"method", info.FullMethod,
+			"stack", string(debug.Stack()),
+		)
+		resp, err = nil, status.Error(codes.Internal, "internal server error")
+	}()
+
+	return handler(ctx, req)
+}
+
+// authenticate rejects MortgageService requests without a valid API key in the x-api-key or authorization
+// metadata with UNAUTHENTICATED and attaches the client ID to the context. The quota is counted by quota.
+func authenticate(auth *middleware.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !protected(info) {
+			return handler(ctx, req)
+		}
+
+		presented := firstValue(ctx, apiKeyKey)
+		if presented == "" {
+			presented = middleware.BearerToken(firstValue(ctx, authorizationKey))
+		}
+
+		ctx, err := auth.Identify(ctx, presented)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// quota rejects MortgageService requests over the client's daily quota with RESOURCE_EXHAUSTED. It runs after
+// rateLimit, so that the requests rejected for their rate are not counted.
+func quota(auth *middleware.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !protected(info) {
+			return handler(ctx, req)
+		}
+
+		if err := auth.Charge(ctx); err != nil {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// rateLimit rejects MortgageService requests over the client's rate with RESOURCE_EXHAUSTED and a retry-after
+// header. Clients share their buckets with the HTTP API: they are identified by their authenticated client ID
+// or, without authentication, by their IP address.
+func rateLimit(limiter *middleware.RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !protected(info) {
+			return handler(ctx, req)
+		}
+
+		// Identify the client by its API key identity, falling back to the IP address
+		key := middleware.ClientIDFromContext(ctx)
+		if key == "" {
+			key = "ip:" + peerIP(ctx)
+		}
+
+		if ok, retryAfter := limiter.Allow(key); !ok {
+			metrics.RateLimitedTotal.Inc()
+			seconds := strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+			if err := grpc.SetHeader(ctx, metadata.Pairs(retryAfterKey, seconds)); err != nil {
+				middleware.Logger(ctx).Warn("failed to set retry-after header", "error", err)
+			}
+			return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// protected reports whether the RPC belongs to the MortgageService, which requires authentication and is
+// rate limited.
+func protected(info *grpc.UnaryServerInfo) bool {
+	return strings.HasPrefix(info.FullMethod, "/"+mortgagev1.MortgageService_ServiceDesc.ServiceName+"/")
+}
+
+// firstValue returns the first value of the incoming metadata key, or an empty string.
+func firstValue(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// peerIP returns the IP address of the client that sent the request.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,149 @@
+// Package grpcapi implements the gRPC API of the mortgage calculation service, defined in
+// sber/pkg/api/mortgage/v1. It shares the cache storage and the calculation engine of sber/pkg/mortgage
+// with the HTTP handlers, so that both APIs calculate the same results and list the same calculations.
+//
+// Requests pass through interceptors that mirror the HTTP middleware: request IDs taken from the
+// x-request-id metadata, structured logging, metrics, panic recovery, API key authentication with the
+// x-api-key or authorization metadata, and rate limiting.
+//
+// Types and Functions:
+//   - Service: Implements the MortgageService RPCs, created with NewService.
+//   - NewServer: Creates the gRPC server serving a service with the interceptors.
+//   - validationStatus: Maps a validation error of the calculation engine to an INVALID_ARGUMENT status.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"sber/internal/cache"
+	"sber/internal/config"
+	"sber/internal/metrics"
+	"sber/internal/middleware"
+	mortgagev1 "sber/pkg/api/mortgage/v1"
+	"sber/pkg/models"
+	"sber/pkg/mortgage"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Service implements the MortgageService RPCs on top of the shared storage and calculation engine.
+type Service struct {
+	mortgagev1.UnimplementedMortgageServiceServer
+
+	store *cache.Storage  // The cache storage shared with the HTTP handlers
+	cfg   *config.Current // The active configuration, swapped on reload
+}
+
+// NewService creates the service storing its calculations in the given storage. The program rates are read
+// from the active configuration for every request, so that reloaded values apply immediately.
+func NewService(store *cache.Storage, cfg *config.Current) *Service {
+	return &Service{store: store, cfg: cfg}
+}
+
+// Calculate validates the parameters, calculates the mortgage for the selected program and stores the result
+// in the cache on behalf of the authenticated client, as POST /execute does. A repeated calculation is answered
+// with the stored entry when memoization is enabled.
+func (s *Service) Calculate(ctx context.Context, req *mortgagev1.CalculateRequest) (*mortgagev1.CalculateResponse, error) {
+	// Validate the request and calculate the mortgage with the active rates
+	input, err := mortgage.InputFromRequest(models.ExecuteReqeust{
+		ObjectCost:     req.GetObjectCost(),
+		InitialPayment: req.GetInitialPayment(),
+		Months:         req.GetMonths(),
+		Program:        fromProgram(req.GetProgram()),
+	})
+	if err != nil {
+		return nil, validationStatus(err)
+	}
+	result, err := s.calculator().Calculate(input)
+	if err != nil {
+		return nil, validationStatus(err)
+	}
+
+	// Store the result in cache on behalf of the authenticated client
+	model := result.Model()
+	entry, hit := s.store.Store(model, models.RecordMeta{
+		ClientID:  middleware.ClientIDFromContext(ctx),
+		Source:    models.SourceGRPC,
+		RequestID: middleware.RequestIDFromContext(ctx),
+		UserAgent: firstValue(ctx, "user-agent"),
+	})
+	metrics.CalculationsTotal.Inc(string(result.Program))
+	if hit {
+		metrics.CacheLookupsTotal.Inc("hit")
+	} else {
+		metrics.CacheLookupsTotal.Inc("miss")
+	}
+
+	return &mortgagev1.CalculateResponse{Result: toResult(model), Id: entry.ID, Cached: hit}, nil
+}
+
+// Compare calculates the mortgage for every program with the same parameters. The results are not cached.
+func (s *Service) Compare(_ context.Context, req *mortgagev1.CompareRequest) (*mortgagev1.CompareResponse, error) {
+	results, err := s.calculator().Compare(mortgage.Input{
+		ObjectCost:     req.GetObjectCost(),
+		InitialPayment: req.GetInitialPayment(),
+		Months:         req.GetMonths(),
+	})
+	if err != nil {
+		return nil, validationStatus(err)
+	}
+
+	resp := &mortgagev1.CompareResponse{Results: make([]*mortgagev1.Result, len(results))}
+	for i, result := range results {
+		resp.Results[i] = toResult(result.Model())
+	}
+	return resp, nil
+}
+
+// ListCalculations returns the cached calculations visible to the caller ordered by ID: authenticated clients
+// only see the calculations they made themselves. The calculations can be limited to a time window, which is
+// rejected with INVALID_ARGUMENT if it is empty. Unlike GET /cache, an empty cache is not an error.
+func (s *Service) ListCalculations(ctx context.Context, req *mortgagev1.ListCalculationsRequest) (*mortgagev1.ListCalculationsResponse, error) {
+	window := cache.Window{From: fromTimestamp(req.GetFrom()), To: fromTimestamp(req.GetTo())}
+	if !window.Valid() {
+		return nil, status.Error(codes.InvalidArgument, "from should be before to")
+	}
+
+	var entries []models.CacheStorageFormat
+	if clientID := middleware.ClientIDFromContext(ctx); clientID != "" {
+		entries = s.store.ReadByClient(clientID)
+	} else {
+		entries = s.store.ReadAll()
+	}
+	entries = window.Filter(entries)
+
+	resp := &mortgagev1.ListCalculationsResponse{Calculations: make([]*mortgagev1.Calculation, len(entries))}
+	for i, entry := range entries {
+		resp.Calculations[i] = toCalculation(entry)
+	}
+	return resp, nil
+}
+
+// calculator returns the calculation engine with the active program rates.
+func (s *Service) calculator() *mortgage.Calculator {
+	return mortgage.New(s.cfg.Load().Programs.Rates())
+}
+
+// validationStatus counts the validation failure and maps it to an INVALID_ARGUMENT status with the message
+// of the HTTP API and a BadRequest detail naming the invalid field.
+func validationStatus(err error) error {
+	metrics.ValidationFailuresTotal.Inc(metrics.ValidationFailureLabel(err))
+
+	var validationErr *mortgage.ValidationError
+	if !errors.As(err, &validationErr) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	st := status.New(codes.InvalidArgument, validationErr.Message)
+	detailed, detailErr := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: validationErr.Field, Description: validationErr.Message},
+		},
+	})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
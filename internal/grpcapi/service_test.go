@@ -0,0 +1,446 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/cache"
+	"sber/internal/config"
+	"sber/internal/handlers"
+	"sber/internal/middleware"
+	mortgagev1 "sber/pkg/api/mortgage/v1"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// newTestClient serves the service over an in-process bufconn listener and returns a client connected to it.
+// The server and the connection are closed when the test ends.
+func newTestClient(t *testing.T, svc mortgagev1.MortgageServiceServer, auth *middleware.Authenticator,
+	limiter *middleware.RateLimiter) mortgagev1.MortgageServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	srv := NewServer(svc, auth, limiter)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return mortgagev1.NewMortgageServiceClient(conn)
+}
+
+// newTestService creates a service with the default configuration on an empty storage.
+func newTestService() (*Service, *cache.Storage) {
+	store := cache.New()
+	return NewService(store, config.NewCurrent(config.Default())), store
+}
+
+// withAPIKey returns a copy of ctx sending the API key in the x-api-key metadata.
+func withAPIKey(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "x-api-key", key)
+}
+
+// hashKey returns the hex-encoded SHA-256 hash of an API key, as stored in the configuration.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// salaryRequest is the calculation used throughout the tests.
+var salaryRequest = &mortgagev1.CalculateRequest{
+	ObjectCost:     5000000,
+	InitialPayment: 1000000,
+	Months:         240,
+	Program:        &mortgagev1.Program{Salary: true},
+}
+
+// TestCalculate verifies that calculations match the HTTP API and are stored in the shared cache.
+func TestCalculate(t *testing.T) {
+	svc, store := newTestService()
+	client := newTestClient(t, svc, nil, nil)
+
+	// The request ID is taken from the metadata and echoed back
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "grpc-request-1")
+	resp, err := client.Calculate(ctx, salaryRequest, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ids := header.Get("x-request-id"); len(ids) != 1 || ids[0] != "grpc-request-1" {
+		t.Errorf("Expected request ID grpc-request-1 in header, got %v", ids)
+	}
+
+	aggregates := resp.GetResult().GetAggregates()
+	if aggregates.GetRate() != 8 || aggregates.GetLoanSum() != 4000000 || aggregates.GetMonthlyPayment() != 33458 ||
+		aggregates.GetOverpayment() != 4029920 {
+		t.Errorf("Unexpected aggregates %v", aggregates)
+	}
+	if !resp.GetResult().GetProgram().GetSalary() || resp.GetResult().GetParams().GetMonths() != 240 {
+		t.Errorf("Unexpected result %v", resp.GetResult())
+	}
+
+	// The calculation is stored under the returned ID
+	entry, ok := store.Get(resp.GetId())
+	if !ok || entry.Aggregates.LastPaymentDate != aggregates.GetLastPaymentDate() {
+		t.Errorf("Expected cached entry %d, got %+v", resp.GetId(), entry)
+	}
+
+	// The HTTP API calculates the same result
+	body, _ := json.Marshal(models.ExecuteReqeust{ObjectCost: 5000000, InitialPayment: 1000000, Months: 240,
+		Program: models.Program{Salary: true}})
+	w := httptest.NewRecorder()
+	handlers.NewHandlers(store).Execute(w, httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader(body)))
+	var httpResp models.ExecuteResponse
+	json.NewDecoder(w.Body).Decode(&httpResp)
+	if !equalResult(resp.GetResult(), httpResp.Result) {
+		t.Errorf("Expected the HTTP API to return %v, got %+v", resp.GetResult(), httpResp.Result)
+	}
+}
+
+// TestCalculateMemoized verifies that a repeated calculation is answered with the stored entry and that its
+// hit counter is listed.
+func TestCalculateMemoized(t *testing.T) {
+	svc := NewService(cache.New(cache.WithMemoization()), config.NewCurrent(config.Default()))
+	client := newTestClient(t, svc, nil, nil)
+
+	first, err := client.Calculate(context.Background(), salaryRequest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repeated, err := client.Calculate(context.Background(), salaryRequest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.GetCached() || !repeated.GetCached() || repeated.GetId() != first.GetId() {
+		t.Errorf("Expected the repeated calculation to return entry %d from the cache, got %d (cached: %v)",
+			first.GetId(), repeated.GetId(), repeated.GetCached())
+	}
+
+	list, err := client.ListCalculations(context.Background(), &mortgagev1.ListCalculationsRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calculations := list.GetCalculations(); len(calculations) != 1 || calculations[0].GetHits() != 1 {
+		t.Errorf("Expected 1 calculation with 1 hit, got %v", calculations)
+	}
+}
+
+// TestListCalculationsWindow verifies that calculations record the gRPC request that made them and can be
+// listed within a time window.
+func TestListCalculationsWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := cache.New(cache.WithClock(func() time.Time { return now }))
+	client := newTestClient(t, NewService(store, config.NewCurrent(config.Default())), nil, nil)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "grpc-request-1")
+	if _, err := client.Calculate(ctx, salaryRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now = now.AddDate(0, 0, 1)
+	if _, err := client.Calculate(context.Background(), salaryRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The first calculation is listed with its metadata
+	list, err := client.ListCalculations(context.Background(), &mortgagev1.ListCalculationsRequest{
+		To: timestamppb.New(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	calculations := list.GetCalculations()
+	if len(calculations) != 1 {
+		t.Fatalf("Expected 1 calculation, got %d", len(calculations))
+	}
+	first := calculations[0]
+	if first.GetId() != 0 || !first.GetCreatedAt().AsTime().Equal(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)) ||
+		first.GetSource() != "grpc" || first.GetRequestId() != "grpc-request-1" ||
+		!strings.HasPrefix(first.GetUserAgent(), "grpc-go/") {
+		t.Errorf("Unexpected calculation %v", first)
+	}
+
+	// An empty window is rejected
+	_, err = client.ListCalculations(context.Background(), &mortgagev1.ListCalculationsRequest{
+		From: timestamppb.New(now),
+		To:   timestamppb.New(now.Add(-time.Hour)),
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected INVALID_ARGUMENT, got %v", err)
+	}
+}
+
+// equalResult reports whether the protobuf result matches the HTTP API result.
+func equalResult(got *mortgagev1.Result, expected models.Result) bool {
+	return got.GetParams().GetObjectCost() == expected.Params.ObjectCost &&
+		got.GetParams().GetInitialPayment() == expected.Params.InitialPayment &&
+		got.GetParams().GetMonths() == expected.Params.Months &&
+		got.GetProgram().GetBase() == expected.Program.Base &&
+		got.GetProgram().GetMilitary() == expected.Program.Military &&
+		got.GetProgram().GetSalary() == expected.Program.Salary &&
+		got.GetAggregates().GetRate() == uint32(expected.Aggregates.Rate) &&
+		got.GetAggregates().GetLoanSum() == expected.Aggregates.LoanSum &&
+		got.GetAggregates().GetMonthlyPayment() == expected.Aggregates.MonthlyPayment &&
+		got.GetAggregates().GetOverpayment() == expected.Aggregates.Overpayment &&
+		got.GetAggregates().GetLastPaymentDate() == expected.Aggregates.LastPaymentDate
+}
+
+// TestCalculateValidation verifies that invalid parameters are rejected with INVALID_ARGUMENT, the message of
+// the HTTP API and the invalid field, and that nothing is cached.
+func TestCalculateValidation(t *testing.T) {
+	svc, store := newTestService()
+	client := newTestClient(t, svc, nil, nil)
+
+	tests := []struct {
+		name    string
+		req     *mortgagev1.CalculateRequest
+		message string
+		field   string
+	}{
+		{"No program", &mortgagev1.CalculateRequest{ObjectCost: 5000000, InitialPayment: 1000000, Months: 240},
+			"choose program", "program"},
+		{"Several programs", &mortgagev1.CalculateRequest{ObjectCost: 5000000, InitialPayment: 1000000, Months: 240,
+			Program: &mortgagev1.Program{Base: true, Salary: true}}, "choose only 1 program", "program"},
+		{"Initial payment too small", &mortgagev1.CalculateRequest{ObjectCost: 5000000, InitialPayment: 100, Months: 240,
+			Program: &mortgagev1.Program{Base: true}}, "the initial payment should be more", "initial_payment"},
+		{"Zero term", &mortgagev1.CalculateRequest{ObjectCost: 5000000, InitialPayment: 1000000,
+			Program: &mortgagev1.Program{Base: true}}, "the loan term should be positive", "months"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Calculate(context.Background(), tt.req)
+			st := status.Convert(err)
+			if st.Code() != codes.InvalidArgument || st.Message() != tt.message {
+				t.Fatalf("Expected INVALID_ARGUMENT %q, got %v", tt.message, err)
+			}
+
+			details := st.Details()
+			if len(details) != 1 {
+				t.Fatalf("Expected 1 detail, got %v", details)
+			}
+			badRequest, ok := details[0].(*errdetails.BadRequest)
+			if !ok || len(badRequest.GetFieldViolations()) != 1 || badRequest.GetFieldViolations()[0].GetField() != tt.field {
+				t.Errorf("Expected violation of %s, got %v", tt.field, details[0])
+			}
+		})
+	}
+
+	if store.Len() != 0 {
+		t.Errorf("Expected no cached calculations, got %d", store.Len())
+	}
+}
+
+// TestCompare verifies that every program is calculated without caching the results.
+func TestCompare(t *testing.T) {
+	svc, store := newTestService()
+	client := newTestClient(t, svc, nil, nil)
+
+	resp, err := client.Compare(context.Background(), &mortgagev1.CompareRequest{
+		ObjectCost: 5000000, InitialPayment: 1000000, Months: 240,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results := resp.GetResults()
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if !results[0].GetProgram().GetBase() || !results[1].GetProgram().GetMilitary() || !results[2].GetProgram().GetSalary() {
+		t.Errorf("Unexpected program order %v", results)
+	}
+	for i, rate := range []uint32{10, 9, 8} {
+		if results[i].GetAggregates().GetRate() != rate {
+			t.Errorf("Expected rate %d for result %d, got %d", rate, i, results[i].GetAggregates().GetRate())
+		}
+	}
+	if results[2].GetAggregates().GetMonthlyPayment() != 33458 {
+		t.Errorf("Expected salary monthly payment 33458, got %d", results[2].GetAggregates().GetMonthlyPayment())
+	}
+	if store.Len() != 0 {
+		t.Errorf("Expected no cached calculations, got %d", store.Len())
+	}
+
+	_, err = client.Compare(context.Background(), &mortgagev1.CompareRequest{ObjectCost: 5000000, Months: 240})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected INVALID_ARGUMENT, got %v", err)
+	}
+}
+
+// TestListCalculations verifies that the cached calculations are listed in ID order, including those made
+// over the HTTP API, and that an empty cache returns an empty list.
+func TestListCalculations(t *testing.T) {
+	svc, store := newTestService()
+	client := newTestClient(t, svc, nil, nil)
+
+	resp, err := client.ListCalculations(context.Background(), &mortgagev1.ListCalculationsRequest{})
+	if err != nil || len(resp.GetCalculations()) != 0 {
+		t.Fatalf("Expected empty list, got %v, %v", resp, err)
+	}
+
+	// One calculation over HTTP, one over gRPC
+	store.Load(models.Result{Params: models.Params{ObjectCost: 100000}, Program: models.Program{Base: true}})
+	if _, err = client.Calculate(context.Background(), salaryRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err = client.ListCalculations(context.Background(), &mortgagev1.ListCalculationsRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	calculations := resp.GetCalculations()
+	if len(calculations) != 2 {
+		t.Fatalf("Expected 2 calculations, got %d", len(calculations))
+	}
+	if calculations[0].GetId() != 0 || calculations[0].GetParams().GetObjectCost() != 100000 || !calculations[0].GetProgram().GetBase() {
+		t.Errorf("Unexpected first calculation %v", calculations[0])
+	}
+	if calculations[1].GetId() != 1 || calculations[1].GetAggregates().GetMonthlyPayment() != 33458 {
+		t.Errorf("Unexpected second calculation %v", calculations[1])
+	}
+}
+
+// TestAuthentication verifies that API keys are required, that clients only list their own calculations and
+// that daily quotas are enforced.
+func TestAuthentication(t *testing.T) {
+	auth, err := middleware.NewAuthenticator([]config.APIKey{
+		{ClientID: "bank-a", KeyHash: hashKey("secret-a")},
+		{ClientID: "bank-b", KeyHash: hashKey("secret-b"), DailyQuota: 1},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	svc, store := newTestService()
+	client := newTestClient(t, svc, auth, nil)
+
+	// Requests without a valid key are rejected
+	if _, err = client.Calculate(context.Background(), salaryRequest); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected UNAUTHENTICATED without key, got %v", err)
+	}
+	if _, err = client.Calculate(withAPIKey(context.Background(), "wrong"), salaryRequest); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected UNAUTHENTICATED with unknown key, got %v", err)
+	}
+
+	// The key can be sent as a bearer token, the calculation is stored on behalf of the client
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret-a")
+	resp, err := client.Calculate(ctx, salaryRequest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entry, _ := store.Get(resp.GetId()); entry.ClientID != "bank-a" {
+		t.Errorf("Expected calculation of bank-a, got %q", entry.ClientID)
+	}
+
+	// Other clients do not see the calculation, and are limited by their quota
+	list, err := client.ListCalculations(withAPIKey(context.Background(), "secret-b"), &mortgagev1.ListCalculationsRequest{})
+	if err != nil || len(list.GetCalculations()) != 0 {
+		t.Errorf("Expected no calculations for bank-b, got %v, %v", list, err)
+	}
+	_, err = client.ListCalculations(withAPIKey(context.Background(), "secret-b"), &mortgagev1.ListCalculationsRequest{})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected RESOURCE_EXHAUSTED over quota, got %v", err)
+	}
+
+	list, err = client.ListCalculations(withAPIKey(context.Background(), "secret-a"), &mortgagev1.ListCalculationsRequest{})
+	if err != nil || len(list.GetCalculations()) != 1 || list.GetCalculations()[0].GetClientId() != "bank-a" {
+		t.Errorf("Expected the calculation of bank-a, got %v, %v", list, err)
+	}
+}
+
+// TestRateLimit verifies that requests over the rate are rejected with a retry-after header.
+func TestRateLimit(t *testing.T) {
+	limiter := middleware.NewRateLimiter(config.RateLimit{RequestsPerSecond: 1, Burst: 1, IdleTimeout: time.Minute})
+	svc, _ := newTestService()
+	client := newTestClient(t, svc, nil, limiter)
+
+	if _, err := client.Compare(context.Background(), &mortgagev1.CompareRequest{
+		ObjectCost: 5000000, InitialPayment: 1000000, Months: 240,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var header metadata.MD
+	_, err := client.ListCalculations(context.Background(), &mortgagev1.ListCalculationsRequest{}, grpc.Header(&header))
+	if st := status.Convert(err); st.Code() != codes.ResourceExhausted || st.Message() != "rate limit exceeded" {
+		t.Errorf("Expected RESOURCE_EXHAUSTED, got %v", err)
+	}
+	if retry := header.Get("retry-after"); len(retry) != 1 || retry[0] != "1" {
+		t.Errorf("Expected retry-after 1, got %v", retry)
+	}
+}
+
+// TestRateLimitQuota verifies that requests rejected for their rate do not use up the daily quota.
+func TestRateLimitQuota(t *testing.T) {
+	auth, err := middleware.NewAuthenticator([]config.APIKey{{ClientID: "bank-a", KeyHash: hashKey("secret-a"), DailyQuota: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	limiter := middleware.NewRateLimiter(config.RateLimit{RequestsPerSecond: 0.001, Burst: 1, IdleTimeout: time.Minute})
+	svc, _ := newTestService()
+	client := newTestClient(t, svc, auth, limiter)
+	ctx := withAPIKey(context.Background(), "secret-a")
+
+	if _, err = client.ListCalculations(ctx, &mortgagev1.ListCalculationsRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range 3 {
+		_, err = client.ListCalculations(ctx, &mortgagev1.ListCalculationsRequest{})
+		if st := status.Convert(err); st.Code() != codes.ResourceExhausted || st.Message() != "rate limit exceeded" {
+			t.Fatalf("Expected the rate limit, got %v", err)
+		}
+	}
+
+	// The rejected requests were not counted, so the quota still allows one more request
+	if _, err = auth.Authorize("secret-a"); err != nil {
+		t.Errorf("Expected one request left in the quota, got %v", err)
+	}
+	if _, err = auth.Authorize("secret-a"); err == nil {
+		t.Error("Expected the quota to be used up")
+	}
+}
+
+// panickingService is a service whose calculation panics.
+type panickingService struct {
+	mortgagev1.UnimplementedMortgageServiceServer
+}
+
+// Calculate panics.
+func (panickingService) Calculate(context.Context, *mortgagev1.CalculateRequest) (*mortgagev1.CalculateResponse, error) {
+	panic("boom")
+}
+
+// TestRecovery verifies that a panicking RPC is answered with INTERNAL and the server keeps serving.
+func TestRecovery(t *testing.T) {
+	client := newTestClient(t, panickingService{}, nil, nil)
+
+	_, err := client.Calculate(context.Background(), salaryRequest)
+	if st := status.Convert(err); st.Code() != codes.Internal || st.Message() != "internal server error" {
+		t.Errorf("Expected INTERNAL, got %v", err)
+	}
+	_, err = client.Compare(context.Background(), &mortgagev1.CompareRequest{})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected the server to keep serving, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sber/internal/ical"
+	"sber/internal/middleware"
+	"strconv"
+	"time"
+)
+
+// defaultReminderDays is how many days before a payment the calendar reminds of it by default.
+const defaultReminderDays = 1
+
+// Calendar handles the GET request for the payment schedule of a cached calculation as an iCalendar (.ics)
+// file with one all-day event per payment. The calculation is identified by the id path value and is only
+// visible to the client that made it. The reminder query parameter sets how many days before each payment
+// calendar applications remind of it, 0 disables reminders.
+func (h *Handlers) Calendar(w http.ResponseWriter, r *http.Request) {
+	// Check if the request method is GET
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "only get method allowed")
+		return
+	}
+
+	reminderDays := defaultReminderDays
+	if value := r.URL.Query().Get("reminder"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 || days > 31 {
+			writeError(w, r, http.StatusBadRequest, "reminder must be a number of days between 0 and 31")
+			return
+		}
+		reminderDays = days
+	}
+
+	entry, ok := h.visibleEntry(w, r)
+	if !ok {
+		return
+	}
+
+	// Build the payment schedule of the calculation
+	schedule, err := paymentSchedule(entry)
+	if err != nil {
+		middleware.Logger(r.Context()).Error("failed to build payment schedule", "id", entry.ID, "error", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to build payment schedule")
+		return
+	}
+
+	// One event per payment, identified by the calculation so that re-imports update the events
+	cal := ical.Calendar{
+		ProdID: "-//Mortgage calculator//Payment schedule//EN",
+		Name:   fmt.Sprintf("Mortgage payments (calculation %d)", entry.ID),
+		Stamp:  time.Now(),
+		Events: make([]ical.Event, 0, len(schedule)),
+	}
+	for _, p := range schedule {
+		date, parseErr := time.Parse(time.DateOnly, p.Date)
+		if parseErr != nil {
+			middleware.Logger(r.Context()).Error("invalid payment date", "id", entry.ID, "date", p.Date, "error", parseErr)
+			writeError(w, r, http.StatusInternalServerError, "failed to build payment schedule")
+			return
+		}
+		cal.Events = append(cal.Events, ical.Event{
+			UID:     fmt.Sprintf("calculation-%d-%s-payment-%d@mortgage-calculator", entry.ID, entry.Aggregates.LastPaymentDate, p.Number),
+			Date:    date,
+			Summary: fmt.Sprintf("Mortgage payment %d: %d", p.Number, p.Amount),
+			Description: fmt.Sprintf("Payment %d of %d: %d (principal %d, interest %d). Remaining balance %d.",
+				p.Number, len(schedule), p.Amount, p.Principal, p.Interest, p.Balance),
+			Reminder: time.Duration(reminderDays) * 24 * time.Hour,
+		})
+	}
+
+	// Render the calendar before sending, so that failures still produce an error response
+	var buf bytes.Buffer
+	if _, err = cal.WriteTo(&buf); err != nil {
+		middleware.Logger(r.Context()).Error("failed to render calendar", "id", entry.ID, "error", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to render calendar")
+		return
+	}
+
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="payments-%d.ics"`, entry.ID))
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(buf.Bytes()); err != nil {
+		middleware.Logger(r.Context()).Error("failed to write calendar", "error", err)
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/middleware"
+	"strings"
+	"testing"
+)
+
+func TestCalendarHandler(t *testing.T) {
+	h := newExportTestHandlers(t, "bank-a")
+
+	tests := []struct {
+		name           string
+		query          string
+		clientID       string
+		expectedCode   int
+		expectedAlarms int
+	}{
+		{"Default reminder", "", "bank-a", http.StatusOK, 240},
+		{"Reminders disabled", "?reminder=0", "bank-a", http.StatusOK, 0},
+		{"Invalid reminder", "?reminder=-1", "bank-a", http.StatusBadRequest, 0},
+		{"Calculation of another client", "", "bank-b", http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/cache/0/calendar"+tt.query, nil)
+			req.SetPathValue("id", "0")
+			req = req.WithContext(middleware.WithClientID(req.Context(), tt.clientID))
+			w := httptest.NewRecorder()
+
+			h.Calendar(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			// One event per payment, ending on the last payment date of the calculation
+			body := w.Body.String()
+			if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar") {
+				t.Errorf("Unexpected content type %s", w.Header().Get("Content-Type"))
+			}
+			if count := strings.Count(body, "BEGIN:VEVENT"); count != 240 {
+				t.Errorf("Expected 240 events, got %d", count)
+			}
+			if count := strings.Count(body, "BEGIN:VALARM"); count != tt.expectedAlarms {
+				t.Errorf("Expected %d alarms, got %d", tt.expectedAlarms, count)
+			}
+			if !strings.Contains(body, "SUMMARY:Mortgage payment 1: 33458\r\n") {
+				t.Errorf("Expected the payment amount in the summary")
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sber/internal/export"
+	"sber/internal/middleware"
+	"sber/pkg/models"
+	"strconv"
+)
+
+// ExportCache handles the GET request for exporting the cached calculations visible to the caller as a
+// CSV file or an XLSX workbook. The format is selected by the format query parameter or the Accept header,
+// and the language of the column headers by the lang query parameter or the Accept-Language header. The from and
+// to query parameters limit the export to a time window, as in Cache.
+func (h *Handlers) ExportCache(w http.ResponseWriter, r *http.Request) {
+	// Check if the request method is GET
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "only get method allowed")
+		return
+	}
+
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+
+	window, ok := queryWindow(w, r)
+	if !ok {
+		return
+	}
+
+	// Retrieve the data visible to the caller within the time window from the cache
+	data := window.Filter(h.visibleEntries(r))
+	if len(data) == 0 {
+		writeError(w, r, http.StatusBadRequest, "empty cache")
+		return
+	}
+
+	lang := export.NegotiateLanguage(r.URL.Query().Get("lang"), r.Header.Get("Accept-Language"))
+	writeExport(w, r, format, "calculations", export.Cache(data, lang))
+}
+
+// ExportCalculation handles the GET request for exporting a single cached calculation with its payment
+// schedule as a CSV file or an XLSX workbook. The calculation is identified by the id path value, and is
+// only visible to the client that made it. Format and language are negotiated as in ExportCache.
+func (h *Handlers) ExportCalculation(w http.ResponseWriter, r *http.Request) {
+	// Check if the request method is GET
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "only get method allowed")
+		return
+	}
+
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+
+	entry, ok := h.visibleEntry(w, r)
+	if !ok {
+		return
+	}
+
+	// Build the payment schedule of the calculation
+	schedule, err := paymentSchedule(entry)
+	if err != nil {
+		middleware.Logger(r.Context()).Error("failed to build payment schedule", "id", entry.ID, "error", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to build payment schedule")
+		return
+	}
+
+	lang := export.NegotiateLanguage(r.URL.Query().Get("lang"), r.Header.Get("Accept-Language"))
+	writeExport(w, r, format, fmt.Sprintf("calculation-%d", entry.ID), export.Calculation(entry, schedule, lang))
+}
+
+// visibleEntries returns the cached calculations visible to the caller: authenticated clients only see
+// the calculations they made themselves.
+func (h *Handlers) visibleEntries(r *http.Request) []models.CacheStorageFormat {
+	if clientID := middleware.ClientIDFromContext(r.Context()); clientID != "" {
+		return h.store.ReadByClient(clientID)
+	}
+	return h.store.ReadAll()
+}
+
+// visibleEntry returns the cached calculation identified by the id path value. It sends an error response
+// and returns false if the ID is invalid, or if the calculation does not exist or belongs to another client.
+func (h *Handlers) visibleEntry(w http.ResponseWriter, r *http.Request) (models.CacheStorageFormat, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid calculation id")
+		return models.CacheStorageFormat{}, false
+	}
+
+	// Calculations of other clients are reported as missing, so that their IDs are not disclosed
+	entry, ok := h.store.Get(int32(id))
+	clientID := middleware.ClientIDFromContext(r.Context())
+	if !ok || (clientID != "" && entry.ClientID != clientID) {
+		writeError(w, r, http.StatusNotFound, "calculation not found")
+		return models.CacheStorageFormat{}, false
+	}
+
+	return entry, true
+}
+
+// exportFormat negotiates the export format of the request. It sends a 406 response and returns false if
+// the requested format is not supported.
+func exportFormat(w http.ResponseWriter, r *http.Request) (export.Format, bool) {
+	format, ok := export.NegotiateFormat(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+	if !ok {
+		writeError(w, r, http.StatusNotAcceptable, "unsupported export format, use csv or xlsx")
+	}
+	return format, ok
+}
+
+// writeExport renders the sheets in the given format and sends them as an attachment named after the
+// given base name. The document is rendered before sending, so that failures still produce an error response.
+func writeExport(w http.ResponseWriter, r *http.Request, format export.Format, name string, sheets []export.Sheet) {
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format, sheets); err != nil {
+		middleware.Logger(r.Context()).Error("failed to render export", "format", format, "error", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to render export")
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	w.Header().Set("Vary", "Accept, Accept-Language")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		middleware.Logger(r.Context()).Error("failed to write export", "error", err)
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/cache"
+	"sber/internal/middleware"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+)
+
+// newExportTestHandlers returns handlers with one calculation made by the given client.
+func newExportTestHandlers(t *testing.T, clientID string) *Handlers {
+	t.Helper()
+
+	h := NewHandlers(cache.New())
+	body, _ := json.Marshal(models.ExecuteReqeust{
+		ObjectCost:     5000000,
+		InitialPayment: 1000000,
+		Months:         240,
+		Program:        models.Program{Salary: true},
+	})
+	req := httptest.NewRequest("POST", "/execute", bytes.NewReader(body))
+	req = req.WithContext(middleware.WithClientID(req.Context(), clientID))
+	w := httptest.NewRecorder()
+	h.Execute(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to prepare calculation: status %d", w.Code)
+	}
+	return h
+}
+
+func TestExportCalculationHandler(t *testing.T) {
+	h := newExportTestHandlers(t, "bank-a")
+
+	tests := []struct {
+		name                string
+		id                  string
+		query               string
+		accept              string
+		clientID            string
+		expectedCode        int
+		expectedContentType string
+	}{
+		{"CSV by default", "0", "", "", "bank-a", http.StatusOK, "text/csv; charset=utf-8"},
+		{"XLSX by query", "0", "?format=xlsx", "", "bank-a", http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{"XLSX by Accept", "0", "", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "bank-a", http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{"Query overrides Accept", "0", "?format=csv", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "bank-a", http.StatusOK, "text/csv; charset=utf-8"},
+		{"Unsupported format", "0", "?format=pdf", "", "bank-a", http.StatusNotAcceptable, "application/json"},
+		{"Unsupported Accept", "0", "", "application/pdf", "bank-a", http.StatusNotAcceptable, "application/json"},
+		{"Invalid ID", "abc", "", "", "bank-a", http.StatusBadRequest, "application/json"},
+		{"Missing calculation", "1", "", "", "bank-a", http.StatusNotFound, "application/json"},
+		{"Calculation of another client", "0", "", "", "bank-b", http.StatusNotFound, "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/cache/"+tt.id+"/export"+tt.query, nil)
+			req.SetPathValue("id", tt.id)
+			req.Header.Set("Accept", tt.accept)
+			req = req.WithContext(middleware.WithClientID(req.Context(), tt.clientID))
+			w := httptest.NewRecorder()
+
+			h.ExportCalculation(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != tt.expectedContentType {
+				t.Errorf("Expected content type %s, got %s", tt.expectedContentType, contentType)
+			}
+		})
+	}
+}
+
+func TestExportCalculationHandlerContent(t *testing.T) {
+	h := newExportTestHandlers(t, "")
+
+	req := httptest.NewRequest("GET", "/cache/0/export?lang=ru", nil)
+	req.SetPathValue("id", "0")
+	w := httptest.NewRecorder()
+
+	h.ExportCalculation(w, req)
+
+	if disposition := w.Header().Get("Content-Disposition"); disposition != `attachment; filename="calculation-0.csv"` {
+		t.Errorf("Unexpected content disposition %s", disposition)
+	}
+
+	// The document contains the calculation summary and the schedule with Russian headers
+	body := strings.TrimPrefix(w.Body.String(), "\uFEFF")
+	if !strings.Contains(body, "Ежемесячный платеж") || !strings.Contains(body, "Остаток долга") {
+		t.Errorf("Expected localized headers, got %s", body)
+	}
+
+	// The schedule section follows the empty line separating the sheets
+	_, scheduleCSV, found := strings.Cut(body, "\n\n")
+	if !found {
+		t.Fatal("Expected the schedule section")
+	}
+	records, err := csv.NewReader(strings.NewReader(scheduleCSV)).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse the schedule: %v", err)
+	}
+	if len(records) != 241 {
+		t.Errorf("Expected header and 240 payments, got %d records", len(records))
+	}
+}
+
+func TestExportCacheHandler(t *testing.T) {
+	h := newExportTestHandlers(t, "bank-a")
+
+	// XLSX workbooks are valid zip archives with a worksheet
+	req := httptest.NewRequest("GET", "/cache/export?format=xlsx", nil)
+	req = req.WithContext(middleware.WithClientID(req.Context(), "bank-a"))
+	w := httptest.NewRecorder()
+
+	h.ExportCache(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("Expected a zip archive: %v", err)
+	}
+	found := false
+	for _, f := range archive.File {
+		found = found || f.Name == "xl/worksheets/sheet1.xml"
+	}
+	if !found {
+		t.Error("Expected worksheet in the workbook")
+	}
+
+	// Clients without calculations get the same error as from /cache
+	req = httptest.NewRequest("GET", "/cache/export", nil)
+	req = req.WithContext(middleware.WithClientID(req.Context(), "bank-b"))
+	w = httptest.NewRecorder()
+
+	h.ExportCache(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestPaymentScheduleOfEntry(t *testing.T) {
+	entry := models.CacheStorageFormat{
+		Params:  models.Params{ObjectCost: 5000000, InitialPayment: 1000000, Months: 240},
+		Program: models.Program{Salary: true},
+		Aggregates: models.Aggregates{
+			Rate:            8,
+			LoanSum:         4000000,
+			MonthlyPayment:  33458,
+			Overpayment:     4029920,
+			LastPaymentDate: "2044-02-29",
+		},
+	}
+
+	schedule, err := paymentSchedule(entry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(schedule) != 240 {
+		t.Fatalf("Expected 240 payments, got %d", len(schedule))
+	}
+
+	// The schedule ends on the last payment date and repays the whole loan
+	last := schedule[len(schedule)-1]
+	if last.Date != "2044-02-29" || last.Balance != 0 {
+		t.Errorf("Unexpected last payment %+v", last)
+	}
+	var principal int64
+	for _, p := range schedule {
+		principal += int64(p.Principal)
+	}
+	if principal != int64(entry.Aggregates.LoanSum) {
+		t.Errorf("Expected principal payments to sum to %d, got %d", entry.Aggregates.LoanSum, principal)
+	}
+	if first := schedule[0]; first.Date != "2024-03-29" || first.Interest != 26667 {
+		t.Errorf("Unexpected first payment %+v", first)
+	}
+
+	// Invalid entries are reported
+	entry.Aggregates.LastPaymentDate = "2006-01-01T00:00"
+	if _, err := paymentSchedule(entry); err == nil {
+		t.Error("Expected error for invalid last payment date")
+	}
+}
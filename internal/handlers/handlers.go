@@ -1,279 +1,233 @@
 // Package handlers defines the HTTP request handlers for the mortgage calculation service.
 //
-// It contains logic for handling incoming requests, performing business logic for mortgage
-// calculations, and interacting with the cache for storing and retrieving results.
+// It contains logic for handling incoming requests, calling the calculation engine of sber/pkg/mortgage,
+// and interacting with the cache for storing and retrieving results.
 // The main HTTP methods it handles are POST for performing mortgage calculations and GET for
 // retrieving cached data.
 //
 // Functions and Methods:
-//   - NewHandlers: Creates and returns a new Handlers instance with the provided cache storage.
+//   - NewHandlers: Creates and returns a new Handlers instance with the provided cache storage and options.
+//   - WithConfig: Makes the handlers use the active, reloadable configuration.
+//   - WithWebhooks: Makes the handlers manage the endpoints of a webhook dispatcher.
+//   - WithClock: Makes the handlers date the calculations with the given clock.
 //   - Execute: Handles the POST request for performing mortgage calculations.
 //   - Cache: Handles the GET request for fetching cached data.
-//   - monthlyPaymentCalculator: Calculates the monthly payment and overpayment for a mortgage.
-//   - programValidator: Validates the selected loan program based on the input data.
-//   - initialPaymentValidator: Validates that the initial payment is valid based on the object cost.
+//   - History: Handles the GET request for the recorded calculation requests.
+//   - Stats: Handles the GET request for the statistics of the cached calculations.
+//   - Stream: Handles the GET request for the stream of new calculations as server-sent events.
+//   - CloseStreams: Ends the open event streams on shutdown.
+//   - ExportCache: Handles the GET request for exporting the cached data as CSV or XLSX.
+//   - ExportCalculation: Handles the GET request for exporting a calculation with its payment schedule.
+//   - Offer: Handles the GET request for the printable PDF offer document of a calculation.
+//   - Calendar: Handles the GET request for the payment schedule of a calculation as an iCalendar file.
+//   - Webhooks: Handles the administrative requests listing and registering webhook endpoints.
+//   - Webhook: Handles the administrative request removing a webhook endpoint.
+//   - Snapshot: Handles the administrative requests downloading and restoring snapshots of the cache.
+//   - Healthz: Handles the liveness probe.
+//   - Readyz: Handles the readiness probe, which fails during shutdown and when the storage is unavailable.
+//   - calculate: Validates a request and calculates the mortgage with the active program rates.
+//   - paymentSchedule: Builds the amortization schedule of a stored calculation.
 package handlers
 
 import (
-	"encoding/json"
 	"errors"
-	"log"
-	"math"
 	"net/http"
 	"sber/internal/cache"
-	errs "sber/pkg/errors"
+	"sber/internal/config"
+	"sber/internal/metrics"
+	"sber/internal/middleware"
+	"sber/internal/webhook"
 	"sber/pkg/models"
+	"sber/pkg/mortgage"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Handlers defines the HTTP request handlers for the mortgage calculation service.
 // It stores a reference to the cache storage and provides methods to handle requests.
 type Handlers struct {
-	store *cache.Storage // The cache storage used for storing and retrieving mortgage calculation results
+	store    *cache.Storage      // The cache storage used for storing and retrieving mortgage calculation results
+	cfg      *config.Current     // The active configuration, swapped on reload
+	webhooks *webhook.Dispatcher // The webhook dispatcher, nil when webhooks are disabled
+	logo     offerLogo           // The decoded logo of the offers
+	now      func() time.Time    // Clock dating the calculations
+	ready    atomic.Bool         // Whether the service is ready to receive traffic
+	done     chan struct{}       // Closed by CloseStreams to end the event streams
+	close    sync.Once           // Closes done once
+}
+
+// Option configures optional dependencies of the Handlers.
+type Option func(h *Handlers)
+
+// WithConfig makes the handlers read the program rates from the given active configuration,
+// so that reloaded values apply to the following requests.
+func WithConfig(cfg *config.Current) Option {
+	return func(h *Handlers) {
+		h.cfg = cfg
+	}
+}
+
+// WithClock makes the handlers date the calculations, i.e. their last payment date, from the given clock
+// instead of the current time.
+func WithClock(now func() time.Time) Option {
+	return func(h *Handlers) {
+		h.now = now
+	}
 }
 
 // NewHandlers creates a new Handlers instance with the provided cache storage.
-func NewHandlers(store *cache.Storage) *Handlers {
-	return &Handlers{store: store}
+// Without WithConfig, the default configuration is used.
+func NewHandlers(store *cache.Storage, opts ...Option) *Handlers {
+	h := &Handlers{store: store, cfg: config.NewCurrent(config.Default()), now: time.Now, done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
+// CacheHeader is the response header of /execute telling whether the result was answered from the cache
+// ("HIT") or stored as a new entry ("MISS").
+const CacheHeader = "X-Cache"
+
 // Execute handles the POST request for performing mortgage calculations.
-// It validates the input data, calculates the mortgage details, and stores the results in cache.
+// It validates the input data, calculates the mortgage details, and stores the results in cache. A repeated
+// calculation is answered with the stored entry when memoization is enabled, as reported by X-Cache.
+// The request body is read in the format given by Content-Type, and the response is sent in the
+// format selected by Accept (JSON, XML or YAML).
 func (h *Handlers) Execute(w http.ResponseWriter, r *http.Request) {
 	// Check if the request method is POST
 	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		err := json.NewEncoder(w).Encode(models.ErrorMessage{Error: "only post method allowed"})
-		if err != nil {
-			log.Println("failed to send error message in method check execute handler")
-			return
-		}
+		writeError(w, r, http.StatusMethodNotAllowed, "only post method allowed")
 		return
 	}
 
-	// Decode the request body into ExecuteRequest structure
-	reqData := models.ExecuteReqeust{}
-	err := json.NewDecoder(r.Body).Decode(&reqData)
-	if err != nil {
-		log.Println("failed to decode request body in execute handler")
+	// Check that the response can be sent in a format the client accepts
+	if !acceptable(w, r) {
 		return
 	}
 
-	// Validate the initial payment (should be at least 20% of the object cost)
-	if !initialPaymentValidator(reqData.ObjectCost, reqData.InitialPayment) {
-		w.WriteHeader(http.StatusBadRequest)
-		err = json.NewEncoder(w).Encode(models.ErrorMessage{Error: "the initial payment should be more"})
-		if err != nil {
-			log.Println("failed to send error message in method check execute handler")
-			return
-		}
+	// Decode the request body into ExecuteRequest structure in the format given by Content-Type
+	reqData := models.ExecuteReqeust{}
+	err := readRequest(w, r, &reqData)
+	if errors.Is(err, errUnsupportedMediaType) {
+		writeError(w, r, http.StatusUnsupportedMediaType, "unsupported content type, use application/json, application/xml or application/yaml")
+		return
+	}
+	if errors.Is(err, errRequestTooLarge) {
+		metrics.ValidationFailuresTotal.Inc("invalid_body")
+		writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
 		return
 	}
-
-	// Validate the selected loan program
-	loanProgram, err := programValidator(reqData)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		programValidatorErrorHandler(w, err)
+		metrics.ValidationFailuresTotal.Inc("invalid_body")
+		middleware.Logger(r.Context()).Warn("failed to decode request body in execute handler", "error", err)
+		writeError(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	// Initialize rate and program based on the selected loan program
-	rate, program := getLoanRateAndProgram(loanProgram)
-
-	// Calculate monthly payment and overpayment
-	monthlyPayment, overpayment := monthlyPaymentCalculator(float64(reqData.ObjectCost-reqData.InitialPayment), float64(rate), reqData.Months)
-
-	// Prepare the response structure
-	resp := prepareResponse(reqData, program, rate, monthlyPayment, overpayment)
-
-	// Store the result in cache
-	h.store.Load(resp.Result)
-
-	// Send the response back to the client
-	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(resp)
+	// Validate the request and calculate the mortgage with the active rates
+	result, err := h.calculate(reqData)
 	if err != nil {
-		log.Println("failed to encode response message")
+		metrics.ValidationFailuresTotal.Inc(metrics.ValidationFailureLabel(err))
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
+
+	// Store the result in cache on behalf of the authenticated client
+	resp := models.ExecuteResponse{Result: result.Model()}
+	_, hit := h.store.Store(resp.Result, models.RecordMeta{
+		ClientID:  middleware.ClientIDFromContext(r.Context()),
+		Source:    models.SourceHTTP,
+		RequestID: middleware.RequestIDFromContext(r.Context()),
+		UserAgent: r.UserAgent(),
+	})
+	metrics.CalculationsTotal.Inc(string(result.Program))
+	metrics.CacheLookupsTotal.Inc(cacheResult(hit))
+	w.Header().Set(CacheHeader, strings.ToUpper(cacheResult(hit)))
+
+	// Send the response back to the client in the format it accepts
+	writeResponse(w, r, http.StatusOK, resp)
 }
 
 // Cache handles the GET request for fetching cached data.
 // It retrieves data from the cache if available, otherwise, returns an error message.
+// Authenticated clients only see the calculations they made themselves, and the from and to query parameters
+// limit the calculations to those stored within the time window. The response is sent in the
+// format selected by Accept (JSON, XML or YAML).
 func (h *Handlers) Cache(w http.ResponseWriter, r *http.Request) {
 	// Check if the request method is GET
 	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		err := json.NewEncoder(w).Encode(models.ErrorMessage{Error: "only get method allowed"})
-		if err != nil {
-			log.Println("failed to send error message in method check execute handler")
-			return
-		}
+		writeError(w, r, http.StatusMethodNotAllowed, "only get method allowed")
 		return
 	}
 
-	// Check if there is any data in the cache
-	if !h.store.HasData() {
-		w.WriteHeader(http.StatusBadRequest)
-		err := json.NewEncoder(w).Encode(models.ErrorMessage{Error: "empty cache"})
-		if err != nil {
-			log.Println("failed to send error message in cache data check ")
-			return
-		}
+	// Check that the response can be sent in a format the client accepts
+	if !acceptable(w, r) {
 		return
 	}
 
-	// Retrieve all data from the cache
-	data := h.store.ReadAll()
-
-	// Send the cached data in the response
-	w.WriteHeader(http.StatusOK)
-	err := json.NewEncoder(w).Encode(data)
-	if err != nil {
+	window, ok := queryWindow(w, r)
+	if !ok {
 		return
 	}
-}
-
-// monthlyPaymentCalculator calculates the monthly payment and overpayment based on the loan amount,
-// interest rate, and number of months for the mortgage.
-func monthlyPaymentCalculator(objectCost, loanRate float64, months int32) (monthlyPayment, overpayment int32) {
-	// Calculate the monthly interest rate
-	monthlyRate := loanRate / (100 * 12)
-
-	// Calculate the factor for the loan formula
-	factor := math.Pow((1 + monthlyRate), float64(months))
-
-	// Calculate the monthly payment
-	monthlyPayment = int32(math.Ceil(objectCost * (monthlyRate * factor) / (factor - 1)))
-
-	// Calculate the overpayment
-	overpayment = int32(math.Ceil(float64(monthlyPayment)*float64(months) - objectCost))
-
-	// Return the calculated values as integers
-	return monthlyPayment, overpayment
-}
-
-// programValidator validates the loan program based on the request data.
-// It checks if exactly one program flag is set to true, and returns an error if any validation fails.
-func programValidator(data models.ExecuteReqeust) (string, error) {
-	// Declare variables for error handling and to hold the result of validation checks.
-	var (
-		countTrue     int    // Tracks how many program flags are set to true
-		lastTrueField string // Holds the name of the last program flag that was set to true
-		hasAnyField   bool   // Indicates whether at least one program flag is set to true
-	)
-
-	// Check each program flag manually
-	if data.Program.Base {
-		countTrue++
-		lastTrueField = "base"
-	}
-	if data.Program.Military {
-		countTrue++
-		lastTrueField = "military"
-	}
-	if data.Program.Salary {
-		countTrue++
-		lastTrueField = "salary"
-	}
 
-	// Check if at least one program flag is set to true
-	if data.Program.Base || data.Program.Military || data.Program.Salary {
-		hasAnyField = true
-	}
+	// Retrieve the data visible to the caller from the cache
+	data := window.Filter(h.visibleEntries(r))
 
-	// Return errors if no flags or more than one flag are set to true
-	if !hasAnyField {
-		return "", errs.ErrNoTrueValues
-	}
-	if countTrue == 0 {
-		return "", errs.ErrNoTrueValues
-	}
-	if countTrue > 1 {
-		return "", errs.ErrMoreThanOneTrue
+	// Check if there is any data in the cache
+	if len(data) == 0 {
+		writeError(w, r, http.StatusBadRequest, "empty cache")
+		return
 	}
 
-	// Return the name of the program flag that was set to true
-	return lastTrueField, nil
+	// Send the cached data in the response in the format the client accepts
+	writeResponse(w, r, http.StatusOK, data)
 }
 
-// InitialPaymentValidator validates the initial payment based on the object cost.
-// The initial payment must be more than zero and at least 20% of the object cost.
-func initialPaymentValidator(objectCost, initialPayment int32) bool {
-	if initialPayment > objectCost {
-		return false
-	}
-	// If both object cost and initial payment are zero, return false
-	if objectCost == 0 && initialPayment == 0 {
-		return false
-	}
-	// If the initial payment is zero, return false
-	if initialPayment == 0 {
-		return false
-	}
-	// If the initial payment is less than 20% of the object cost, return false
-	if initialPayment*5 < objectCost {
-		return false
+// History handles the GET request for the recorded calculation requests, in the order they were made.
+// Authenticated clients only see their own requests. It responds with 404 when the history is disabled, and
+// the response is sent in the format selected by Accept (JSON, XML or YAML).
+func (h *Handlers) History(w http.ResponseWriter, r *http.Request) {
+	// Check if the request method is GET
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "only get method allowed")
+		return
 	}
 
-	// If all conditions are satisfied, return true
-	return true
-}
-
-func programValidatorErrorHandler(w http.ResponseWriter, err error) {
-	if errors.Is(err, errs.ErrNoTrueValues) {
-		err = json.NewEncoder(w).Encode(models.ErrorMessage{Error: "choose program"})
-		if err != nil {
-			log.Println("failed to send error message in program validator error handler")
-			return
-		}
+	// Check that the response can be sent in a format the client accepts
+	if !acceptable(w, r) {
 		return
 	}
-	if errors.Is(err, errs.ErrMoreThanOneTrue) {
-		err = json.NewEncoder(w).Encode(models.ErrorMessage{Error: "choose only 1 program"})
-		if err != nil {
-			log.Println("failed to send error message in program validator error handler")
-			return
-		}
+
+	if !h.store.RecordsHistory() {
+		writeError(w, r, http.StatusNotFound, "history is disabled")
 		return
 	}
+
+	// An empty history is an empty list, unlike an empty cache
+	records := h.store.History(middleware.ClientIDFromContext(r.Context()))
+	if records == nil {
+		records = []models.HistoryRecord{}
+	}
+	writeResponse(w, r, http.StatusOK, records)
 }
 
-func prepareResponse(reqData models.ExecuteReqeust, program models.Program, rate uint8, monthlyPayment, overpayment int32) models.ExecuteResponse {
-	lastDate := time.Now().AddDate(0, int(reqData.Months), 0).Format("2006-01-01")
-	return models.ExecuteResponse{
-		Result: models.Result{
-			Params: models.Params{
-				ObjectCost:     reqData.ObjectCost,
-				InitialPayment: reqData.InitialPayment,
-				Months:         reqData.Months,
-			},
-			Program: program,
-			Aggregates: models.Aggregates{
-				Rate:            rate,
-				LoanSum:         reqData.ObjectCost - reqData.InitialPayment,
-				MonthlyPayment:  monthlyPayment,
-				Overpayment:     overpayment,
-				LastPaymentDate: lastDate,
-			},
-		},
+// cacheResult returns the cache result label of a stored calculation.
+func cacheResult(hit bool) string {
+	if hit {
+		return "hit"
 	}
+	return "miss"
 }
 
-func getLoanRateAndProgram(loanProgram string) (uint8, models.Program) {
-	var rate uint8
-	program := models.Program{}
-	switch loanProgram {
-	case "base":
-		rate = 10
-		program = models.Program{Base: true}
-	case "military":
-		rate = 9
-		program = models.Program{Military: true}
-	case "salary":
-		rate = 8
-		program = models.Program{Salary: true}
+// calculate validates the request and calculates the mortgage with the active program rates. Validation
+// failures are reported as *mortgage.ValidationError.
+func (h *Handlers) calculate(reqData models.ExecuteReqeust) (mortgage.Result, error) {
+	input, err := mortgage.InputFromRequest(reqData)
+	if err != nil {
+		return mortgage.Result{}, err
 	}
-	return rate, program
+	return mortgage.New(h.cfg.Load().Programs.Rates(), mortgage.WithClock(h.now)).Calculate(input)
 }
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"sber/internal/cache"
+	"sber/internal/config"
+	"sber/internal/middleware"
 	"sber/pkg/models"
 	"testing"
 	"time"
@@ -125,3 +127,260 @@ func TestCacheHandlerEmpty(t *testing.T) {
 		}
 	})
 }
+
+func TestCacheHandlerScopedToClient(t *testing.T) {
+	mockCache := cache.New()
+	h := NewHandlers(mockCache)
+
+	// Prepopulate cache with calculations made by two clients
+	mockCache.LoadWithMeta(models.Result{Params: models.Params{ObjectCost: 100000}}, models.RecordMeta{ClientID: "bank-a"})
+	mockCache.LoadWithMeta(models.Result{Params: models.Params{ObjectCost: 200000}}, models.RecordMeta{ClientID: "bank-b"})
+	mockCache.LoadWithMeta(models.Result{Params: models.Params{ObjectCost: 300000}}, models.RecordMeta{ClientID: "bank-a"})
+
+	tests := []struct {
+		name         string
+		clientID     string
+		expectedCode int
+		expectedLen  int
+	}{
+		{"Client sees own calculations", "bank-a", http.StatusOK, 2},
+		{"Other client", "bank-b", http.StatusOK, 1},
+		{"Client without calculations", "bank-c", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/cache", nil)
+			req = req.WithContext(middleware.WithClientID(req.Context(), tt.clientID))
+			w := httptest.NewRecorder()
+
+			h.Cache(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedLen == 0 {
+				return
+			}
+
+			var response []models.CacheStorageFormat
+			json.NewDecoder(w.Body).Decode(&response)
+			if len(response) != tt.expectedLen {
+				t.Fatalf("Expected %d results, got %d", tt.expectedLen, len(response))
+			}
+			for _, entry := range response {
+				if entry.ClientID != tt.clientID {
+					t.Errorf("Expected entries of client %s, got %s", tt.clientID, entry.ClientID)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteHandlerUsesActiveRates(t *testing.T) {
+	cfg := config.Default()
+	current := config.NewCurrent(cfg)
+	h := NewHandlers(cache.New(), WithConfig(current))
+
+	execute := func() models.ExecuteResponse {
+		body, _ := json.Marshal(models.ExecuteReqeust{
+			ObjectCost:     100000,
+			InitialPayment: 20000,
+			Months:         12,
+			Program:        models.Program{Salary: true},
+		})
+		w := httptest.NewRecorder()
+		h.Execute(w, httptest.NewRequest("POST", "/execute", bytes.NewReader(body)))
+
+		var resp models.ExecuteResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp
+	}
+
+	if rate := execute().Result.Aggregates.Rate; rate != 8 {
+		t.Errorf("Expected default salary rate 8, got %d", rate)
+	}
+
+	// Swap in a configuration with a new salary rate
+	updated := *cfg
+	updated.Programs.Salary = 7
+	current.Store(&updated)
+
+	if rate := execute().Result.Aggregates.Rate; rate != 7 {
+		t.Errorf("Expected reloaded salary rate 7, got %d", rate)
+	}
+}
+
+// TestExecuteHandlerLastPaymentDate verifies that the last payment date is counted in calendar months, clamped
+// to the end of a shorter month, and formatted as a date without time.
+func TestExecuteHandlerLastPaymentDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		months   int32
+		expected string
+	}{
+		{"Middle of the month", time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC), 12, "2025-01-15"},
+		{"End of January into leap February", time.Date(2024, 1, 31, 15, 0, 0, 0, time.UTC), 1, "2024-02-29"},
+		{"End of January into February", time.Date(2025, 1, 31, 15, 0, 0, 0, time.UTC), 1, "2025-02-28"},
+		{"End of August into November", time.Date(2024, 8, 31, 15, 0, 0, 0, time.UTC), 3, "2024-11-30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlers(cache.New(), WithClock(func() time.Time { return tt.now }))
+			body, _ := json.Marshal(models.ExecuteReqeust{
+				ObjectCost:     100000,
+				InitialPayment: 20000,
+				Months:         tt.months,
+				Program:        models.Program{Base: true},
+			})
+			w := httptest.NewRecorder()
+			h.Execute(w, httptest.NewRequest("POST", "/execute", bytes.NewReader(body)))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+			}
+			var resp models.ExecuteResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if date := resp.Result.Aggregates.LastPaymentDate; date != tt.expected {
+				t.Errorf("Expected last payment date %s, got %s", tt.expected, date)
+			}
+		})
+	}
+}
+
+// TestExecuteHandlerMemoization verifies that a repeated calculation is answered from the cache, as reported
+// by X-Cache, and recorded in the history served by History.
+func TestExecuteHandlerMemoization(t *testing.T) {
+	store := cache.New(cache.WithMemoization(), cache.WithHistory(100))
+	h := NewHandlers(store)
+
+	body, _ := json.Marshal(models.ExecuteReqeust{
+		ObjectCost:     100000,
+		InitialPayment: 20000,
+		Months:         12,
+		Program:        models.Program{Base: true},
+	})
+	for _, expected := range []string{"MISS", "HIT"} {
+		w := httptest.NewRecorder()
+		h.Execute(w, httptest.NewRequest("POST", "/execute", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if cached := w.Header().Get(CacheHeader); cached != expected {
+			t.Errorf("Expected X-Cache %s, got %q", expected, cached)
+		}
+	}
+
+	entries := store.ReadAll()
+	if len(entries) != 1 || entries[0].Hits != 1 {
+		t.Fatalf("Expected 1 entry with 1 hit, got %+v", entries)
+	}
+
+	w := httptest.NewRecorder()
+	h.History(w, httptest.NewRequest("GET", "/cache/history", nil))
+	var records []models.HistoryRecord
+	json.NewDecoder(w.Body).Decode(&records)
+	if w.Code != http.StatusOK || len(records) != 2 || records[0].Cache != "miss" || records[1].Cache != "hit" {
+		t.Errorf("Expected a miss and a hit in the history, got %d %+v", w.Code, records)
+	}
+}
+
+// TestHistoryHandlerDisabled verifies that the history is reported as missing when it is not recorded.
+func TestHistoryHandlerDisabled(t *testing.T) {
+	h := NewHandlers(cache.New())
+
+	w := httptest.NewRecorder()
+	h.History(w, httptest.NewRequest("GET", "/cache/history", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+// TestCacheHandlerWindow verifies that the from and to query parameters limit the calculations to a time
+// window, and that the audit metadata of the calculations is returned.
+func TestCacheHandlerWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := cache.New(cache.WithClock(func() time.Time { return now }))
+	h := NewHandlers(store)
+
+	// Store calculations on three days
+	for i := range 3 {
+		now = time.Date(2024, 1, 1+i, 12, 0, 0, 0, time.UTC)
+		store.LoadWithMeta(models.Result{Params: models.Params{ObjectCost: int32(100000 * (i + 1))}},
+			models.RecordMeta{Source: models.SourceHTTP, RequestID: "req", UserAgent: "crm/1.0"})
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expectedIDs  []int32
+	}{
+		{"Without window", "", http.StatusOK, []int32{0, 1, 2}},
+		{"From date", "?from=2024-01-02", http.StatusOK, []int32{1, 2}},
+		{"To date is exclusive", "?to=2024-01-02", http.StatusOK, []int32{0}},
+		{"RFC 3339 window", "?from=2024-01-02T00:00:00Z&to=2024-01-02T23:59:59Z", http.StatusOK, []int32{1}},
+		{"Time zone offset", "?from=2024-01-02T14:00:00%2B03:00&to=2024-01-02T16:00:00%2B03:00", http.StatusOK, []int32{1}},
+		{"Empty result", "?from=2024-02-01", http.StatusBadRequest, nil},
+		{"Invalid from", "?from=yesterday", http.StatusBadRequest, nil},
+		{"From after to", "?from=2024-01-03&to=2024-01-02", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.Cache(w, httptest.NewRequest("GET", "/cache"+tt.query, nil))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedIDs == nil {
+				return
+			}
+
+			var response []models.CacheStorageFormat
+			json.NewDecoder(w.Body).Decode(&response)
+			if len(response) != len(tt.expectedIDs) {
+				t.Fatalf("Expected %d results, got %d", len(tt.expectedIDs), len(response))
+			}
+			for i, entry := range response {
+				if entry.ID != tt.expectedIDs[i] {
+					t.Errorf("Expected entry %d, got %d", tt.expectedIDs[i], entry.ID)
+				}
+				if entry.CreatedAt.IsZero() || entry.Source != "http" || entry.RequestID != "req" || entry.UserAgent != "crm/1.0" {
+					t.Errorf("Expected audit metadata, got %+v", entry)
+				}
+			}
+		})
+	}
+}
+
+// TestExecuteHandlerRecordsMetadata verifies that calculations record the request that made them.
+func TestExecuteHandlerRecordsMetadata(t *testing.T) {
+	store := cache.New()
+	h := NewHandlers(store)
+
+	body, _ := json.Marshal(models.ExecuteReqeust{
+		ObjectCost:     100000,
+		InitialPayment: 20000,
+		Months:         12,
+		Program:        models.Program{Base: true},
+	})
+	req := httptest.NewRequest("POST", "/execute", bytes.NewReader(body))
+	req.Header.Set("User-Agent", "crm/1.0")
+	ctx := middleware.WithRequestID(middleware.WithClientID(req.Context(), "bank-a"), "req-1")
+	h.Execute(httptest.NewRecorder(), req.WithContext(ctx))
+
+	entry, ok := store.Get(0)
+	if !ok {
+		t.Fatal("Expected the calculation to be stored")
+	}
+	if entry.ClientID != "bank-a" || entry.Source != models.SourceHTTP || entry.RequestID != "req-1" ||
+		entry.UserAgent != "crm/1.0" || time.Since(entry.CreatedAt) > time.Minute {
+		t.Errorf("Unexpected metadata %+v", entry)
+	}
+}
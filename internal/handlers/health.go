@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"sber/pkg/models"
+)
+
+// SetReady marks the service as ready or not ready to receive traffic. The server flips readiness off
+// before draining connections on shutdown, so that load balancers stop routing new requests to it.
+func (h *Handlers) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// Healthz handles the liveness probe. It reports that the process is running and able to serve HTTP.
+func (h *Handlers) Healthz(w http.ResponseWriter, r *http.Request) {
+	// Check if the request method is GET
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "only get method allowed")
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, models.StatusMessage{Status: "ok"})
+}
+
+// Readyz handles the readiness probe. It fails while the server is starting or shutting down
+// and when the storage backend is unavailable.
+func (h *Handlers) Readyz(w http.ResponseWriter, r *http.Request) {
+	// Check if the request method is GET
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "only get method allowed")
+		return
+	}
+
+	// Check if the server accepts traffic
+	if !h.ready.Load() {
+		writeError(w, r, http.StatusServiceUnavailable, "not ready")
+		return
+	}
+
+	// Check if the storage backend is available
+	if err := h.store.Ping(); err != nil {
+		writeError(w, r, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, models.StatusMessage{Status: "ready"})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/cache"
+	"sber/pkg/models"
+	"testing"
+)
+
+func TestHealthzHandler(t *testing.T) {
+	h := NewHandlers(cache.New())
+
+	tests := []struct {
+		name         string
+		method       string
+		expectedCode int
+	}{
+		{"Liveness probe", http.MethodGet, http.StatusOK},
+		{"Invalid method", http.MethodPost, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.Healthz(w, httptest.NewRequest(tt.method, "/healthz", nil))
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestReadyzHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		store         *cache.Storage
+		ready         bool
+		expectedCode  int
+		expectedError string
+	}{
+		{"Ready", cache.New(), true, http.StatusOK, ""},
+		{"Not ready during shutdown", cache.New(), false, http.StatusServiceUnavailable, "not ready"},
+		{"Storage unavailable", &cache.Storage{}, true, http.StatusServiceUnavailable, "storage is unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlers(tt.store)
+			h.SetReady(tt.ready)
+
+			w := httptest.NewRecorder()
+			h.Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+
+			if tt.expectedError != "" {
+				var errMsg models.ErrorMessage
+				json.NewDecoder(w.Body).Decode(&errMsg)
+				if errMsg.Error != tt.expectedError {
+					t.Errorf("Expected error '%s', got '%s'", tt.expectedError, errMsg.Error)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"sber/pkg/models"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// codec encodes and decodes API messages in one of the supported formats.
+type codec struct {
+	mediaTypes []string                       // Media types of the format, the first one is sent in Content-Type
+	marshal    func(v any) ([]byte, error)    // Encodes a response message
+	unmarshal  func(data []byte, v any) error // Decodes a request message
+}
+
+// The supported formats. JSON is the default when the client expresses no preference.
+var (
+	jsonCodec = codec{
+		mediaTypes: []string{"application/json"},
+		marshal: func(v any) ([]byte, error) {
+			data, err := json.Marshal(v)
+			return append(data, '\n'), err
+		},
+		unmarshal: json.Unmarshal,
+	}
+	xmlCodec = codec{
+		mediaTypes: []string{"application/xml", "text/xml"},
+		marshal: func(v any) ([]byte, error) {
+			data, err := xml.Marshal(xmlDocument(v))
+			return append([]byte(xml.Header), append(data, '\n')...), err
+		},
+		unmarshal: xml.Unmarshal,
+	}
+	yamlCodec = codec{
+		mediaTypes: []string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"},
+		marshal:    yaml.Marshal,
+		unmarshal:  yaml.Unmarshal,
+	}
+
+	// codecs lists the formats in order of preference for wildcard media ranges
+	codecs = []codec{jsonCodec, xmlCodec, yamlCodec}
+)
+
+// contentType returns the value of the Content-Type header for responses in the format.
+func (c codec) contentType() string {
+	return c.mediaTypes[0]
+}
+
+// matches reports whether the media type or media range (such as "application/*") selects the format.
+func (c codec) matches(mediaRange string) bool {
+	for _, mediaType := range c.mediaTypes {
+		if mediaRange == "*/*" || mediaRange == mediaType {
+			return true
+		}
+		if prefix, found := strings.CutSuffix(mediaRange, "/*"); found && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateCodec selects the response format from the Accept header, preferring media ranges with higher
+// quality values and, among equal ones, the order given by the client. JSON is used when the header is
+// empty. It returns false if none of the accepted media types is supported.
+func negotiateCodec(accept string) (codec, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return jsonCodec, true
+	}
+
+	type mediaRange struct {
+		name    string
+		quality float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		name, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{name: name, quality: quality})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+
+	for _, r := range ranges {
+		for _, c := range codecs {
+			if c.matches(r.name) {
+				return c, true
+			}
+		}
+	}
+	return codec{}, false
+}
+
+// requestCodec selects the format of the request body from the Content-Type header. JSON is assumed when the
+// header is missing. It returns false if the media type is not supported.
+func requestCodec(contentType string) (codec, bool) {
+	if strings.TrimSpace(contentType) == "" {
+		return jsonCodec, true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || strings.Contains(mediaType, "*") {
+		return codec{}, false
+	}
+	for _, c := range codecs {
+		if c.matches(mediaType) {
+			return c, true
+		}
+	}
+	return codec{}, false
+}
+
+// acceptable checks that the response can be produced in a format accepted by the client. It sends
+// a 406 response and returns false otherwise, before the request is processed.
+func acceptable(w http.ResponseWriter, r *http.Request) bool {
+	if _, ok := negotiateCodec(r.Header.Get("Accept")); !ok {
+		writeError(w, r, http.StatusNotAcceptable, "unsupported accept type, use application/json, application/xml or application/yaml")
+		return false
+	}
+	return true
+}
+
+// maxRequestBodySize is the largest request body readRequest accepts, so that a client cannot make the server
+// buffer an arbitrarily large body.
+const maxRequestBodySize = 1 << 20
+
+// Errors returned by readRequest.
+var (
+	// errUnsupportedMediaType is returned when the request body is in an unsupported format.
+	errUnsupportedMediaType = errors.New("unsupported content type")
+	// errRequestTooLarge is returned when the request body is larger than maxRequestBodySize.
+	errRequestTooLarge = errors.New("request body too large")
+)
+
+// readRequest decodes the request body into v in the format given by the Content-Type header.
+// It returns errUnsupportedMediaType if the format is not supported and errRequestTooLarge if the body
+// is larger than maxRequestBodySize.
+func readRequest(w http.ResponseWriter, r *http.Request, v any) error {
+	c, ok := requestCodec(r.Header.Get("Content-Type"))
+	if !ok {
+		return errUnsupportedMediaType
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return errRequestTooLarge
+	}
+	if err != nil {
+		return err
+	}
+	return c.unmarshal(data, v)
+}
+
+// xmlDocument wraps values that have no single root element in XML, such as lists, into their XML document type.
+func xmlDocument(v any) any {
+	if entries, ok := v.([]models.CacheStorageFormat); ok {
+		return models.CacheResponse{Results: entries}
+	}
+	if records, ok := v.([]models.HistoryRecord); ok {
+		return models.HistoryResponse{Records: records}
+	}
+	if webhooks, ok := v.([]models.Webhook); ok {
+		return models.WebhooksResponse{Webhooks: webhooks}
+	}
+	return v
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/cache"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestNegotiateCodec(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		expected string
+		ok       bool
+	}{
+		{"No preference", "", "application/json", true},
+		{"JSON", "application/json", "application/json", true},
+		{"XML", "application/xml", "application/xml", true},
+		{"Legacy XML", "text/xml; charset=utf-8", "application/xml", true},
+		{"YAML", "application/x-yaml", "application/yaml", true},
+		{"Wildcard", "*/*", "application/json", true},
+		{"Type wildcard", "text/*", "application/xml", true},
+		{"Quality values", "application/json;q=0.5, application/yaml", "application/yaml", true},
+		{"Order among equal quality", "application/xml, application/json", "application/xml", true},
+		{"Unsupported types are skipped", "text/html, application/yaml;q=0.1", "application/yaml", true},
+		{"Excluded type", "application/json;q=0", "", false},
+		{"Unsupported", "text/html", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := negotiateCodec(tt.accept)
+			if ok != tt.ok {
+				t.Fatalf("Expected ok %v, got %v", tt.ok, ok)
+			}
+			if ok && c.contentType() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, c.contentType())
+			}
+		})
+	}
+}
+
+func TestRequestCodec(t *testing.T) {
+	tests := []struct {
+		contentType string
+		expected    string
+		ok          bool
+	}{
+		{"", "application/json", true},
+		{"application/json; charset=utf-8", "application/json", true},
+		{"text/xml", "application/xml", true},
+		{"application/yaml", "application/yaml", true},
+		{"application/*", "", false},
+		{"text/plain", "", false},
+		{"invalid;;", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			c, ok := requestCodec(tt.contentType)
+			if ok != tt.ok || (ok && c.contentType() != tt.expected) {
+				t.Errorf("Expected (%s, %v), got (%s, %v)", tt.expected, tt.ok, c.contentType(), ok)
+			}
+		})
+	}
+}
+
+func TestExecuteHandlerFormats(t *testing.T) {
+	xmlBody := `<request><object_cost>5000000</object_cost><initial_payment>1000000</initial_payment>` +
+		`<months>240</months><program><salary>true</salary></program></request>`
+	yamlBody := "object_cost: 5000000\ninitial_payment: 1000000\nmonths: 240\nprogram:\n  salary: true\n"
+
+	tests := []struct {
+		name                string
+		contentType         string
+		accept              string
+		body                string
+		expectedCode        int
+		expectedContentType string
+	}{
+		{"XML in, XML out", "application/xml", "application/xml", xmlBody, http.StatusOK, "application/xml"},
+		{"YAML in, JSON out", "application/yaml", "", yamlBody, http.StatusOK, "application/json"},
+		{"JSON in, YAML out", "application/json", "application/yaml",
+			`{"object_cost":5000000,"initial_payment":1000000,"months":240,"program":{"salary":true}}`, http.StatusOK, "application/yaml"},
+		{"Unsupported content type", "text/plain", "application/xml", "object_cost=5000000", http.StatusUnsupportedMediaType, "application/xml"},
+		{"Unsupported accept", "application/xml", "text/html", xmlBody, http.StatusNotAcceptable, "application/json"},
+		{"Invalid body", "application/xml", "application/xml", "<request>", http.StatusBadRequest, "application/xml"},
+		{"Body too large", "application/json", "application/json", `{"object_cost":5000000,"padding":"` +
+			strings.Repeat("x", maxRequestBodySize) + `"}`, http.StatusRequestEntityTooLarge, "application/json"},
+		{"Validation error in YAML", "application/yaml", "application/yaml", "object_cost: 100\ninitial_payment: 1\nmonths: 12\n",
+			http.StatusBadRequest, "application/yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlers(cache.New())
+			req := httptest.NewRequest("POST", "/execute", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+
+			h.Execute(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != tt.expectedContentType {
+				t.Errorf("Expected content type %s, got %s", tt.expectedContentType, contentType)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			// The result can be decoded from the negotiated format
+			c, _ := negotiateCodec(tt.accept)
+			var response models.ExecuteResponse
+			if err := c.unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Result.Aggregates.MonthlyPayment != 33458 {
+				t.Errorf("Expected monthly payment 33458, got %d", response.Result.Aggregates.MonthlyPayment)
+			}
+		})
+	}
+}
+
+func TestCacheHandlerFormats(t *testing.T) {
+	mockCache := cache.New()
+	h := NewHandlers(mockCache)
+	mockCache.Load(models.Result{Params: models.Params{ObjectCost: 100000}})
+	mockCache.Load(models.Result{Params: models.Params{ObjectCost: 200000}})
+
+	// XML lists are wrapped into a root element
+	req := httptest.NewRequest("GET", "/cache", nil)
+	req.Header.Set("Accept", "application/xml")
+	w := httptest.NewRecorder()
+	h.Cache(w, req)
+
+	if !strings.HasPrefix(w.Body.String(), xml.Header+"<calculations><calculation><id>0</id>") {
+		t.Errorf("Unexpected XML document %s", w.Body.String())
+	}
+	var xmlResponse models.CacheResponse
+	if err := xml.Unmarshal(w.Body.Bytes(), &xmlResponse); err != nil || len(xmlResponse.Results) != 2 {
+		t.Errorf("Expected 2 calculations in XML, got %+v (%v)", xmlResponse, err)
+	}
+
+	// YAML documents contain the list itself
+	req = httptest.NewRequest("GET", "/cache", nil)
+	req.Header.Set("Accept", "application/yaml")
+	w = httptest.NewRecorder()
+	h.Cache(w, req)
+
+	if !strings.HasPrefix(w.Body.String(), "- id: 0\n") {
+		t.Errorf("Unexpected YAML document %s", w.Body.String())
+	}
+	var yamlResponse []models.CacheStorageFormat
+	if err := yaml.Unmarshal(w.Body.Bytes(), &yamlResponse); err != nil || len(yamlResponse) != 2 {
+		t.Fatalf("Expected 2 calculations in YAML, got %+v (%v)", yamlResponse, err)
+	}
+	if yamlResponse[1].Params.ObjectCost != 200000 {
+		t.Errorf("Unexpected second calculation %+v", yamlResponse[1])
+	}
+
+	// Unsupported formats are rejected before reading the cache
+	req = httptest.NewRequest("GET", "/cache", nil)
+	req.Header.Set("Accept", "text/html")
+	w = httptest.NewRecorder()
+	h.Cache(w, req)
+
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("Expected status %d, got %d", http.StatusNotAcceptable, w.Code)
+	}
+}
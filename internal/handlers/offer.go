@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sber/internal/middleware"
+	"sber/internal/offer"
+	"sber/internal/pdf"
+	"sync"
+	"time"
+)
+
+// offerLogo caches the decoded logo of the offers, so that the file is read and decoded once per version rather
+// than on every request.
+type offerLogo struct {
+	mu      sync.Mutex // Protects the fields below
+	file    string     // Path of the cached file
+	modTime time.Time  // Modification time of the cached version
+	size    int64      // Size of the cached version
+	image   *pdf.Image // The decoded logo, nil if the version could not be decoded
+}
+
+// load returns the decoded logo in the file, reading it again only when the file changes. A logo that cannot be
+// read or decoded is a configuration problem, the offer is still useful without it: the problem is logged once
+// per version of the file and nil is returned.
+func (l *offerLogo) load(ctx context.Context, file string) *pdf.Image {
+	info, err := os.Stat(file)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to read offer logo", "file", file, "error", err)
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if file == l.file && info.ModTime().Equal(l.modTime) && info.Size() == l.size {
+		return l.image
+	}
+	l.file, l.modTime, l.size, l.image = file, info.ModTime(), info.Size(), nil
+
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to read offer logo", "file", file, "error", err)
+		return nil
+	}
+	if l.image, err = pdf.DecodeImage(data); err != nil {
+		middleware.Logger(ctx).Error("failed to decode offer logo, rendering offers without it", "file", file, "error", err)
+	}
+	return l.image
+}
+
+// Offer handles the GET request for the printable PDF offer document of a cached calculation. The calculation
+// is identified by the id path value and is only visible to the client that made it. The header title, logo
+// and footer disclaimer are taken from the active configuration.
+func (h *Handlers) Offer(w http.ResponseWriter, r *http.Request) {
+	// Check if the request method is GET
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "only get method allowed")
+		return
+	}
+
+	entry, ok := h.visibleEntry(w, r)
+	if !ok {
+		return
+	}
+
+	// Build the payment schedule of the calculation
+	schedule, err := paymentSchedule(entry)
+	if err != nil {
+		middleware.Logger(r.Context()).Error("failed to build payment schedule", "id", entry.ID, "error", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to build payment schedule")
+		return
+	}
+
+	cfg := h.cfg.Load().Offer
+	opts := offer.Options{
+		Title:      cfg.Title,
+		Disclaimer: cfg.Disclaimer,
+		IssuedAt:   time.Now(),
+	}
+
+	if cfg.LogoFile != "" {
+		opts.Logo = h.logo.load(r.Context(), cfg.LogoFile)
+	}
+
+	// Render the document before sending, so that failures still produce an error response
+	var buf bytes.Buffer
+	if err = offer.Render(&buf, entry, schedule, opts); err != nil {
+		middleware.Logger(r.Context()).Error("failed to render offer", "id", entry.ID, "error", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to render offer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="offer-%d.pdf"`, entry.ID))
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(buf.Bytes()); err != nil {
+		middleware.Logger(r.Context()).Error("failed to write offer", "error", err)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sber/internal/config"
+	"sber/internal/middleware"
+	"testing"
+)
+
+func TestOfferHandler(t *testing.T) {
+	h := newExportTestHandlers(t, "bank-a")
+
+	tests := []struct {
+		name         string
+		method       string
+		id           string
+		clientID     string
+		expectedCode int
+	}{
+		{"Offer of own calculation", "GET", "0", "bank-a", http.StatusOK},
+		{"Calculation of another client", "GET", "0", "bank-b", http.StatusNotFound},
+		{"Missing calculation", "GET", "5", "bank-a", http.StatusNotFound},
+		{"Invalid method", "POST", "0", "bank-a", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/cache/"+tt.id+"/offer", nil)
+			req.SetPathValue("id", tt.id)
+			req = req.WithContext(middleware.WithClientID(req.Context(), tt.clientID))
+			w := httptest.NewRecorder()
+
+			h.Offer(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+			if w.Header().Get("Content-Type") != "application/pdf" || !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-")) {
+				t.Errorf("Expected a PDF document, got %s", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestOfferHandlerUsesActiveBranding(t *testing.T) {
+	cfg := config.Default()
+	cfg.Offer.Title = "Partner Bank"
+	cfg.Offer.LogoFile = filepath.Join(t.TempDir(), "missing.png")
+	current := config.NewCurrent(cfg)
+
+	h := newExportTestHandlers(t, "")
+	WithConfig(current)(h)
+
+	req := httptest.NewRequest("GET", "/cache/0/offer", nil)
+	req.SetPathValue("id", "0")
+	w := httptest.NewRecorder()
+
+	h.Offer(w, req)
+
+	// A missing logo is logged, and the offer is rendered without it
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte("(Partner Bank)")) {
+		t.Error("Expected the configured title in the document")
+	}
+
+	// A logo that cannot be decoded is logged as well
+	if err := os.WriteFile(cfg.Offer.LogoFile, []byte("not an image"), 0600); err != nil {
+		t.Fatalf("failed to write logo: %v", err)
+	}
+	w = httptest.NewRecorder()
+	h.Offer(w, req)
+	if w.Code != http.StatusOK || bytes.Contains(w.Body.Bytes(), []byte("/Im1 Do")) {
+		t.Errorf("Expected the offer without a logo, got status %d", w.Code)
+	}
+
+	// A valid logo is drawn once the file is replaced
+	var logo bytes.Buffer
+	if err := png.Encode(&logo, image.NewRGBA(image.Rect(0, 0, 300, 60))); err != nil {
+		t.Fatalf("failed to encode logo: %v", err)
+	}
+	if err := os.WriteFile(cfg.Offer.LogoFile, logo.Bytes(), 0600); err != nil {
+		t.Fatalf("failed to write logo: %v", err)
+	}
+	w = httptest.NewRecorder()
+	h.Offer(w, req)
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte("/Im1 Do")) {
+		t.Errorf("Expected the offer with the logo, got status %d", w.Code)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sber/internal/middleware"
+	"sber/pkg/models"
+)
+
+// writeJSON sends the value as a JSON response with the given status code.
+// Encoding failures are logged with the request ID, since the status has already been sent.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		middleware.Logger(r.Context()).Error("failed to encode response message",
+			"path", r.URL.Path, "status", status, "error", err)
+		return
+	}
+}
+
+// writeResponse sends the value with the given status code in the format selected by the Accept header,
+// falling back to JSON when none of the accepted formats is supported. The value is encoded before the
+// status is sent, so that encoding failures are answered with a 500 response.
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, v any) {
+	c, ok := negotiateCodec(r.Header.Get("Accept"))
+	if !ok {
+		c = jsonCodec
+	}
+
+	body, err := c.marshal(v)
+	if err != nil {
+		middleware.Logger(r.Context()).Error("failed to encode response message",
+			"path", r.URL.Path, "status", status, "format", c.contentType(), "error", err)
+		writeJSON(w, r, http.StatusInternalServerError, models.ErrorMessage{Error: "failed to encode response"})
+		return
+	}
+
+	w.Header().Set("Content-Type", c.contentType())
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		middleware.Logger(r.Context()).Error("failed to write response message", "path", r.URL.Path, "error", err)
+	}
+}
+
+// writeError sends an ErrorMessage with the given status code in the format selected by the Accept header.
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeResponse(w, r, status, models.ErrorMessage{Error: message})
+}
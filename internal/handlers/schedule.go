@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"sber/pkg/models"
+	"sber/pkg/mortgage"
+)
+
+// paymentSchedule builds the amortization schedule of a stored calculation with the calculation engine.
+func paymentSchedule(entry models.CacheStorageFormat) ([]models.Payment, error) {
+	result, err := mortgage.ResultFromModel(models.Result{
+		Params:     entry.Params,
+		Program:    entry.Program,
+		Aggregates: entry.Aggregates,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Schedule()
+}
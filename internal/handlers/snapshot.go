@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sber/internal/cache"
+	"sber/internal/middleware"
+	"sber/pkg/models"
+	"time"
+)
+
+// snapshotTransferTimeout is the time allowed to download or upload a snapshot, which can take longer than the
+// timeouts of the server.
+const snapshotTransferTimeout = 5 * time.Minute
+
+// Snapshot handles the administrative requests for snapshots of the cache. GET downloads a snapshot of all the
+// stored calculations with the ID counter, as JSON or, with compress=gzip, as gzip-compressed JSON. POST restores
+// a snapshot sent as the body in either form, merging it into the stored calculations or, with mode=replace,
+// replacing them; the outcome is sent in the format selected by Accept.
+func (h *Handlers) Snapshot(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.exportSnapshot(w, r)
+	case http.MethodPost:
+		h.importSnapshot(w, r)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "only get and post methods allowed")
+	}
+}
+
+// exportSnapshot sends a snapshot of the cache as an attachment.
+func (h *Handlers) exportSnapshot(w http.ResponseWriter, r *http.Request) {
+	compress := false
+	switch r.URL.Query().Get("compress") {
+	case "":
+	case "gzip":
+		compress = true
+	default:
+		writeError(w, r, http.StatusBadRequest, "invalid compress parameter, use gzip")
+		return
+	}
+
+	// Snapshots are large, so they outlive the write timeout of the server
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Now().Add(snapshotTransferTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		middleware.Logger(r.Context()).Warn("failed to extend write deadline of snapshot", "error", err)
+	}
+
+	snap := h.store.Snapshot()
+	filename := "mortgage-snapshot-" + snap.CreatedAt.Format("20060102T150405Z") + ".json"
+	contentType := "application/json"
+	if compress {
+		filename += ".gz"
+		contentType = "application/gzip"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	if err := cache.WriteSnapshot(w, snap, compress); err != nil {
+		middleware.Logger(r.Context()).Error("failed to send snapshot", "error", err)
+		return
+	}
+	middleware.Logger(r.Context()).Info("snapshot exported", "entries", len(snap.Entries), "id_counter", snap.IDCounter)
+}
+
+// importSnapshot restores the snapshot sent as the request body.
+func (h *Handlers) importSnapshot(w http.ResponseWriter, r *http.Request) {
+	// Check that the response can be sent in a format the client accepts
+	if !acceptable(w, r) {
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = models.RestoreMerge
+	}
+	if mode != models.RestoreMerge && mode != models.RestoreReplace {
+		writeError(w, r, http.StatusBadRequest, "invalid mode parameter, use merge or replace")
+		return
+	}
+
+	// Snapshots are large, so they outlive the timeouts of the server, but their size is bounded
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(snapshotTransferTimeout)
+	for _, extend := range []func(time.Time) error{rc.SetReadDeadline, rc.SetWriteDeadline} {
+		if err := extend(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			middleware.Logger(r.Context()).Warn("failed to extend deadline of snapshot", "error", err)
+		}
+	}
+	snap, err := cache.ReadSnapshot(http.MaxBytesReader(w, r.Body, cache.MaxSnapshotSize))
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeError(w, r, http.StatusRequestEntityTooLarge, "snapshot is too large")
+		return
+	case err != nil:
+		// The errors of ReadSnapshot wrap errs.ErrInvalidSnapshot and describe the problem
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.store.Restore(snap, mode)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	middleware.Logger(r.Context()).Info("snapshot restored", "mode", mode, "imported", result.Imported,
+		"renumbered", result.Renumbered, "skipped", result.Skipped, "id_counter", result.IDCounter)
+	writeResponse(w, r, http.StatusOK, result)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/cache"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotHandler(t *testing.T) {
+	source := cache.New()
+	for _, cost := range []int32{1_000_000, 2_000_000} {
+		source.LoadWithMeta(models.Result{Params: models.Params{ObjectCost: cost}}, models.RecordMeta{ClientID: "bank"})
+	}
+	target := cache.New()
+	target.Load(models.Result{Params: models.Params{ObjectCost: 9_000_000}})
+
+	for _, compress := range []string{"", "gzip"} {
+		t.Run("Compress "+compress, func(t *testing.T) {
+			// Download the snapshot of the source
+			w := httptest.NewRecorder()
+			NewHandlers(source).Snapshot(w, httptest.NewRequest("GET", "/admin/snapshot?compress="+compress, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+			}
+			if !strings.Contains(w.Header().Get("Content-Disposition"), "attachment; filename=\"mortgage-snapshot-") {
+				t.Errorf("Unexpected Content-Disposition %q", w.Header().Get("Content-Disposition"))
+			}
+			snapshot := w.Body.Bytes()
+
+			// Restore it into a fresh copy of the target, replacing its calculations
+			restored := cache.New()
+			restored.Load(models.Result{Params: models.Params{ObjectCost: 9_000_000}})
+			w = httptest.NewRecorder()
+			NewHandlers(restored).Snapshot(w, httptest.NewRequest("POST", "/admin/snapshot?mode=replace", bytes.NewReader(snapshot)))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+			}
+			var result models.SnapshotImport
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			expected := models.SnapshotImport{Mode: models.RestoreReplace, Imported: 2, IDCounter: 2}
+			if result != expected || restored.Len() != 2 {
+				t.Errorf("Expected %+v with 2 entries, got %+v with %d", expected, result, restored.Len())
+			}
+		})
+	}
+
+	// Merging into the target renumbers the colliding entry
+	w := httptest.NewRecorder()
+	NewHandlers(source).Snapshot(w, httptest.NewRequest("GET", "/admin/snapshot", nil))
+	snapshot := w.Body.String()
+	w = httptest.NewRecorder()
+	NewHandlers(target).Snapshot(w, httptest.NewRequest("POST", "/admin/snapshot", strings.NewReader(snapshot)))
+	if !strings.Contains(w.Body.String(), `"renumbered":1`) || target.Len() != 3 {
+		t.Errorf("Expected one renumbered entry, got %d: %s", target.Len(), w.Body)
+	}
+}
+
+func TestSnapshotHandlerErrors(t *testing.T) {
+	h := NewHandlers(cache.New())
+
+	tests := []struct {
+		name          string
+		method        string
+		url           string
+		body          string
+		expectedCode  int
+		expectedError string
+	}{
+		{"Invalid compression", "GET", "/admin/snapshot?compress=zip", "", http.StatusBadRequest, "invalid compress parameter"},
+		{"Invalid mode", "POST", "/admin/snapshot?mode=append", `{"version": 1}`, http.StatusBadRequest, "invalid mode parameter"},
+		{"Invalid snapshot", "POST", "/admin/snapshot", `{"version": 1, "id_counter": 0, "entries": [{"id": 0}]}`, http.StatusBadRequest, "invalid snapshot: entry ID 0"},
+		{"Not a snapshot", "POST", "/admin/snapshot", `[]`, http.StatusBadRequest, "invalid snapshot"},
+		{"Invalid method", "DELETE", "/admin/snapshot", "", http.StatusMethodNotAllowed, "only get and post methods allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.Snapshot(w, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedCode || !strings.Contains(w.Body.String(), tt.expectedError) {
+				t.Errorf("Expected %d %q, got %d: %s", tt.expectedCode, tt.expectedError, w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"sber/internal/stats"
+)
+
+// Stats handles the GET request for the statistics of the cached calculations visible to the caller: the number
+// of calculations per program, the distributions of the loan amount, the term, the initial payment share and the
+// monthly payment, and a histogram of the loan amounts. The from and to query parameters limit the statistics to
+// a time window, as in Cache. Unlike Cache, an empty cache is not an error: the statistics are all zero. The
+// response is sent in the format selected by Accept (JSON, XML or YAML).
+func (h *Handlers) Stats(w http.ResponseWriter, r *http.Request) {
+	// Check if the request method is GET
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "only get method allowed")
+		return
+	}
+
+	// Check that the response can be sent in a format the client accepts
+	if !acceptable(w, r) {
+		return
+	}
+
+	window, ok := queryWindow(w, r)
+	if !ok {
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, stats.Compute(window.Filter(h.visibleEntries(r))))
+}
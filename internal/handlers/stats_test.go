@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/cache"
+	"sber/internal/middleware"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsHandler(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := cache.New(cache.WithClock(func() time.Time { return now }))
+	h := NewHandlers(store)
+
+	// bank-a calculates on two days, bank-b on the second one
+	load := func(clientID string, program models.Program, loanSum int32) {
+		store.LoadWithMeta(models.Result{
+			Params:     models.Params{ObjectCost: loanSum * 5 / 4, InitialPayment: loanSum / 4, Months: 120},
+			Program:    program,
+			Aggregates: models.Aggregates{LoanSum: loanSum, MonthlyPayment: loanSum / 100},
+		}, models.RecordMeta{ClientID: clientID})
+	}
+	load("bank-a", models.Program{Base: true}, 2_000_000)
+	now = now.AddDate(0, 0, 1)
+	load("bank-a", models.Program{Salary: true}, 4_000_000)
+	load("bank-b", models.Program{Military: true}, 8_000_000)
+
+	tests := []struct {
+		name             string
+		clientID         string
+		query            string
+		expectedCode     int
+		expectedCount    int
+		expectedPrograms models.ProgramCounts
+		expectedMedian   float64
+	}{
+		{"All calculations", "", "", http.StatusOK, 3, models.ProgramCounts{Base: 1, Military: 1, Salary: 1}, 4_000_000},
+		{"Client calculations", "bank-a", "", http.StatusOK, 2, models.ProgramCounts{Base: 1, Salary: 1}, 3_000_000},
+		{"Time window", "", "?from=2024-01-02", http.StatusOK, 2, models.ProgramCounts{Military: 1, Salary: 1}, 6_000_000},
+		{"No calculations", "bank-c", "", http.StatusOK, 0, models.ProgramCounts{}, 0},
+		{"Invalid window", "", "?to=tomorrow", http.StatusBadRequest, 0, models.ProgramCounts{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/stats"+tt.query, nil)
+			if tt.clientID != "" {
+				req = req.WithContext(middleware.WithClientID(req.Context(), tt.clientID))
+			}
+			w := httptest.NewRecorder()
+
+			h.Stats(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var stats models.Stats
+			json.NewDecoder(w.Body).Decode(&stats)
+			if stats.Count != tt.expectedCount || stats.Programs != tt.expectedPrograms {
+				t.Errorf("Expected %d calculations %+v, got %d %+v", tt.expectedCount, tt.expectedPrograms,
+					stats.Count, stats.Programs)
+			}
+			if stats.LoanSum.Median != tt.expectedMedian {
+				t.Errorf("Expected median loan sum %v, got %v", tt.expectedMedian, stats.LoanSum.Median)
+			}
+			if tt.expectedCount > 0 && stats.InitialPaymentShare.Median != 20 {
+				t.Errorf("Expected median initial payment share 20, got %v", stats.InitialPaymentShare.Median)
+			}
+		})
+	}
+}
+
+// TestStatsHandlerXML verifies that the statistics are sent as an XML document with a bucket element per range.
+func TestStatsHandlerXML(t *testing.T) {
+	store := cache.New()
+	store.Load(models.Result{Aggregates: models.Aggregates{LoanSum: 4_000_000}})
+	h := NewHandlers(store)
+
+	req := httptest.NewRequest("GET", "/stats", nil)
+	req.Header.Set("Accept", "application/xml")
+	w := httptest.NewRecorder()
+	h.Stats(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "<loan_sum_histogram><bucket><from>0</from>") {
+		t.Errorf("Unexpected XML document %s", w.Body.String())
+	}
+	var stats models.Stats
+	if err := xml.Unmarshal(w.Body.Bytes(), &stats); err != nil || stats.Count != 1 || stats.LoanSumHistogram[2].Count != 1 {
+		t.Errorf("Expected 1 calculation in the third bucket, got %+v (%v)", stats, err)
+	}
+}
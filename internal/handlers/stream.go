@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sber/internal/metrics"
+	"sber/internal/middleware"
+	"sber/pkg/models"
+	"strconv"
+	"time"
+)
+
+// lastEventIDHeader is the header with which EventSource clients resume a stream after reconnecting.
+const lastEventIDHeader = "Last-Event-ID"
+
+// Stream handles the GET request for the stream of new cached calculations as server-sent events. Each
+// calculation is sent as a calculation event with its ID as the event ID, as soon as it is stored; repeated
+// calculations answered from the cache are not sent again. Authenticated clients only receive the calculations
+// they made themselves. A client that reconnects with the Last-Event-ID header first receives the calculations
+// stored after that ID. A client that does not keep up is disconnected, so that it never delays the calculations,
+// and catches up by reconnecting. Comments are sent on idle streams to keep the connection open.
+func (h *Handlers) Stream(w http.ResponseWriter, r *http.Request) {
+	// Check if the request method is GET
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "only get method allowed")
+		return
+	}
+
+	// Resume after the last event the client has received, if any
+	lastID := int32(-1)
+	resume := r.Header.Get(lastEventIDHeader)
+	if resume != "" {
+		id, err := strconv.ParseInt(resume, 10, 32)
+		if err != nil || id < 0 {
+			writeError(w, r, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+		lastID = int32(id)
+	}
+
+	// Streams outlive the write timeout of the server
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		middleware.Logger(r.Context()).Warn("failed to clear write deadline of stream", "error", err)
+	}
+
+	// Subscribe before reading the stored calculations, so that none are missed in between. Only the
+	// calculations of the client are queued, so that other clients cannot make the stream overflow.
+	cfg := h.cfg.Load().Stream
+	clientID := middleware.ClientIDFromContext(r.Context())
+	sub := h.store.Subscribe(clientID, cfg.Buffer)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &eventStream{w: bufio.NewWriter(w), rc: rc, clientID: clientID, lastID: lastID}
+	if resume != "" {
+		for _, entry := range h.store.ReadAfter(lastID) {
+			stream.send(entry)
+		}
+	}
+	if err := stream.flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case entry, ok := <-sub.C():
+			if !ok {
+				if sub.Overflowed() {
+					metrics.StreamOverflowsTotal.Inc()
+					middleware.Logger(r.Context()).Warn("disconnected slow stream client", "last_event_id", stream.lastID)
+				}
+				return
+			}
+			stream.send(entry)
+		case <-heartbeat.C:
+			fmt.Fprint(stream.w, ": keep-alive\n\n")
+		}
+		if err := stream.flush(); err != nil {
+			return
+		}
+	}
+}
+
+// CloseStreams ends the open event streams and makes new ones end immediately. The server calls it on
+// shutdown, since streams would otherwise keep their connections open until the shutdown times out.
+func (h *Handlers) CloseStreams() {
+	h.close.Do(func() { close(h.done) })
+}
+
+// eventStream writes calculations as server-sent events.
+type eventStream struct {
+	w        *bufio.Writer            // Buffered writer of the response
+	rc       *http.ResponseController // Controller used to flush the events to the client
+	clientID string                   // Client whose calculations are sent, all calculations if empty
+	lastID   int32                    // ID of the last calculation sent, so that none is sent twice
+}
+
+// send writes the calculation as an event, unless it has already been sent or belongs to another client, which
+// only the calculations read with ReadAfter may.
+func (s *eventStream) send(entry models.CacheStorageFormat) {
+	if entry.ID <= s.lastID || (s.clientID != "" && entry.ClientID != s.clientID) {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(s.w, "id: %d\nevent: calculation\ndata: %s\n\n", entry.ID, data)
+	s.lastID = entry.ID
+}
+
+// flush sends the buffered events to the client.
+func (s *eventStream) flush() error {
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
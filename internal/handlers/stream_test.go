@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/cache"
+	"sber/internal/config"
+	"sber/internal/middleware"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseEvent is an event read from a server-sent event stream.
+type sseEvent struct {
+	id, event, data string
+	comment         bool
+}
+
+// readEvent reads the next event or comment from the stream.
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return ev
+		case strings.HasPrefix(line, ":"):
+			ev.comment = true
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// TestStreamHandler verifies that a stream replays the calculations after Last-Event-ID, pushes new ones of
+// the client as they are stored, sends heartbeats and ends on CloseStreams.
+func TestStreamHandler(t *testing.T) {
+	store := cache.New()
+	cfg := config.Default()
+	cfg.Stream.HeartbeatInterval = 50 * time.Millisecond
+	cfg.Stream.Buffer = 1
+	h := NewHandlers(store, WithConfig(config.NewCurrent(cfg)))
+
+	// The client is identified by a header instead of an API key
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Stream(w, r.WithContext(middleware.WithClientID(r.Context(), r.Header.Get("X-Client"))))
+	}))
+	defer srv.Close()
+
+	load := func(clientID string, cost int32) {
+		store.LoadWithMeta(models.Result{Params: models.Params{ObjectCost: cost}}, models.RecordMeta{ClientID: clientID})
+	}
+	load("bank-a", 100000)
+	load("bank-b", 200000)
+	load("bank-a", 300000)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("X-Client", "bank-a")
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("stream request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	// The calculation of bank-a stored after entry 0 is replayed
+	ev := readEvent(t, reader)
+	var entry models.CacheStorageFormat
+	json.Unmarshal([]byte(ev.data), &entry)
+	if ev.id != "2" || ev.event != "calculation" || entry.Params.ObjectCost != 300000 {
+		t.Fatalf("Expected replayed calculation 2, got %+v", ev)
+	}
+
+	// New calculations of bank-a are pushed, the ones of other clients are not and never fill its queue
+	load("bank-b", 400000)
+	load("bank-b", 450000)
+	load("bank-a", 500000)
+	for {
+		ev = readEvent(t, reader)
+		if !ev.comment {
+			break
+		}
+	}
+	if ev.id != "5" {
+		t.Errorf("Expected calculation 5, got %+v", ev)
+	}
+
+	// Idle streams receive heartbeats
+	if ev = readEvent(t, reader); !ev.comment {
+		t.Errorf("Expected a heartbeat comment, got %+v", ev)
+	}
+
+	// The stream ends on shutdown
+	h.CloseStreams()
+	if _, err := io.ReadAll(reader); err != nil {
+		t.Errorf("Expected the stream to end, got %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for store.Subscribers() != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if store.Subscribers() != 0 {
+		t.Errorf("Expected the subscription to be closed, got %d subscribers", store.Subscribers())
+	}
+}
+
+func TestStreamHandlerInvalidLastEventID(t *testing.T) {
+	h := NewHandlers(cache.New())
+
+	req := httptest.NewRequest(http.MethodGet, "/cache/stream", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	w := httptest.NewRecorder()
+	h.Stream(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sber/internal/middleware"
+	"sber/internal/webhook"
+	"sber/pkg/models"
+)
+
+// WithWebhooks makes the handlers manage the endpoints of the given webhook dispatcher. Without it, the webhook
+// endpoints answer that webhooks are disabled.
+func WithWebhooks(d *webhook.Dispatcher) Option {
+	return func(h *Handlers) {
+		h.webhooks = d
+	}
+}
+
+// Webhooks handles the administrative requests for the webhook endpoints: GET lists the endpoints with the
+// number of their undelivered events, and POST registers a new endpoint from a models.WebhookRequest. The
+// response to POST contains the secret of the endpoint, which is not returned afterwards. The request body is read
+// in the format given by Content-Type, and the response is sent in the format selected by Accept.
+func (h *Handlers) Webhooks(w http.ResponseWriter, r *http.Request) {
+	// Check if the request method is GET or POST
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "only get and post methods allowed")
+		return
+	}
+
+	// Check that the response can be sent in a format the client accepts
+	if !acceptable(w, r) {
+		return
+	}
+
+	if h.webhooks == nil {
+		writeError(w, r, http.StatusNotFound, "webhooks are disabled")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		writeResponse(w, r, http.StatusOK, h.webhooks.Endpoints())
+		return
+	}
+
+	// Decode the endpoint in the format given by Content-Type
+	var req models.WebhookRequest
+	err := readRequest(w, r, &req)
+	if errors.Is(err, errUnsupportedMediaType) {
+		writeError(w, r, http.StatusUnsupportedMediaType, "unsupported content type, use application/json, application/xml or application/yaml")
+		return
+	}
+	if errors.Is(err, errRequestTooLarge) {
+		writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	registered, err := h.webhooks.Register(req)
+	if errors.Is(err, webhook.ErrInvalid) {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		middleware.Logger(r.Context()).Error("failed to register webhook", "error", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to register webhook")
+		return
+	}
+
+	middleware.Logger(r.Context()).Info("webhook registered", "webhook", registered.ID, "url", registered.URL)
+	writeResponse(w, r, http.StatusCreated, registered)
+}
+
+// Webhook handles the DELETE request removing a webhook endpoint registered through the API, along with its
+// undelivered events. The endpoints listed in the configuration file cannot be removed.
+func (h *Handlers) Webhook(w http.ResponseWriter, r *http.Request) {
+	// Check if the request method is DELETE
+	if r.Method != http.MethodDelete {
+		writeError(w, r, http.StatusMethodNotAllowed, "only delete method allowed")
+		return
+	}
+
+	if h.webhooks == nil {
+		writeError(w, r, http.StatusNotFound, "webhooks are disabled")
+		return
+	}
+
+	id := r.PathValue("id")
+	err := h.webhooks.Remove(id)
+	switch {
+	case errors.Is(err, webhook.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, webhook.ErrConfigured):
+		writeError(w, r, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		writeError(w, r, http.StatusInternalServerError, "failed to remove webhook")
+		return
+	}
+
+	middleware.Logger(r.Context()).Info("webhook removed", "webhook", id)
+	w.WriteHeader(http.StatusNoContent)
+}
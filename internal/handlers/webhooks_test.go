@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/cache"
+	"sber/internal/config"
+	"sber/internal/webhook"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+)
+
+func TestWebhooksHandler(t *testing.T) {
+	cfg := config.Default().Webhooks
+	cfg.Endpoints = []config.WebhookEndpoint{{URL: "https://crm.example.com/hooks", Secret: "secret"}}
+	d, err := webhook.New(cfg)
+	if err != nil {
+		t.Fatalf("webhook.New: %v", err)
+	}
+	h := NewHandlers(cache.New(), WithWebhooks(d))
+
+	// Register an endpoint, whose secret is only returned once
+	req := httptest.NewRequest("POST", "/admin/webhooks", strings.NewReader(`{"url": "https://crm.example.com/bank", "client_id": "bank"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.Webhooks(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body)
+	}
+	var registered models.Webhook
+	if err := json.NewDecoder(w.Body).Decode(&registered); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if registered.ID == "" || registered.Secret == "" || registered.ClientID != "bank" || registered.Source != models.WebhookSourceAPI {
+		t.Errorf("Unexpected registered webhook %+v", registered)
+	}
+
+	// List the configured and the registered endpoints without their secrets
+	w = httptest.NewRecorder()
+	h.Webhooks(w, httptest.NewRequest("GET", "/admin/webhooks", nil))
+	var webhooks []models.Webhook
+	if err := json.NewDecoder(w.Body).Decode(&webhooks); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(webhooks) != 2 || webhooks[0].Source != models.WebhookSourceConfig || webhooks[1].ID != registered.ID {
+		t.Fatalf("Unexpected webhooks %+v", webhooks)
+	}
+	for _, wh := range webhooks {
+		if wh.Secret != "" {
+			t.Errorf("Expected no secret in the list, got %+v", wh)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		id           string
+		expectedCode int
+	}{
+		{"Configured endpoint", "DELETE", webhooks[0].ID, http.StatusConflict},
+		{"Registered endpoint", "DELETE", registered.ID, http.StatusNoContent},
+		{"Removed endpoint", "DELETE", registered.ID, http.StatusNotFound},
+		{"Invalid method", "GET", registered.ID, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/admin/webhooks/"+tt.id, nil)
+			req.SetPathValue("id", tt.id)
+			w := httptest.NewRecorder()
+
+			h.Webhook(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestWebhooksHandlerInvalidURL(t *testing.T) {
+	d, err := webhook.New(config.Default().Webhooks)
+	if err != nil {
+		t.Fatalf("webhook.New: %v", err)
+	}
+	h := NewHandlers(cache.New(), WithWebhooks(d))
+
+	req := httptest.NewRequest("POST", "/admin/webhooks", strings.NewReader(`{"url": "crm.example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.Webhooks(w, req)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "absolute http or https URL") {
+		t.Errorf("Expected a 400 explaining the URL, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestWebhooksHandlerDisabled(t *testing.T) {
+	h := NewHandlers(cache.New())
+
+	w := httptest.NewRecorder()
+	h.Webhooks(w, httptest.NewRequest("GET", "/admin/webhooks", nil))
+
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "webhooks are disabled") {
+		t.Errorf("Expected 404 webhooks are disabled, got %d: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sber/internal/cache"
+	"time"
+)
+
+// windowDateLayout is the layout of dates accepted in the from and to query parameters besides RFC 3339.
+const windowDateLayout = "2006-01-02"
+
+// queryWindow parses the time window given by the from and to query parameters, each a time in RFC 3339 or
+// a date in the YYYY-MM-DD format, meaning midnight UTC. From is inclusive and to is exclusive, and either can
+// be omitted. It sends a 400 response and returns false if a parameter is invalid or the window is empty.
+func queryWindow(w http.ResponseWriter, r *http.Request) (cache.Window, bool) {
+	var window cache.Window
+	bounds := []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"from", &window.From},
+		{"to", &window.To},
+	}
+	for _, b := range bounds {
+		value := r.URL.Query().Get(b.name)
+		if value == "" {
+			continue
+		}
+		t, err := parseWindowTime(value)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid %s parameter, use RFC 3339 or YYYY-MM-DD", b.name))
+			return cache.Window{}, false
+		}
+		*b.dst = t
+	}
+
+	if !window.Valid() {
+		writeError(w, r, http.StatusBadRequest, "from should be before to")
+		return cache.Window{}, false
+	}
+	return window, true
+}
+
+// parseWindowTime parses a bound of the time window as a time in RFC 3339 or a date in UTC.
+func parseWindowTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(windowDateLayout, value)
+}
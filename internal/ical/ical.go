@@ -0,0 +1,167 @@
+// Package ical writes calendars in the iCalendar format (RFC 5545) that import into calendar applications.
+//
+// It supports what the service needs for payment reminders: all-day events with a summary, a description
+// and an optional display alarm. Text values are escaped and long lines are folded as the format requires.
+//
+// Types and Functions:
+//   - Calendar: A calendar with its events, written with WriteTo.
+//   - Event: An all-day event.
+//   - escapeText: Escapes text property values.
+//   - foldLine: Folds content lines longer than 75 octets.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Format of date and date-time values.
+const (
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405Z"
+)
+
+// maxLineLength is the maximum length of a content line in octets, excluding the line break.
+const maxLineLength = 75
+
+// ContentType is the media type of iCalendar files.
+const ContentType = "text/calendar; charset=utf-8"
+
+// Calendar is an iCalendar object with its events.
+type Calendar struct {
+	ProdID string    // Identifier of the product that created the calendar
+	Name   string    // Display name of the calendar, optional
+	Stamp  time.Time // Time the calendar was created, used as the timestamp of every event
+	Events []Event   // Events in order
+}
+
+// Event is an all-day event.
+type Event struct {
+	UID         string        // Globally unique and stable identifier, so that re-imports update the event
+	Date        time.Time     // Day of the event, only the date part is used
+	Summary     string        // Title of the event
+	Description string        // Details of the event, optional
+	Reminder    time.Duration // How long before the start of the day to show a reminder, zero for none
+}
+
+// WriteTo writes the calendar to w with CRLF line breaks. It implements io.WriterTo.
+func (c *Calendar) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	line := func(name, value string) {
+		cw.writeString(foldLine(name+":"+value) + "\r\n")
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", escapeText(c.ProdID))
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escapeText(c.Name))
+	}
+
+	stamp := c.Stamp.UTC().Format(dateTimeFormat)
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", escapeText(e.UID))
+		line("DTSTAMP", stamp)
+		line("DTSTART;VALUE=DATE", e.Date.Format(dateFormat))
+		line("DTEND;VALUE=DATE", e.Date.AddDate(0, 0, 1).Format(dateFormat))
+		line("SUMMARY", escapeText(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escapeText(e.Description))
+		}
+		line("TRANSP", "TRANSPARENT")
+		if e.Reminder > 0 {
+			line("BEGIN", "VALARM")
+			line("ACTION", "DISPLAY")
+			line("DESCRIPTION", escapeText(e.Summary))
+			line("TRIGGER", "-"+formatDuration(e.Reminder))
+			line("END", "VALARM")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// countingWriter counts the written bytes and keeps the first error, so that WriteTo can check it once.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+// writeString writes s unless a previous write failed.
+func (cw *countingWriter) writeString(s string) {
+	if cw.err != nil {
+		return
+	}
+	n, err := cw.w.WriteString(s)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+// escapeText escapes backslashes, semicolons, commas and line breaks in text property values.
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldLine folds a content line into lines of at most 75 octets, continued lines starting with a space.
+// Lines are only split between characters, so that multi-byte UTF-8 sequences stay intact.
+func foldLine(line string) string {
+	if len(line) <= maxLineLength {
+		return line
+	}
+
+	var b strings.Builder
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continued lines lose one octet to the leading space
+		limit = maxLineLength - 1
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+// formatDuration formats a positive duration as an iCalendar duration, e.g. P1D or PT2H30M.
+func formatDuration(d time.Duration) string {
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+
+	s := "P"
+	if days > 0 {
+		s += fmt.Sprintf("%dD", days)
+	}
+	if hours > 0 || minutes > 0 {
+		s += "T"
+		if hours > 0 {
+			s += fmt.Sprintf("%dH", hours)
+		}
+		if minutes > 0 {
+			s += fmt.Sprintf("%dM", minutes)
+		}
+	}
+	if s == "P" {
+		s = "PT0M"
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteTo(t *testing.T) {
+	cal := Calendar{
+		ProdID: "-//Test//EN",
+		Name:   "Payments",
+		Stamp:  time.Date(2026, 10, 18, 12, 30, 0, 0, time.FixedZone("MSK", 3*60*60)),
+		Events: []Event{
+			{
+				UID:         "payment-1@test",
+				Date:        time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+				Summary:     "Payment 1: 33458",
+				Description: "Principal 6791, interest 26667; balance 3993209",
+				Reminder:    24 * time.Hour,
+			},
+			{UID: "payment-2@test", Date: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), Summary: "Payment 2"},
+		},
+	}
+
+	var buf bytes.Buffer
+	n, err := cal.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("Expected %d bytes written, got %d", buf.Len(), n)
+	}
+	data := buf.String()
+
+	// Every line ends with CRLF
+	if strings.Count(data, "\n") != strings.Count(data, "\r\n") {
+		t.Error("Expected CRLF line breaks")
+	}
+
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//EN\r\n",
+		"X-WR-CALNAME:Payments\r\n",
+		"DTSTAMP:20261018T093000Z\r\n",
+		"DTSTART;VALUE=DATE:20241231\r\nDTEND;VALUE=DATE:20250101\r\n",
+		`DESCRIPTION:Principal 6791\, interest 26667\; balance 3993209` + "\r\n",
+		"BEGIN:VALARM\r\nACTION:DISPLAY\r\nDESCRIPTION:Payment 1: 33458\r\nTRIGGER:-P1D\r\nEND:VALARM\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(data, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, data)
+		}
+	}
+
+	// Events without a reminder have no alarm
+	if strings.Count(data, "BEGIN:VEVENT") != 2 || strings.Count(data, "BEGIN:VALARM") != 1 {
+		t.Errorf("Unexpected events:\n%s", data)
+	}
+}
+
+func TestFoldLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"Short line", "SUMMARY:Payment"},
+		{"Exactly 75 octets", "SUMMARY:" + strings.Repeat("a", 67)},
+		{"Long ASCII line", "DESCRIPTION:" + strings.Repeat("abcdefghij", 20)},
+		{"Long multi-byte line", "DESCRIPTION:" + strings.Repeat("Платеж ", 30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folded := foldLine(tt.line)
+			lines := strings.Split(folded, "\r\n")
+			for i, line := range lines {
+				if len(line) > maxLineLength {
+					t.Errorf("Line %d is %d octets long", i, len(line))
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("Continued line %d does not start with a space", i)
+				}
+			}
+
+			// Unfolding restores the original line
+			if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != tt.line {
+				t.Errorf("Expected %q after unfolding, got %q", tt.line, unfolded)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		24 * time.Hour:                "P1D",
+		3 * 24 * time.Hour:            "P3D",
+		2*time.Hour + 30*time.Minute:  "PT2H30M",
+		24*time.Hour + 15*time.Minute: "P1DT15M",
+		30 * time.Second:              "PT0M",
+	}
+	for d, expected := range tests {
+		if formatted := formatDuration(d); formatted != expected {
+			t.Errorf("Expected %v to be formatted as %s, got %s", d, expected, formatted)
+		}
+	}
+}
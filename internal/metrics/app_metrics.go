@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	errs "sber/pkg/errors"
+)
+
+// Application metrics collected by the mortgage calculation service.
+var (
+	// HTTPRequestsTotal counts handled HTTP requests by route pattern and response status code.
+	HTTPRequestsTotal = NewCounterVec("mortgage_http_requests_total",
+		"Total number of HTTP requests by route and status code.", "route", "code")
+
+	// HTTPRequestDuration observes HTTP request latencies by route pattern and response status code.
+	HTTPRequestDuration = NewHistogramVec("mortgage_http_request_duration_seconds",
+		"HTTP request latencies in seconds by route and status code.", DefaultBuckets, "route", "code")
+
+	// CalculationsTotal counts successful mortgage calculations by loan program.
+	CalculationsTotal = NewCounterVec("mortgage_calculations_total",
+		"Total number of successful mortgage calculations by program.", "program")
+
+	// CacheLookupsTotal counts stored calculations by whether they were answered from the cache ("hit")
+	// or stored as a new entry ("miss").
+	CacheLookupsTotal = NewCounterVec("mortgage_cache_lookups_total",
+		"Total number of stored calculations by cache result.", "result")
+
+	// StreamOverflowsTotal counts event stream clients disconnected because they did not keep up.
+	StreamOverflowsTotal = NewCounterVec("mortgage_cache_stream_overflows_total",
+		"Total number of /cache/stream clients disconnected because they did not keep up.")
+
+	// WebhookDeliveriesTotal counts webhook delivery attempts by result: "delivered", "failed" (to be retried)
+	// and "dropped" (given up after the last attempt or on a full queue).
+	WebhookDeliveriesTotal = NewCounterVec("mortgage_webhook_deliveries_total",
+		"Total number of webhook deliveries by result.", "result")
+
+	// ValidationFailuresTotal counts rejected calculation requests by validation error type.
+	ValidationFailuresTotal = NewCounterVec("mortgage_validation_failures_total",
+		"Total number of rejected calculation requests by validation error type.", "error")
+
+	// IdempotentReplaysTotal counts responses replayed for repeated idempotency keys.
+	IdempotentReplaysTotal = NewCounterVec("mortgage_idempotent_replays_total",
+		"Total number of responses replayed for repeated idempotency keys.")
+
+	// IdempotencyEvictionsTotal counts idempotency keys dropped before their expiry to make room for new ones.
+	IdempotencyEvictionsTotal = NewCounterVec("mortgage_idempotency_evictions_total",
+		"Total number of idempotency keys dropped before their expiry to make room for new ones.")
+
+	// RateLimitedTotal counts requests rejected by the rate limiter.
+	RateLimitedTotal = NewCounterVec("mortgage_rate_limited_requests_total",
+		"Total number of requests rejected by the rate limiter.")
+
+	// GRPCRequestsTotal counts handled gRPC requests by method and status code.
+	GRPCRequestsTotal = NewCounterVec("mortgage_grpc_requests_total",
+		"Total number of gRPC requests by method and status code.", "method", "code")
+
+	// GRPCRequestDuration observes gRPC request latencies by method and status code.
+	GRPCRequestDuration = NewHistogramVec("mortgage_grpc_request_duration_seconds",
+		"gRPC request latencies in seconds by method and status code.", DefaultBuckets, "method", "code")
+
+	// PanicsTotal counts panics recovered in HTTP handlers.
+	PanicsTotal = NewCounterVec("mortgage_http_panics_total",
+		"Total number of panics recovered in HTTP handlers.")
+)
+
+// Default is the registry exposed on the /metrics endpoint.
+var Default = NewRegistry(HTTPRequestsTotal, HTTPRequestDuration, GRPCRequestsTotal, GRPCRequestDuration,
+	CalculationsTotal, CacheLookupsTotal, StreamOverflowsTotal, WebhookDeliveriesTotal, ValidationFailuresTotal,
+	IdempotentReplaysTotal, IdempotencyEvictionsTotal, RateLimitedTotal, PanicsTotal)
+
+// Handler returns an http.Handler serving the Default registry.
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// ValidationFailureLabel maps a calculation validation error to the label used in ValidationFailuresTotal,
+// so that the HTTP and gRPC APIs count the same failures under the same labels.
+func ValidationFailureLabel(err error) string {
+	switch {
+	case errors.Is(err, errs.ErrInitalPaymentIsTooSmall):
+		return "initial_payment_too_small"
+	case errors.Is(err, errs.ErrNoTrueValues):
+		return "no_program"
+	case errors.Is(err, errs.ErrMoreThanOneTrue):
+		return "multiple_programs"
+	case errors.Is(err, errs.ErrInvalidTerm):
+		return "invalid_term"
+	case errors.Is(err, errs.ErrLoanTooLarge):
+		return "loan_too_large"
+	case errors.Is(err, errs.ErrInvalidRate):
+		return "invalid_rate"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	errs "sber/pkg/errors"
+	"strings"
+	"testing"
+)
+
+// TestCounterVec verifies that counters are partitioned by label values and rendered correctly.
+func TestCounterVec(t *testing.T) {
+	c := NewCounterVec("test_total", "Test counter.", "program")
+	c.Inc("base")
+	c.Inc("base")
+	c.Add(3, "salary")
+	c.Add(-1, "salary") // Negative values must be ignored
+
+	if got := c.Value("base"); got != 2 {
+		t.Errorf("Expected base counter 2, got %v", got)
+	}
+	if got := c.Value("salary"); got != 3 {
+		t.Errorf("Expected salary counter 3, got %v", got)
+	}
+
+	var buf bytes.Buffer
+	if _, err := NewRegistry(c).WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{program="base"} 2
+test_total{program="salary"} 3
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected exposition:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+// TestHistogramVec verifies that histogram buckets are cumulative and include the +Inf bucket.
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("test_seconds", "Test histogram.", []float64{0.1, 1}, "route")
+	h.Observe(0.05, "/execute")
+	h.Observe(0.5, "/execute")
+	h.Observe(5, "/execute")
+
+	if got := h.Count("/execute"); got != 3 {
+		t.Errorf("Expected 3 observations, got %d", got)
+	}
+
+	var buf bytes.Buffer
+	if _, err := NewRegistry(h).WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, line := range []string{
+		`test_seconds_bucket{route="/execute",le="0.1"} 1`,
+		`test_seconds_bucket{route="/execute",le="1"} 2`,
+		`test_seconds_bucket{route="/execute",le="+Inf"} 3`,
+		`test_seconds_sum{route="/execute"} 5.55`,
+		`test_seconds_count{route="/execute"} 3`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("Expected line %q in exposition:\n%s", line, buf.String())
+		}
+	}
+}
+
+// TestGaugeFuncAndEscaping verifies gauge callbacks and label value escaping.
+func TestGaugeFuncAndEscaping(t *testing.T) {
+	g := NewGaugeFunc("test_entries", "Test gauge.", func() float64 { return 42 })
+	c := NewCounterVec("test_errors_total", "Test escaping.", "error")
+	c.Inc("bad \"value\"\n")
+
+	var buf bytes.Buffer
+	if _, err := NewRegistry(g, c).WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "test_entries 42\n") {
+		t.Errorf("Expected gauge value in exposition:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `test_errors_total{error="bad \"value\"\n"} 1`) {
+		t.Errorf("Expected escaped label value in exposition:\n%s", buf.String())
+	}
+}
+
+// TestMustRegisterDuplicate verifies that registering the same metric name twice panics.
+func TestMustRegisterDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on duplicate registration")
+		}
+	}()
+	NewRegistry(NewCounterVec("dup_total", "Duplicate."), NewCounterVec("dup_total", "Duplicate."))
+}
+
+// TestHandler verifies that the registry handler responds with the text exposition content type.
+func TestHandler(t *testing.T) {
+	r := NewRegistry(NewGaugeFunc("test_up", "Test gauge.", func() float64 { return 1 }))
+	w := httptest.NewRecorder()
+
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	if !strings.Contains(w.Body.String(), "test_up 1\n") {
+		t.Errorf("Unexpected body:\n%s", w.Body.String())
+	}
+}
+
+// TestValidationFailureLabel verifies that validation errors are mapped to bounded metric labels.
+func TestValidationFailureLabel(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{errs.ErrInitalPaymentIsTooSmall, "initial_payment_too_small"},
+		{errs.ErrNoTrueValues, "no_program"},
+		{errs.ErrMoreThanOneTrue, "multiple_programs"},
+		{errs.ErrInvalidTerm, "invalid_term"},
+		{errs.ErrLoanTooLarge, "loan_too_large"},
+		{errs.ErrInvalidRate, "invalid_rate"},
+		{fmt.Errorf("wrapped: %w", errs.ErrNoTrueValues), "no_program"},
+		{errors.New("unexpected"), "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := ValidationFailureLabel(tt.err); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
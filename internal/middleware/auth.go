@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sber/internal/config"
+	errs "sber/pkg/errors"
+	"sber/pkg/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeyHeader is the header used by clients to send their API key.
+// The key can also be sent as a bearer token in the Authorization header.
+const APIKeyHeader = "X-API-Key"
+
+// clientIDKey is the context key under which the authenticated client ID is stored.
+type clientIDKey struct{}
+
+// apiKeyKey is the context key under which the authenticated key is stored until its quota is counted.
+type apiKeyKey struct{}
+
+// Authenticator validates API keys against their stored SHA-256 hashes and enforces per-client daily quotas.
+type Authenticator struct {
+	clients map[string]config.APIKey // Keys indexed by their hex-encoded SHA-256 hash
+	mu      sync.Mutex               // Protects usage
+	usage   map[string]*dailyUsage   // Request counters indexed by client ID
+	now     func() time.Time         // Clock used for quota windows, replaceable in tests
+}
+
+// dailyUsage holds the number of requests made by a client during a single UTC day.
+type dailyUsage struct {
+	day   string // The UTC day the counter belongs to, formatted as 2006-01-02
+	count int    // Number of requests made during the day
+}
+
+// NewAuthenticator creates an Authenticator from the configured keys. It returns an error if a key hash is
+// not a valid hex-encoded SHA-256 digest, or if two keys share a hash or a client ID is missing.
+func NewAuthenticator(keys []config.APIKey) (*Authenticator, error) {
+	a := &Authenticator{
+		clients: make(map[string]config.APIKey, len(keys)),
+		usage:   map[string]*dailyUsage{},
+		now:     time.Now,
+	}
+
+	for _, key := range keys {
+		hash := strings.ToLower(strings.TrimSpace(key.KeyHash))
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("invalid key hash for client %q: expected hex-encoded SHA-256", key.ClientID)
+		}
+		if key.ClientID == "" {
+			return nil, fmt.Errorf("missing client id for key hash %s", hash)
+		}
+		if _, ok := a.clients[hash]; ok {
+			return nil, fmt.Errorf("duplicate key hash for client %q", key.ClientID)
+		}
+		a.clients[hash] = key
+	}
+
+	return a, nil
+}
+
+// Middleware is a middleware function that rejects requests without a valid API key with 401, rejects requests
+// over the client's daily quota with 429 and attaches the client ID to the request context. It combines
+// Authenticate and Quota, which are used apart to run other checks, such as rate limiting, in between.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return a.Authenticate(a.Quota(next))
+}
+
+// Authenticate is a middleware function that rejects requests without a valid API key with 401 and attaches the
+// client ID to the request context. The quota is not counted until Quota.
+func (a *Authenticator) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Look up the client by the hash of the presented key
+		key, ok := a.authenticate(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mortgage"`)
+			writeErrorMessage(w, r, http.StatusUnauthorized, errs.ErrInvalidAPIKey.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(withAPIKey(r.Context(), key)))
+	})
+}
+
+// Quota is a middleware function that counts the requests authenticated by Authenticate against the client's
+// daily quota and rejects the ones over it with 429. Requests that were not authenticated pass.
+func (a *Authenticator) Quota(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := a.Charge(r.Context()); err != nil {
+			writeErrorMessage(w, r, http.StatusTooManyRequests, err.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Authorize checks an API key presented over another transport, such as gRPC metadata, like Middleware does:
+// it returns the client ID of the key, errs.ErrInvalidAPIKey for an unknown key, or errs.ErrQuotaExceeded when
+// the request is over the client's daily quota.
+func (a *Authenticator) Authorize(presented string) (string, error) {
+	ctx, err := a.Identify(context.Background(), presented)
+	if err != nil {
+		return "", err
+	}
+	if err = a.Charge(ctx); err != nil {
+		return "", err
+	}
+	return ClientIDFromContext(ctx), nil
+}
+
+// Identify checks an API key presented over another transport like Authenticate does: it returns a copy of ctx
+// carrying the client ID of the key, or errs.ErrInvalidAPIKey for an unknown key.
+func (a *Authenticator) Identify(ctx context.Context, presented string) (context.Context, error) {
+	key, ok := a.lookup(presented)
+	if !ok {
+		return ctx, errs.ErrInvalidAPIKey
+	}
+	return withAPIKey(ctx, key), nil
+}
+
+// Charge counts the request authenticated in ctx by Authenticate or Identify against the client's daily quota.
+// It returns errs.ErrQuotaExceeded when the request is over the quota, and nil for unauthenticated requests.
+func (a *Authenticator) Charge(ctx context.Context) error {
+	key, ok := ctx.Value(apiKeyKey{}).(config.APIKey)
+	if ok && !a.allow(key) {
+		return errs.ErrQuotaExceeded
+	}
+	return nil
+}
+
+// authenticate returns the key matching the API key presented in the request.
+func (a *Authenticator) authenticate(r *http.Request) (config.APIKey, bool) {
+	presented := r.Header.Get(APIKeyHeader)
+	if presented == "" {
+		presented = BearerToken(r.Header.Get("Authorization"))
+	}
+	return a.lookup(presented)
+}
+
+// BearerToken returns the token of an Authorization header value using the Bearer scheme, or an empty string.
+func BearerToken(authorization string) string {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// lookup returns the key matching the presented API key by its hash.
+func (a *Authenticator) lookup(presented string) (config.APIKey, bool) {
+	if presented == "" {
+		return config.APIKey{}, false
+	}
+
+	sum := sha256.Sum256([]byte(presented))
+	key, ok := a.clients[hex.EncodeToString(sum[:])]
+	return key, ok
+}
+
+// allow increments the client's request counter for the current UTC day and reports whether it is within quota.
+func (a *Authenticator) allow(key config.APIKey) bool {
+	day := a.now().UTC().Format(time.DateOnly)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Start a new window when the day changes
+	u, ok := a.usage[key.ClientID]
+	if !ok || u.day != day {
+		u = &dailyUsage{day: day}
+		a.usage[key.ClientID] = u
+	}
+
+	if key.DailyQuota > 0 && u.count >= key.DailyQuota {
+		return false
+	}
+	u.count++
+	return true
+}
+
+// withAPIKey returns a copy of ctx carrying the authenticated key and its client ID.
+func withAPIKey(ctx context.Context, key config.APIKey) context.Context {
+	return context.WithValue(WithClientID(ctx, key.ClientID), apiKeyKey{}, key)
+}
+
+// WithClientID returns a copy of ctx carrying the authenticated client ID.
+func WithClientID(ctx context.Context, clientID string) context.Context {
+	return context.WithValue(ctx, clientIDKey{}, clientID)
+}
+
+// ClientIDFromContext returns the authenticated client ID stored in ctx, or an empty string if there is none.
+func ClientIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(clientIDKey{}).(string)
+	return id
+}
+
+// writeErrorMessage sends a JSON ErrorMessage with the given status code from a middleware.
+func writeErrorMessage(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(models.ErrorMessage{Error: message})
+	if err != nil {
+		Logger(r.Context()).Error("failed to send error message in middleware", "status", status, "error", err)
+		return
+	}
+}
@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/config"
+	errs "sber/pkg/errors"
+	"testing"
+	"time"
+)
+
+// hashKey returns the hex-encoded SHA-256 hash of an API key, as stored in the configuration.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// TestNewAuthenticatorValidation verifies that invalid key configurations are rejected.
+func TestNewAuthenticatorValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		keys      []config.APIKey
+		expectErr bool
+	}{
+		{"Valid key", []config.APIKey{{ClientID: "bank", KeyHash: hashKey("secret")}}, false},
+		{"Plain text key", []config.APIKey{{ClientID: "bank", KeyHash: "secret"}}, true},
+		{"Missing client", []config.APIKey{{KeyHash: hashKey("secret")}}, true},
+		{"Duplicate hash", []config.APIKey{
+			{ClientID: "bank-a", KeyHash: hashKey("secret")},
+			{ClientID: "bank-b", KeyHash: hashKey("secret")},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAuthenticator(tt.keys)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("Expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+// TestAuthenticatorMiddleware verifies key validation and propagation of the client ID.
+func TestAuthenticatorMiddleware(t *testing.T) {
+	auth, err := NewAuthenticator([]config.APIKey{{ClientID: "partner-bank", KeyHash: hashKey("secret")}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var clientID string
+	handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID = ClientIDFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name         string
+		header       string
+		value        string
+		expectedCode int
+	}{
+		{"API key header", APIKeyHeader, "secret", http.StatusOK},
+		{"Bearer token", "Authorization", "Bearer secret", http.StatusOK},
+		{"Wrong key", APIKeyHeader, "wrong", http.StatusUnauthorized},
+		{"Missing key", "", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientID = ""
+			req := httptest.NewRequest(http.MethodGet, "/cache", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, recorder.Code)
+			}
+			if tt.expectedCode == http.StatusOK && clientID != "partner-bank" {
+				t.Errorf("Expected client ID partner-bank, got %q", clientID)
+			}
+		})
+	}
+}
+
+// TestAuthenticatorDailyQuota verifies that the quota is enforced per client and reset on a new UTC day.
+func TestAuthenticatorDailyQuota(t *testing.T) {
+	auth, err := NewAuthenticator([]config.APIKey{
+		{ClientID: "limited", KeyHash: hashKey("limited-key"), DailyQuota: 2},
+		{ClientID: "unlimited", KeyHash: hashKey("unlimited-key")},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	auth.now = func() time.Time { return now }
+
+	handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	do := func(key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/execute", nil)
+		req.Header.Set(APIKeyHeader, key)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	// The limited client may make two requests per day
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if code := do("limited-key"); code != expected {
+			t.Errorf("Request %d: expected status code %d, got %d", i+1, expected, code)
+		}
+	}
+
+	// Other clients are not affected
+	for range 5 {
+		if code := do("unlimited-key"); code != http.StatusOK {
+			t.Errorf("Expected unlimited client to pass, got %d", code)
+		}
+	}
+
+	// The quota is reset on the next UTC day
+	now = now.Add(24 * time.Hour)
+	if code := do("limited-key"); code != http.StatusOK {
+		t.Errorf("Expected quota reset on the next day, got %d", code)
+	}
+}
+
+// TestAuthenticatorQuotaAfterRateLimit verifies that with the rate limiter between Authenticate and Quota, the
+// requests rejected for their rate do not use up the daily quota.
+func TestAuthenticatorQuotaAfterRateLimit(t *testing.T) {
+	auth, err := NewAuthenticator([]config.APIKey{{ClientID: "limited", KeyHash: hashKey("limited-key"), DailyQuota: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	limiter := NewRateLimiter(config.RateLimit{RequestsPerSecond: 1, Burst: 1, IdleTimeout: time.Minute})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	handler := auth.Authenticate(limiter.Middleware(auth.Quota(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))))
+	do := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/execute", nil)
+		req.Header.Set(APIKeyHeader, "limited-key")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	// Retries within the same second are rate limited
+	tests := []struct {
+		name       string
+		advance    time.Duration
+		code       int
+		retryAfter bool
+	}{
+		{"First request", 0, http.StatusOK, false},
+		{"Rate limited retry", 0, http.StatusTooManyRequests, true},
+		{"Another rate limited retry", 0, http.StatusTooManyRequests, true},
+		{"Second request within quota", time.Second, http.StatusOK, false},
+		{"Over quota", time.Second, http.StatusTooManyRequests, false},
+	}
+	for _, tt := range tests {
+		now = now.Add(tt.advance)
+		recorder := do()
+		if recorder.Code != tt.code {
+			t.Errorf("%s: expected status code %d, got %d", tt.name, tt.code, recorder.Code)
+		}
+		if retryAfter := recorder.Header().Get("Retry-After") != ""; retryAfter != tt.retryAfter {
+			t.Errorf("%s: expected Retry-After %v, got %v", tt.name, tt.retryAfter, retryAfter)
+		}
+	}
+}
+
+// TestAuthenticatorAuthorize verifies the key check used by other transports.
+func TestAuthenticatorAuthorize(t *testing.T) {
+	auth, err := NewAuthenticator([]config.APIKey{{ClientID: "partner-bank", KeyHash: hashKey("secret"), DailyQuota: 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := auth.Authorize(""); !errors.Is(err, errs.ErrInvalidAPIKey) {
+		t.Errorf("Expected ErrInvalidAPIKey for missing key, got %v", err)
+	}
+	if _, err := auth.Authorize("wrong"); !errors.Is(err, errs.ErrInvalidAPIKey) {
+		t.Errorf("Expected ErrInvalidAPIKey for unknown key, got %v", err)
+	}
+	if clientID, err := auth.Authorize("secret"); err != nil || clientID != "partner-bank" {
+		t.Errorf("Expected partner-bank, got %q, %v", clientID, err)
+	}
+	if _, err := auth.Authorize("secret"); !errors.Is(err, errs.ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded over quota, got %v", err)
+	}
+}
+
+// TestBearerToken verifies parsing of Authorization header values.
+func TestBearerToken(t *testing.T) {
+	tests := map[string]string{
+		"Bearer secret":   "secret",
+		"Bearer  secret ": "secret",
+		"Basic c2VjcmV0":  "",
+		"":                "",
+	}
+	for header, expected := range tests {
+		if got := BearerToken(header); got != expected {
+			t.Errorf("BearerToken(%q): expected %q, got %q", header, expected, got)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net/http"
+	"sber/internal/config"
+	"strconv"
+	"strings"
+)
+
+// exposedHeaders lists the response headers set for the clients beyond the safelisted ones, which browsers
+// only let scripts read when they are exposed: the request ID, the rate limit and idempotency headers, the cache
+// status of /execute, the file names of the exports and the authentication challenge.
+var exposedHeaders = strings.Join([]string{
+	RequestIDHeader,
+	"Retry-After",
+	IdempotentReplayedHeader,
+	"X-Cache",
+	"Content-Disposition",
+	"WWW-Authenticate",
+}, ", ")
+
+// CORS answers preflight requests and adds the cross-origin headers to the responses for allowed origins.
+type CORS struct {
+	allowAnyOrigin bool                // Whether any origin is allowed
+	origins        map[string]struct{} // Allowed origins, compared case-insensitively
+	methods        map[string]struct{} // Allowed methods
+	allowMethods   string              // Value of the Access-Control-Allow-Methods header
+	allowHeaders   string              // Value of the Access-Control-Allow-Headers header
+	maxAge         string              // Value of the Access-Control-Max-Age header, empty if not set
+}
+
+// NewCORS creates a CORS middleware from the configuration.
+func NewCORS(cfg config.CORS) *CORS {
+	c := &CORS{
+		origins: map[string]struct{}{},
+		methods: map[string]struct{}{},
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			c.allowAnyOrigin = true
+			continue
+		}
+		c.origins[strings.ToLower(origin)] = struct{}{}
+	}
+
+	methods := make([]string, 0, len(cfg.AllowedMethods))
+	for _, method := range cfg.AllowedMethods {
+		method = strings.ToUpper(method)
+		c.methods[method] = struct{}{}
+		methods = append(methods, method)
+	}
+	c.allowMethods = strings.Join(methods, ", ")
+	c.allowHeaders = strings.Join(cfg.AllowedHeaders, ", ")
+
+	if cfg.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	return c
+}
+
+// Middleware is a middleware function that answers OPTIONS preflight requests with 204 without passing them
+// to the handlers, and adds the Access-Control-Allow-Origin and Access-Control-Expose-Headers headers to the
+// responses for allowed origins.
+// Requests from origins that are not allowed are served without CORS headers, so browsers block them.
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+
+		// Preflight requests never reach the handlers, which only accept their own methods
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			c.preflight(w, r, origin)
+			return
+		}
+
+		// Responses differ by origin, so caches must take it into account
+		w.Header().Add("Vary", "Origin")
+		if origin != "" && c.originAllowed(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", c.allowOriginValue(origin))
+			w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// preflight answers an OPTIONS preflight request.
+func (c *CORS) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	headers := w.Header()
+	headers.Add("Vary", "Origin")
+	headers.Add("Vary", "Access-Control-Request-Method")
+	headers.Add("Vary", "Access-Control-Request-Headers")
+
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	if origin == "" || !c.originAllowed(origin) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if _, ok := c.methods[method]; !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	headers.Set("Access-Control-Allow-Origin", c.allowOriginValue(origin))
+	headers.Set("Access-Control-Allow-Methods", c.allowMethods)
+	if c.allowHeaders != "" {
+		headers.Set("Access-Control-Allow-Headers", c.allowHeaders)
+	}
+	if c.maxAge != "" {
+		headers.Set("Access-Control-Max-Age", c.maxAge)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// originAllowed reports whether the origin may call the service.
+func (c *CORS) originAllowed(origin string) bool {
+	if c.allowAnyOrigin {
+		return true
+	}
+	_, ok := c.origins[strings.ToLower(origin)]
+	return ok
+}
+
+// allowOriginValue returns the value of the Access-Control-Allow-Origin header for an allowed origin.
+func (c *CORS) allowOriginValue(origin string) string {
+	if c.allowAnyOrigin {
+		return "*"
+	}
+	return origin
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestCORS creates a CORS middleware wrapping a handler that rejects every method except POST,
+// like the Execute handler does.
+func newTestCORS(origins ...string) http.Handler {
+	cors := NewCORS(config.CORS{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{"get", "post"},
+		AllowedHeaders: []string{"Content-Type", "X-API-Key"},
+		MaxAge:         10 * time.Minute,
+	})
+	return cors.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+// TestCORSPreflight verifies that preflight requests are answered without reaching the handler.
+func TestCORSPreflight(t *testing.T) {
+	handler := newTestCORS("https://bank.example")
+
+	tests := []struct {
+		name          string
+		origin        string
+		method        string
+		expectAllowed bool
+	}{
+		{"Allowed origin", "https://bank.example", "POST", true},
+		{"Allowed origin with different case", "https://BANK.example", "POST", true},
+		{"Disallowed origin", "https://evil.example", "POST", false},
+		{"Disallowed method", "https://bank.example", "DELETE", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/execute", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, req)
+
+			// The preflight is answered by the middleware instead of hitting the method check
+			if recorder.Code != http.StatusNoContent {
+				t.Errorf("Expected status code %d, got %d", http.StatusNoContent, recorder.Code)
+			}
+
+			allowOrigin := recorder.Header().Get("Access-Control-Allow-Origin")
+			if tt.expectAllowed {
+				if allowOrigin != tt.origin {
+					t.Errorf("Expected allowed origin %q, got %q", tt.origin, allowOrigin)
+				}
+				if methods := recorder.Header().Get("Access-Control-Allow-Methods"); methods != "GET, POST" {
+					t.Errorf("Expected allowed methods 'GET, POST', got %q", methods)
+				}
+				if headers := recorder.Header().Get("Access-Control-Allow-Headers"); headers != "Content-Type, X-API-Key" {
+					t.Errorf("Expected allowed headers, got %q", headers)
+				}
+				if maxAge := recorder.Header().Get("Access-Control-Max-Age"); maxAge != "600" {
+					t.Errorf("Expected max age 600, got %q", maxAge)
+				}
+			} else if allowOrigin != "" {
+				t.Errorf("Expected no allowed origin, got %q", allowOrigin)
+			}
+		})
+	}
+}
+
+// TestCORSActualRequest verifies that regular cross-origin requests get the CORS headers for allowed origins.
+func TestCORSActualRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		origins  []string
+		origin   string
+		expected string
+	}{
+		{"Allowed origin", []string{"https://bank.example"}, "https://bank.example", "https://bank.example"},
+		{"Any origin", []string{"*"}, "https://widget.example", "*"},
+		{"Disallowed origin", []string{"https://bank.example"}, "https://evil.example", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/execute", nil)
+			req.Header.Set("Origin", tt.origin)
+			recorder := httptest.NewRecorder()
+
+			newTestCORS(tt.origins...).ServeHTTP(recorder, req)
+
+			if recorder.Code != http.StatusOK {
+				t.Errorf("Expected status code %d, got %d", http.StatusOK, recorder.Code)
+			}
+			if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != tt.expected {
+				t.Errorf("Expected allowed origin %q, got %q", tt.expected, got)
+			}
+
+			// The headers set for the clients are exposed to the scripts of allowed origins only
+			exposed := recorder.Header().Get("Access-Control-Expose-Headers")
+			if tt.expected == "" {
+				if exposed != "" {
+					t.Errorf("Expected no exposed headers, got %q", exposed)
+				}
+				return
+			}
+			for _, header := range []string{"X-Request-ID", "Retry-After", "Idempotent-Replayed", "X-Cache", "Content-Disposition"} {
+				if !strings.Contains(exposed, header) {
+					t.Errorf("Expected %s in exposed headers %q", header, exposed)
+				}
+			}
+		})
+	}
+}
+
+// TestCORSPlainOptions verifies that OPTIONS requests that are not preflights still reach the handler.
+func TestCORSPlainOptions(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newTestCORS("*").ServeHTTP(recorder, httptest.NewRequest(http.MethodOptions, "/execute", nil))
+
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status code %d, got %d", http.StatusMethodNotAllowed, recorder.Code)
+	}
+}
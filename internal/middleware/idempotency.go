@@ -0,0 +1,326 @@
+package middleware
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"sber/internal/config"
+	"sber/internal/metrics"
+	"slices"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader is the header used by clients to make retries of a request safe.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set to true on responses replayed for a repeated idempotency key.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+const (
+	// maxIdempotencyKeyLength limits the length of idempotency keys to keep the stored keys bounded.
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize limits the size of request bodies buffered to compare repeated requests.
+	maxIdempotentBodySize = 1 << 20
+	// idempotencySweepInterval is how often expired keys are dropped at most.
+	idempotencySweepInterval = time.Minute
+)
+
+// Idempotency makes requests with an Idempotency-Key header safe to retry: the response to the first request
+// with a key is stored and replayed for later requests with the same key and an identical body, instead of
+// running the handler again. Keys are scoped to the authenticated client and expire after the configured TTL.
+// Without authentication all the clients share one scope, so a client knowing the key of another one gets its
+// response. At most the configured number of keys is kept: the oldest completed keys are dropped for new ones.
+type Idempotency struct {
+	ttl       time.Duration                // Time after which a key expires
+	maxKeys   int                          // Maximum number of stored keys, unlimited if not positive
+	mu        sync.Mutex                   // Protects entries, order and lastSweep
+	entries   map[string]*idempotentResult // Results indexed by client ID and key
+	order     *list.List                   // Results from the oldest to the newest, for eviction
+	lastSweep time.Time                    // Time of the last removal of expired keys
+	now       func() time.Time             // Clock used for expiry, replaceable in tests
+}
+
+// idempotentResult is the stored result of the request made with an idempotency key.
+type idempotentResult struct {
+	scope       string            // Client ID and key of the result
+	element     *list.Element     // Element of the result in the eviction order
+	fingerprint [sha256.Size]byte // SHA-256 hash of the request body
+	expires     time.Time         // Time after which the key can be reused
+	done        bool              // Whether the response has been recorded, false while the request is in flight
+	status      int               // Status code of the response
+	header      http.Header       // Headers of the response
+	body        []byte            // Body of the response
+}
+
+// idempotencyState is the outcome of looking up an idempotency key.
+type idempotencyState int
+
+const (
+	idempotencyNew        idempotencyState = iota // The key is new, the request is handled
+	idempotencyReplay                             // The key has a stored response for the same body
+	idempotencyConflict                           // The key was used with a different body
+	idempotencyInProgress                         // The request with the key is still in flight
+	idempotencyFull                               // The store is full of requests in flight
+)
+
+// NewIdempotency creates the idempotency key store from the configuration.
+func NewIdempotency(cfg config.Idempotency) *Idempotency {
+	return &Idempotency{
+		ttl:     cfg.TTL,
+		maxKeys: cfg.MaxKeys,
+		entries: map[string]*idempotentResult{},
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+// Middleware is a middleware function that handles the Idempotency-Key header. Requests without the header
+// pass through. The first request with a key is handled and its response stored, unless it is a server error,
+// so that such requests can be retried. Repeated requests with an identical body get the stored response with
+// the Idempotent-Replayed header; a different body, or a repeat while the first request is still in flight,
+// is rejected with 409. Invalid keys are rejected with 400, bodies over 1 MiB with 413, and new keys with 503
+// when the store is full of requests in flight.
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !validIdempotencyKey(key) {
+			writeErrorMessage(w, r, http.StatusBadRequest, "invalid idempotency key")
+			return
+		}
+
+		// Buffer the body to compare it with the first request, and hand it on to the handler
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				writeErrorMessage(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+			writeErrorMessage(w, r, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are scoped to the client, so that clients cannot see each other's responses; anonymous clients
+		// share the empty client ID
+		scope := ClientIDFromContext(r.Context()) + "\x00" + key
+		result, state := i.begin(scope, sha256.Sum256(body))
+		switch state {
+		case idempotencyReplay:
+			metrics.IdempotentReplaysTotal.Inc()
+			replay(w, result)
+			return
+		case idempotencyConflict:
+			writeErrorMessage(w, r, http.StatusConflict, "idempotency key was used with a different request body")
+			return
+		case idempotencyInProgress:
+			writeErrorMessage(w, r, http.StatusConflict, "a request with this idempotency key is in progress")
+			return
+		case idempotencyFull:
+			writeErrorMessage(w, r, http.StatusServiceUnavailable, "too many idempotency keys in progress")
+			return
+		}
+
+		// Record the response, releasing the key if the handler panics
+		recorder := &recordingWriter{ResponseWriter: w, status: http.StatusOK, outer: w.Header().Clone()}
+		completed := false
+		defer func() {
+			if !completed {
+				i.release(scope)
+			}
+		}()
+		next.ServeHTTP(recorder, r)
+		completed = true
+		i.finish(scope, recorder)
+	})
+}
+
+// begin looks up the key. A new key is reserved for the request until finish or release is called. If the store
+// is full, the oldest completed key is dropped for it.
+func (i *Idempotency) begin(scope string, fingerprint [sha256.Size]byte) (*idempotentResult, idempotencyState) {
+	now := i.now()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.sweep(now)
+
+	// Expired keys are treated as new
+	result, ok := i.entries[scope]
+	if ok && now.Before(result.expires) {
+		switch {
+		case result.fingerprint != fingerprint:
+			return nil, idempotencyConflict
+		case !result.done:
+			return nil, idempotencyInProgress
+		default:
+			return result, idempotencyReplay
+		}
+	}
+
+	if ok {
+		i.remove(result)
+	}
+	if i.maxKeys > 0 && len(i.entries) >= i.maxKeys && !i.evict() {
+		return nil, idempotencyFull
+	}
+
+	result = &idempotentResult{scope: scope, fingerprint: fingerprint, expires: now.Add(i.ttl)}
+	result.element = i.order.PushBack(result)
+	i.entries[scope] = result
+	return nil, idempotencyNew
+}
+
+// evict drops the oldest completed key. It returns false if all the keys are in flight. The caller must hold
+// the mutex.
+func (i *Idempotency) evict() bool {
+	for e := i.order.Front(); e != nil; e = e.Next() {
+		if result := e.Value.(*idempotentResult); result.done {
+			i.remove(result)
+			metrics.IdempotencyEvictionsTotal.Inc()
+			return true
+		}
+	}
+	return false
+}
+
+// remove drops the stored result. The caller must hold the mutex.
+func (i *Idempotency) remove(result *idempotentResult) {
+	delete(i.entries, result.scope)
+	i.order.Remove(result.element)
+}
+
+// finish stores the recorded response for the key. Server errors are not stored, so that the request can be
+// retried with the same key.
+func (i *Idempotency) finish(scope string, recorder *recordingWriter) {
+	if recorder.status >= http.StatusInternalServerError {
+		i.release(scope)
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	result, ok := i.entries[scope]
+	if !ok {
+		return
+	}
+	result.done = true
+	result.status = recorder.status
+	result.header = recorder.header
+	if result.header == nil {
+		result.header = recorder.handlerHeader()
+	}
+	result.body = recorder.body.Bytes()
+}
+
+// release drops the reservation of a key whose request did not complete.
+func (i *Idempotency) release(scope string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if result, ok := i.entries[scope]; ok {
+		i.remove(result)
+	}
+}
+
+// sweep drops the expired keys. It runs at most once per sweep interval, so that the cost is amortized over
+// the requests.
+func (i *Idempotency) sweep(now time.Time) {
+	if now.Sub(i.lastSweep) < idempotencySweepInterval {
+		return
+	}
+	i.lastSweep = now
+	for _, result := range i.entries {
+		if result.done && !now.Before(result.expires) {
+			i.remove(result)
+		}
+	}
+}
+
+// Len returns the number of stored idempotency keys.
+func (i *Idempotency) Len() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return len(i.entries)
+}
+
+// replay sends the stored response. Only the headers set by the handler are stored, so the ones set by the
+// outer middlewares, such as the request ID and the CORS headers, are those of the current request.
+func replay(w http.ResponseWriter, result *idempotentResult) {
+	for name, values := range result.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(result.status)
+	_, _ = w.Write(result.body)
+}
+
+// validIdempotencyKey reports whether an idempotency key is non-empty, bounded and printable ASCII.
+func validIdempotencyKey(key string) bool {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// recordingWriter is a wrapper for the http.ResponseWriter that records the response while sending it.
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int          // Status code of the response
+	outer       http.Header  // Headers set by the outer middlewares before the handler ran
+	header      http.Header  // Headers set by the handler as they were sent, nil until WriteHeader
+	body        bytes.Buffer // Body of the response
+	wroteHeader bool         // Whether the headers have been sent
+}
+
+// WriteHeader records the status code and the headers and sends them to the client.
+func (rw *recordingWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.wroteHeader = true
+		rw.status = code
+		rw.header = rw.handlerHeader()
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// handlerHeader returns a copy of the headers the handler set or changed, leaving out the ones of the outer
+// middlewares.
+func (rw *recordingWriter) handlerHeader() http.Header {
+	header := http.Header{}
+	for name, values := range rw.ResponseWriter.Header() {
+		if outer, ok := rw.outer[name]; ok && slices.Equal(outer, values) {
+			continue
+		}
+		header[name] = slices.Clone(values)
+	}
+	return header
+}
+
+// Write records the body and sends it to the client.
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter, so that http.ResponseController can reach it.
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
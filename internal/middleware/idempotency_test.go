@@ -0,0 +1,273 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/config"
+	"sber/pkg/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestIdempotencyReplay verifies that a repeated key with an identical body replays the stored response
+// without running the handler again, and that other bodies, clients and expired keys are handled apart.
+func TestIdempotencyReplay(t *testing.T) {
+	idempotency := NewIdempotency(config.Idempotency{TTL: time.Hour})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	idempotency.now = func() time.Time { return now }
+
+	calls := 0
+	handler := idempotency.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]any{"call": calls, "body": string(body)})
+	}))
+	do := func(clientID, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/execute", strings.NewReader(body))
+		req = req.WithContext(WithClientID(req.Context(), clientID))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	first := do("bank-a", "key-1", `{"a":1}`)
+	if first.Code != http.StatusOK || first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("Expected first request to be handled, got %d", first.Code)
+	}
+
+	// The retry gets the same response, the handler sees the body only once
+	retry := do("bank-a", "key-1", `{"a":1}`)
+	if retry.Code != http.StatusOK {
+		t.Errorf("Expected replayed status %d, got %d", http.StatusOK, retry.Code)
+	}
+	if retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Error("Expected replayed response to be marked")
+	}
+	if retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected replayed Content-Type, got %q", retry.Header().Get("Content-Type"))
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Errorf("Expected replayed body %q, got %q", first.Body.String(), retry.Body.String())
+	}
+	if calls != 1 {
+		t.Errorf("Expected handler to be called once, got %d", calls)
+	}
+
+	tests := []struct {
+		name         string
+		clientID     string
+		key          string
+		body         string
+		expectedCode int
+		expectedCall bool
+	}{
+		{"Different body", "bank-a", "key-1", `{"a":2}`, http.StatusConflict, false},
+		{"Other client with the same key", "bank-b", "key-1", `{"a":1}`, http.StatusOK, true},
+		{"Without key", "bank-a", "", `{"a":1}`, http.StatusOK, true},
+		{"Invalid key", "bank-a", "key\n", `{"a":1}`, http.StatusBadRequest, false},
+		{"Too long key", "bank-a", strings.Repeat("k", 256), `{"a":1}`, http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := calls
+			recorder := do(tt.clientID, tt.key, tt.body)
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, recorder.Code)
+			}
+			if called := calls > before; called != tt.expectedCall {
+				t.Errorf("Expected handler called %v, got %v", tt.expectedCall, called)
+			}
+		})
+	}
+
+	// The key can be reused with any body once it has expired
+	now = now.Add(time.Hour)
+	if recorder := do("bank-a", "key-1", `{"a":2}`); recorder.Code != http.StatusOK ||
+		recorder.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Errorf("Expected expired key to be handled again, got %d", recorder.Code)
+	}
+
+	// Expired keys are dropped by the next sweep
+	now = now.Add(2 * time.Hour)
+	do("bank-c", "key-2", `{}`)
+	if n := idempotency.Len(); n != 1 {
+		t.Errorf("Expected expired keys to be dropped, got %d keys", n)
+	}
+}
+
+// TestIdempotencyReplayOuterHeaders verifies that a replay only repeats the headers set by the handler, so that
+// the request ID and the CORS headers are those of the current request, e.g. of another allowed origin.
+func TestIdempotencyReplayOuterHeaders(t *testing.T) {
+	idempotency := NewIdempotency(config.Idempotency{TTL: time.Hour})
+	cors := NewCORS(config.CORS{AllowedOrigins: []string{"https://a.example", "https://b.example"}})
+	handler := RequestIDMiddleware(cors.Middleware(idempotency.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))))
+	do := func(origin, requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/execute", strings.NewReader(`{}`))
+		req.Header.Set("Origin", origin)
+		req.Header.Set(RequestIDHeader, requestID)
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	do("https://a.example", "req-1")
+	retry := do("https://b.example", "req-2")
+	if retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatal("Expected the retry to be replayed")
+	}
+
+	expected := map[string]string{
+		"Access-Control-Allow-Origin": "https://b.example",
+		RequestIDHeader:               "req-2",
+		"Content-Type":                "application/json",
+	}
+	for name, value := range expected {
+		if got := retry.Header().Values(name); len(got) != 1 || got[0] != value {
+			t.Errorf("Expected replayed %s %q, got %q", name, value, got)
+		}
+	}
+	if vary := retry.Header().Values("Vary"); len(vary) != 1 || vary[0] != "Origin" {
+		t.Errorf("Expected a single Vary: Origin, got %q", vary)
+	}
+}
+
+// TestIdempotencyServerErrorsNotStored verifies that server errors are not replayed, so that the request can
+// be retried with the same key.
+func TestIdempotencyServerErrorsNotStored(t *testing.T) {
+	idempotency := NewIdempotency(config.Idempotency{TTL: time.Hour})
+
+	calls := 0
+	handler := idempotency.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	do := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/execute", strings.NewReader("{}"))
+		req.Header.Set(IdempotencyKeyHeader, "key")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	if code := do(); code != http.StatusInternalServerError {
+		t.Fatalf("Expected status code %d, got %d", http.StatusInternalServerError, code)
+	}
+	if code := do(); code != http.StatusOK {
+		t.Errorf("Expected retry after a server error to be handled, got %d", code)
+	}
+	if calls != 2 {
+		t.Errorf("Expected handler to be called twice, got %d", calls)
+	}
+}
+
+// TestIdempotencyInProgress verifies that a repeated key is rejected while the first request is in flight.
+func TestIdempotencyInProgress(t *testing.T) {
+	idempotency := NewIdempotency(config.Idempotency{TTL: time.Hour})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := idempotency.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/execute", strings.NewReader("{}"))
+		req.Header.Set(IdempotencyKeyHeader, "key")
+		return req
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+	}()
+	<-started
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest())
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d, got %d", http.StatusConflict, recorder.Code)
+	}
+	var errMsg models.ErrorMessage
+	json.NewDecoder(recorder.Body).Decode(&errMsg)
+	if errMsg.Error != "a request with this idempotency key is in progress" {
+		t.Errorf("Unexpected error '%s'", errMsg.Error)
+	}
+
+	close(release)
+	<-done
+}
+
+// TestIdempotencyMaxKeys verifies that the oldest completed key is dropped for a new one, and that new keys are
+// rejected while all the stored keys are in flight.
+func TestIdempotencyMaxKeys(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-release
+		}
+		w.Write([]byte(r.Header.Get(IdempotencyKeyHeader)))
+	})
+	idempotency := NewIdempotency(config.Idempotency{TTL: time.Hour, MaxKeys: 2})
+	handler := idempotency.Middleware(next)
+	serve := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("{}"))
+		req.Header.Set(IdempotencyKeyHeader, key)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	// The third key drops the first one, which is handled again afterwards
+	for _, key := range []string{"first", "second", "third"} {
+		serve("/execute", key)
+	}
+	if n := idempotency.Len(); n != 2 {
+		t.Errorf("Expected 2 stored keys, got %d", n)
+	}
+	if recorder := serve("/execute", "second"); recorder.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Error("Expected the second key to be replayed")
+	}
+	if recorder := serve("/execute", "first"); recorder.Header().Get(IdempotentReplayedHeader) == "true" {
+		t.Error("Expected the first key to be dropped")
+	}
+
+	// With all the keys in flight, a new key is rejected
+	idempotency = NewIdempotency(config.Idempotency{TTL: time.Hour, MaxKeys: 2})
+	handler = idempotency.Middleware(next)
+	var wg sync.WaitGroup
+	for _, key := range []string{"slow-1", "slow-2"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve("/slow", key)
+		}()
+	}
+	<-started
+	<-started
+	if recorder := serve("/execute", "new"); recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d, got %d", http.StatusServiceUnavailable, recorder.Code)
+	}
+	close(release)
+	wg.Wait()
+}
@@ -1,19 +1,27 @@
-// Package middleware provides a middleware function for logging HTTP response status codes
-// and measuring the duration of each request in nanoseconds. It helps in tracking the performance
-// of the application by logging the status code and the processing time for each HTTP request.
+// Package middleware provides middleware functions for structured request logging, request ID
+// propagation, panic recovery and collecting Prometheus metrics. It helps in tracking the performance of the
+// application by logging the method, path, status code, processing time and response size of each
+// HTTP request, correlated by a request ID.
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"sber/internal/metrics"
+	"strconv"
 	"time"
 )
 
+// unmatchedRoute is the route label used for requests that do not match any registered pattern.
+// Using a fixed label keeps the metrics cardinality bounded regardless of the requested paths.
+const unmatchedRoute = "unmatched"
+
 // responseWriterWrapper is a custom wrapper for the http.ResponseWriter that allows capturing the status code
-// of the response. It is used in the RequestInfoMiddleware to track the response status code.
+// and the number of bytes of the response. It is used in the RequestInfoMiddleware to track the response.
 type responseWriterWrapper struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode   int
+	bytesWritten int
 }
 
 // WriteHeader captures the status code and sends it to the client.
@@ -22,9 +30,21 @@ func (rw *responseWriterWrapper) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code) // Send the response header to the client
 }
 
-// RequestInfoMiddleware is a middleware function that logs the status code and duration
-// of each HTTP request in nanoseconds. It can be used for performance monitoring and
-// debugging the response times of API endpoints.
+// Write counts the bytes of the response body and sends them to the client.
+func (rw *responseWriterWrapper) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytesWritten += n
+	return n, err
+}
+
+// Unwrap returns the underlying ResponseWriter, so that http.ResponseController can reach it.
+func (rw *responseWriterWrapper) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// RequestInfoMiddleware is a middleware function that writes a structured log record for each HTTP request
+// with its method, path, status code, duration in nanoseconds, response size, client IP and request ID.
+// It can be used for performance monitoring and debugging the response times of API endpoints.
 func RequestInfoMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now() // Capture the start time of the request
@@ -38,8 +58,55 @@ func RequestInfoMiddleware(next http.Handler) http.Handler {
 		// Measure the duration of the request processing
 		duration := time.Since(start)
 
-		// Log the status code and request processing time in nanoseconds
-		code := wrappedWriter.statusCode
-		log.Printf("status_code: %v , duration: %v ns", code, duration.Nanoseconds())
+		// Log the request summary, correlated by the request ID
+		Logger(r.Context()).LogAttrs(r.Context(), slog.LevelInfo, "request handled",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", wrappedWriter.statusCode),
+			slog.Int64("duration_ns", duration.Nanoseconds()),
+			slog.Int("bytes", wrappedWriter.bytesWritten),
+			slog.String("client_ip", ClientIP(r)),
+		)
+	})
+}
+
+// MetricsMiddleware is a middleware function that records the number of requests and their latency
+// per route pattern and status code. When next is an *http.ServeMux, the registered pattern is used
+// as the route label; otherwise the request path is used.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now() // Capture the start time of the request
+
+		// Resolve the route label before the handler runs, so that it cannot be affected by the handler
+		route := routeOf(next, r)
+
+		// Wrap the ResponseWriter to capture the status code
+		wrappedWriter := &responseWriterWrapper{ResponseWriter: w, statusCode: http.StatusOK}
+
+		// Record the request count and latency, a panicking handler is recorded as an internal server error
+		completed := false
+		defer func() {
+			code := strconv.Itoa(wrappedWriter.statusCode)
+			if !completed {
+				code = strconv.Itoa(http.StatusInternalServerError)
+			}
+			metrics.HTTPRequestsTotal.Inc(route, code)
+			metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), route, code)
+		}()
+
+		next.ServeHTTP(wrappedWriter, r)
+		completed = true
 	})
 }
+
+// routeOf returns the route label for the request.
+func routeOf(next http.Handler, r *http.Request) string {
+	mux, ok := next.(*http.ServeMux)
+	if !ok {
+		return r.URL.Path
+	}
+	if _, pattern := mux.Handler(r); pattern != "" {
+		return pattern
+	}
+	return unmatchedRoute
+}
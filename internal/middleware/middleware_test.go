@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"sber/internal/metrics"
 	"testing"
 	"time"
 )
@@ -84,3 +85,83 @@ func TestRequestInfoMiddlewareWithError(t *testing.T) {
 		t.Errorf("Expected duration >= 100ms, got %v", duration)
 	}
 }
+
+// TestMetricsMiddleware verifies that MetricsMiddleware records requests by route pattern and status code.
+func TestMetricsMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics-test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := MetricsMiddleware(mux)
+
+	before := metrics.HTTPRequestsTotal.Value("/metrics-test", "418")
+	unmatchedBefore := metrics.HTTPRequestsTotal.Value(unmatchedRoute, "404")
+
+	// Execute a request to a registered route and to an unknown route
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics-test", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown/path", nil))
+
+	// Verify that both requests were counted with bounded route labels
+	if got := metrics.HTTPRequestsTotal.Value("/metrics-test", "418"); got != before+1 {
+		t.Errorf("Expected counter %v, got %v", before+1, got)
+	}
+	if got := metrics.HTTPRequestsTotal.Value(unmatchedRoute, "404"); got != unmatchedBefore+1 {
+		t.Errorf("Expected unmatched counter %v, got %v", unmatchedBefore+1, got)
+	}
+	if metrics.HTTPRequestDuration.Count("/metrics-test", "418") == 0 {
+		t.Error("Expected latency observation for /metrics-test")
+	}
+}
+
+// TestRequestIDMiddleware verifies that the request ID is taken from the header or generated,
+// stored in the request context and echoed back in the response header.
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"ID from header", "abc-123", "abc-123"},
+		{"Generated ID", "", ""},
+		{"Invalid header replaced", "bad id\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromContext string
+			handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromContext = RequestIDFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			echoed := recorder.Header().Get(RequestIDHeader)
+			if echoed == "" || echoed != fromContext {
+				t.Errorf("Expected echoed ID %q to match context ID %q", echoed, fromContext)
+			}
+			if tt.expected != "" && echoed != tt.expected {
+				t.Errorf("Expected ID %q, got %q", tt.expected, echoed)
+			}
+			if tt.expected == "" && len(echoed) != 32 {
+				t.Errorf("Expected generated 32-character ID, got %q", echoed)
+			}
+		})
+	}
+}
+
+// TestResponseWriterWrapperBytes verifies that responseWriterWrapper counts the bytes written.
+func TestResponseWriterWrapperBytes(t *testing.T) {
+	wrappedWriter := &responseWriterWrapper{ResponseWriter: httptest.NewRecorder(), statusCode: http.StatusOK}
+
+	wrappedWriter.Write([]byte("hello"))
+	wrappedWriter.Write([]byte(" world"))
+
+	if wrappedWriter.bytesWritten != 11 {
+		t.Errorf("Expected 11 bytes written, got %d", wrappedWriter.bytesWritten)
+	}
+}
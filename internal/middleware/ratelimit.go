@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"sber/internal/config"
+	"sber/internal/metrics"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the request rate of each client with a token bucket. Clients are identified by their
+// authenticated client ID or, without authentication, by their IP address. Buckets of clients that have been
+// idle for longer than the idle timeout are dropped, so that the limiter does not grow without bound.
+type RateLimiter struct {
+	rate        float64       // Tokens added to a bucket per second
+	burst       float64       // Capacity of a bucket
+	idleTimeout time.Duration // Time after which an idle bucket is dropped
+	mu          sync.Mutex    // Protects buckets and lastSweep
+	buckets     map[string]*bucket
+	lastSweep   time.Time        // Time of the last removal of idle buckets
+	now         func() time.Time // Clock used for refilling, replaceable in tests
+}
+
+// bucket is the token bucket of a single client.
+type bucket struct {
+	tokens float64   // Tokens currently available
+	last   time.Time // Time of the last refill
+}
+
+// NewRateLimiter creates a RateLimiter from the configuration. A non-positive burst defaults to one request,
+// and a non-positive idle timeout defaults to the time needed to refill a full bucket.
+func NewRateLimiter(cfg config.RateLimit) *RateLimiter {
+	burst := float64(cfg.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	idleTimeout := cfg.IdleTimeout
+	if idleTimeout <= 0 && cfg.RequestsPerSecond > 0 {
+		idleTimeout = time.Duration(burst / cfg.RequestsPerSecond * float64(time.Second))
+	}
+
+	return &RateLimiter{
+		rate:        cfg.RequestsPerSecond,
+		burst:       burst,
+		idleTimeout: idleTimeout,
+		buckets:     map[string]*bucket{},
+		now:         time.Now,
+	}
+}
+
+// Middleware is a middleware function that rejects requests over the client's rate with 429, a Retry-After
+// header and a JSON ErrorMessage.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Identify the client by its API key identity, falling back to the IP address
+		key := ClientIDFromContext(r.Context())
+		if key == "" {
+			key = "ip:" + ClientIP(r)
+		}
+
+		if ok, retryAfter := l.Allow(key); !ok {
+			metrics.RateLimitedTotal.Inc()
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			writeErrorMessage(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Allow takes a token from the bucket of the client identified by key. If the bucket is empty, it returns
+// false and the time until the next token becomes available. Middleware uses it for HTTP requests, other
+// transports call it directly.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	// New clients start with a full bucket
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	// Refill the bucket for the time elapsed since the last request
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	// Without a refill rate the bucket never recovers, ask the client to come back after the idle timeout
+	if l.rate <= 0 {
+		return false, l.idleTimeout
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// sweep drops the buckets that have been idle for longer than the idle timeout. It runs at most once per
+// idle timeout, so that the cost is amortized over the requests.
+func (l *RateLimiter) sweep(now time.Time) {
+	if l.idleTimeout <= 0 || now.Sub(l.lastSweep) < l.idleTimeout {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Len returns the number of client buckets currently held by the limiter.
+func (l *RateLimiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.buckets)
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/config"
+	"sber/pkg/models"
+	"testing"
+	"time"
+)
+
+// TestRateLimiterBurstAndRefill verifies that a client may send a burst of requests, is rejected with 429
+// and Retry-After afterwards, and is allowed again once tokens have been refilled.
+func TestRateLimiterBurstAndRefill(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimit{RequestsPerSecond: 1, Burst: 2, IdleTimeout: time.Minute})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	do := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/execute", nil)
+		req.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	// The burst is allowed
+	for i := range 2 {
+		if code := do("10.0.0.1:1234").Code; code != http.StatusOK {
+			t.Errorf("Request %d: expected status code %d, got %d", i+1, http.StatusOK, code)
+		}
+	}
+
+	// The next request is rejected with Retry-After and a JSON error
+	recorder := do("10.0.0.1:1234")
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status code %d, got %d", http.StatusTooManyRequests, recorder.Code)
+	}
+	if retryAfter := recorder.Header().Get("Retry-After"); retryAfter != "1" {
+		t.Errorf("Expected Retry-After 1, got %q", retryAfter)
+	}
+	var errMsg models.ErrorMessage
+	json.NewDecoder(recorder.Body).Decode(&errMsg)
+	if errMsg.Error != "rate limit exceeded" {
+		t.Errorf("Expected error 'rate limit exceeded', got '%s'", errMsg.Error)
+	}
+
+	// Another client has its own bucket
+	if code := do("10.0.0.2:1234").Code; code != http.StatusOK {
+		t.Errorf("Expected other client to pass, got %d", code)
+	}
+
+	// A token is refilled after one second
+	now = now.Add(time.Second)
+	if code := do("10.0.0.1:1234").Code; code != http.StatusOK {
+		t.Errorf("Expected request after refill to pass, got %d", code)
+	}
+}
+
+// TestRateLimiterClientID verifies that authenticated clients are limited by their identity, not their IP.
+func TestRateLimiterClientID(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimit{RequestsPerSecond: 1, Burst: 1})
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(clientID string) int {
+		req := httptest.NewRequest(http.MethodPost, "/execute", nil)
+		req = req.WithContext(WithClientID(req.Context(), clientID))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	if code := do("bank-a"); code != http.StatusOK {
+		t.Errorf("Expected first request of bank-a to pass, got %d", code)
+	}
+	if code := do("bank-b"); code != http.StatusOK {
+		t.Errorf("Expected first request of bank-b from the same IP to pass, got %d", code)
+	}
+	if code := do("bank-a"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected second request of bank-a to be limited, got %d", code)
+	}
+}
+
+// TestRateLimiterExpiresIdleBuckets verifies that buckets of idle clients are dropped.
+func TestRateLimiterExpiresIdleBuckets(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimit{RequestsPerSecond: 1, Burst: 1, IdleTimeout: time.Minute})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	for _, client := range []string{"a", "b", "c"} {
+		limiter.Allow(client)
+	}
+	if limiter.Len() != 3 {
+		t.Fatalf("Expected 3 buckets, got %d", limiter.Len())
+	}
+
+	// After the idle timeout only the active client keeps its bucket
+	now = now.Add(2 * time.Minute)
+	limiter.Allow("d")
+	if limiter.Len() != 1 {
+		t.Errorf("Expected idle buckets to be dropped, got %d buckets", limiter.Len())
+	}
+}
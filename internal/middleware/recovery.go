@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sber/internal/metrics"
+)
+
+// RecoveryMiddleware is a middleware function that recovers from panics in the handlers. It logs the panic
+// with its stack trace and the request ID, increments the panic counter and responds with a 500 status
+// and a JSON ErrorMessage, so that a single faulty request does not drop the connection without a response.
+func RecoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Wrap the ResponseWriter to know whether the handler has already sent the headers
+		wrappedWriter := &headerTrackingWriter{ResponseWriter: w}
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// http.ErrAbortHandler is used to deliberately abort a response, let the server handle it
+			if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(rec)
+			}
+
+			metrics.PanicsTotal.Inc()
+			Logger(r.Context()).Error("panic recovered",
+				"panic", fmt.Sprint(rec),
+				"method", r.Method,
+				"path", r.URL.Path,
+				"stack", string(debug.Stack()),
+			)
+
+			// The status can only be changed if nothing has been sent yet
+			if wrappedWriter.wroteHeader {
+				return
+			}
+			writeErrorMessage(w, r, http.StatusInternalServerError, "internal server error")
+		}()
+
+		next.ServeHTTP(wrappedWriter, r)
+	})
+}
+
+// headerTrackingWriter is a wrapper for the http.ResponseWriter that records whether the headers have been sent.
+type headerTrackingWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+// WriteHeader records that the headers have been sent and sends them to the client.
+func (hw *headerTrackingWriter) WriteHeader(code int) {
+	hw.wroteHeader = true
+	hw.ResponseWriter.WriteHeader(code)
+}
+
+// Write records that the headers have been sent implicitly and sends the body to the client.
+func (hw *headerTrackingWriter) Write(b []byte) (int, error) {
+	hw.wroteHeader = true
+	return hw.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter, so that http.ResponseController can reach it.
+func (hw *headerTrackingWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sber/internal/metrics"
+	"sber/pkg/models"
+	"testing"
+)
+
+// TestRecoveryMiddleware verifies that a panicking handler results in a 500 JSON error and an incremented counter.
+func TestRecoveryMiddleware(t *testing.T) {
+	handler := RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	before := metrics.PanicsTotal.Value()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req = req.WithContext(WithRequestID(req.Context(), "panic-test"))
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, req)
+
+	// Verify the response status and body
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, recorder.Code)
+	}
+	var errMsg models.ErrorMessage
+	if err := json.NewDecoder(recorder.Body).Decode(&errMsg); err != nil {
+		t.Fatalf("Expected JSON error message, got error: %v", err)
+	}
+	if errMsg.Error != "internal server error" {
+		t.Errorf("Expected error 'internal server error', got '%s'", errMsg.Error)
+	}
+
+	// Verify that the panic was counted
+	if got := metrics.PanicsTotal.Value(); got != before+1 {
+		t.Errorf("Expected panic counter %v, got %v", before+1, got)
+	}
+}
+
+// TestRecoveryMiddlewareAfterWrite verifies that the status is not overwritten when the handler
+// has already started the response before panicking.
+func TestRecoveryMiddlewareAfterWrite(t *testing.T) {
+	handler := RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("boom")
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	if recorder.Code != http.StatusAccepted {
+		t.Errorf("Expected status code %d, got %d", http.StatusAccepted, recorder.Code)
+	}
+}
+
+// TestRecoveryMiddlewareAbortHandler verifies that http.ErrAbortHandler is propagated to the server.
+func TestRecoveryMiddlewareAbortHandler(t *testing.T) {
+	handler := RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("Expected http.ErrAbortHandler to be re-panicked, got %v", rec)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+}
+
+// TestMetricsMiddlewarePanic verifies that a panicking handler is recorded as an internal server error.
+func TestMetricsMiddlewarePanic(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic-test", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	handler := RecoveryMiddleware(MetricsMiddleware(mux))
+
+	before := metrics.HTTPRequestsTotal.Value("/panic-test", "500")
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic-test", nil))
+
+	if got := metrics.HTTPRequestsTotal.Value("/panic-test", "500"); got != before+1 {
+		t.Errorf("Expected counter %v, got %v", before+1, got)
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+)
+
+// RequestIDHeader is the header used to receive and return the request correlation ID.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits the length of client-supplied request IDs to keep log lines bounded.
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key under which the request ID is stored.
+type requestIDKey struct{}
+
+// RequestIDMiddleware is a middleware function that takes the request ID from the X-Request-ID header
+// or generates a new one, stores it in the request context and echoes it back in the response header.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reuse the caller's ID if it is safe to log, otherwise generate a new one
+		id := RequestID(r.Header.Get(RequestIDHeader))
+
+		// Echo the ID back and make it available to the handlers
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), id)))
+	})
+}
+
+// RequestID returns the request ID presented by the caller if it is safe to log, and a newly generated one
+// otherwise. Other transports, such as gRPC metadata, use it to assign request IDs like RequestIDMiddleware.
+func RequestID(presented string) string {
+	if validRequestID(presented) {
+		return presented
+	}
+	return newRequestID()
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or an empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Logger returns the default structured logger annotated with the request ID stored in ctx.
+func Logger(ctx context.Context) *slog.Logger {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return slog.Default().With(slog.String("request_id", id))
+	}
+	return slog.Default()
+}
+
+// ClientIP returns the IP address of the client that sent the request.
+// Forwarding headers are ignored, since they can be set by any client.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// newRequestID generates a random 128-bit request ID encoded as hex.
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// validRequestID reports whether a client-supplied request ID is non-empty, bounded and printable ASCII.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
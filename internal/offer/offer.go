@@ -0,0 +1,242 @@
+// Package offer renders printable mortgage offer documents as PDF.
+//
+// An offer describes a stored calculation: the loan parameters, the program and its rate, the monthly
+// payment and overpayment, followed by the amortization table, which continues over as many pages as needed.
+// Every page carries the configured header (title and optional logo) and the footer disclaimer.
+//
+// Types and Functions:
+//   - Options: The branding of the document and its issue date.
+//   - Render: Writes the offer document of a calculation.
+//   - formatAmount: Formats an amount with thousands separators.
+package offer
+
+import (
+	"fmt"
+	"io"
+	"sber/internal/pdf"
+	"sber/pkg/models"
+	"strconv"
+	"time"
+)
+
+// Page layout in points.
+const (
+	margin        = 50.0                     // Margin around the content
+	contentWidth  = pdf.PageWidth - 2*margin // Width of the content area
+	headerHeight  = 56.0                     // Height of the header area, including the rule below it
+	logoHeight    = 40.0                     // Height the logo is scaled to
+	logoMaxWidth  = 160.0                    // Maximum width of the logo
+	rowHeight     = 14.0                     // Height of a table row
+	footerSize    = 7.5                      // Font size of the disclaimer
+	footerLeading = 9.5                      // Line height of the disclaimer
+)
+
+// Options configures the branding of offer documents.
+type Options struct {
+	Title      string     // Title printed in the header of every page
+	Logo       *pdf.Image // Logo decoded with pdf.DecodeImage, printed in the header, optional
+	Disclaimer string     // Text printed in the footer of every page
+	IssuedAt   time.Time  // Issue date printed in the header
+}
+
+// Render writes the offer document of the stored calculation and its payment schedule to w.
+// It returns an error if the document cannot be written.
+func Render(w io.Writer, entry models.CacheStorageFormat, schedule []models.Payment, opts Options) error {
+	doc := pdf.New()
+
+	// Embed the logo once, it is drawn on every page
+	var logo *pdf.Image
+	if opts.Logo != nil {
+		logo = doc.AddDecodedImage(opts.Logo)
+	}
+
+	// The footer height depends on the length of the disclaimer
+	disclaimer := pdf.WrapText(pdf.Helvetica, footerSize, opts.Disclaimer, contentWidth)
+	bottom := margin + float64(len(disclaimer))*footerLeading + 16
+
+	l := &layout{doc: doc, entry: entry, opts: opts, logo: logo, bottom: bottom}
+	l.newPage()
+	l.summary()
+	l.schedule(schedule)
+
+	// Draw the footers once the number of pages is known
+	pages := doc.Pages()
+	for i, page := range pages {
+		drawFooter(page, disclaimer, i+1, len(pages))
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// layout places the content of an offer on pages, starting new pages when the current one is full.
+type layout struct {
+	doc    *pdf.Document
+	entry  models.CacheStorageFormat
+	opts   Options
+	logo   *pdf.Image
+	page   *pdf.Page // The current page
+	y      float64   // The baseline of the next line on the current page
+	bottom float64   // The lowest baseline available above the footer
+}
+
+// newPage starts a new page with the header.
+func (l *layout) newPage() {
+	l.page = l.doc.AddPage()
+	top := pdf.PageHeight - margin
+
+	// Logo on the left, scaled to the header height
+	titleX := margin
+	if l.logo != nil {
+		width := logoHeight * float64(l.logo.Width) / float64(l.logo.Height)
+		height := logoHeight
+		if width > logoMaxWidth {
+			width, height = logoMaxWidth, logoMaxWidth*float64(l.logo.Height)/float64(l.logo.Width)
+		}
+		l.page.DrawImage(l.logo, margin, top-height, width, height)
+		titleX += width + 12
+	}
+
+	// Title next to the logo, issue date and calculation number on the right
+	l.page.Text(titleX, top-26, pdf.HelveticaBold, 18, l.opts.Title)
+	right := pdf.PageWidth - margin
+	l.page.TextRight(right, top-12, pdf.Helvetica, 9, "Issued "+l.opts.IssuedAt.Format(time.DateOnly))
+	l.page.TextRight(right, top-24, pdf.Helvetica, 9, "Calculation No. "+strconv.Itoa(int(l.entry.ID)))
+	l.page.Line(margin, top-headerHeight+6, right, top-headerHeight+6, 0.75)
+
+	l.y = top - headerHeight - 18
+}
+
+// ensureSpace starts a new page if less than the given height is left above the footer.
+// It reports whether a new page was started.
+func (l *layout) ensureSpace(height float64) bool {
+	if l.y-height >= l.bottom {
+		return false
+	}
+	l.newPage()
+	return true
+}
+
+// heading draws a section heading.
+func (l *layout) heading(text string) {
+	l.ensureSpace(3 * rowHeight)
+	l.page.Text(margin, l.y, pdf.HelveticaBold, 12, text)
+	l.y -= rowHeight + 6
+}
+
+// summary draws the loan parameters and the results of the calculation.
+func (l *layout) summary() {
+	params, aggregates := l.entry.Params, l.entry.Aggregates
+	rows := [][2]string{
+		{"Program", programName(l.entry.Program)},
+		{"Interest rate", fmt.Sprintf("%d%% per annum", aggregates.Rate)},
+		{"Object cost", formatAmount(params.ObjectCost)},
+		{"Initial payment", formatAmount(params.InitialPayment)},
+		{"Loan sum", formatAmount(aggregates.LoanSum)},
+		{"Term", fmt.Sprintf("%d months", params.Months)},
+		{"Monthly payment", formatAmount(aggregates.MonthlyPayment)},
+		{"Overpayment", formatAmount(aggregates.Overpayment)},
+		{"Last payment date", aggregates.LastPaymentDate},
+	}
+
+	l.heading("Loan parameters")
+	for _, row := range rows {
+		l.ensureSpace(rowHeight)
+		l.page.Text(margin, l.y, pdf.Helvetica, 10, row[0])
+		l.page.Text(margin+180, l.y, pdf.HelveticaBold, 10, row[1])
+		l.y -= rowHeight
+	}
+	l.y -= rowHeight
+}
+
+// column is a column of the amortization table, aligned to the right edge given relative to the margin.
+type column struct {
+	title string
+	right float64
+}
+
+// scheduleColumns are the columns of the amortization table.
+var scheduleColumns = []column{
+	{"No.", 30},
+	{"Date", 110},
+	{"Payment", 210},
+	{"Principal", 300},
+	{"Interest", 390},
+	{"Balance", contentWidth},
+}
+
+// schedule draws the amortization table, repeating the table header on every page.
+func (l *layout) schedule(payments []models.Payment) {
+	l.heading("Amortization schedule")
+	l.tableHeader()
+
+	for _, p := range payments {
+		if l.ensureSpace(rowHeight) {
+			l.tableHeader()
+		}
+		cells := []string{
+			strconv.Itoa(int(p.Number)),
+			p.Date,
+			formatAmount(p.Amount),
+			formatAmount(p.Principal),
+			formatAmount(p.Interest),
+			formatAmount(p.Balance),
+		}
+		for i, cell := range cells {
+			l.page.TextRight(margin+scheduleColumns[i].right, l.y, pdf.Helvetica, 9, cell)
+		}
+		l.y -= rowHeight
+	}
+}
+
+// tableHeader draws the header row of the amortization table on a shaded background.
+func (l *layout) tableHeader() {
+	l.page.FillRect(margin, l.y-4, contentWidth, rowHeight, 0.9)
+	for _, c := range scheduleColumns {
+		l.page.TextRight(margin+c.right, l.y, pdf.HelveticaBold, 9, c.title)
+	}
+	l.y -= rowHeight + 2
+}
+
+// drawFooter draws the disclaimer and the page number at the bottom of the page.
+func drawFooter(page *pdf.Page, disclaimer []string, number, total int) {
+	y := margin + float64(len(disclaimer)-1)*footerLeading
+	page.Line(margin, y+footerLeading+2, pdf.PageWidth-margin, y+footerLeading+2, 0.5)
+	for _, line := range disclaimer {
+		page.Text(margin, y, pdf.Helvetica, footerSize, line)
+		y -= footerLeading
+	}
+	page.TextRight(pdf.PageWidth-margin, margin-18, pdf.Helvetica, 8, fmt.Sprintf("Page %d of %d", number, total))
+}
+
+// programName returns the name of the selected loan program.
+func programName(program models.Program) string {
+	switch {
+	case program.Base:
+		return "Base"
+	case program.Military:
+		return "Military"
+	case program.Salary:
+		return "Salary"
+	default:
+		return "-"
+	}
+}
+
+// formatAmount formats an amount with spaces as thousands separators, e.g. 5 000 000.
+func formatAmount(amount int32) string {
+	digits := strconv.FormatInt(int64(amount), 10)
+	sign := ""
+	if amount < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var out []byte
+	for i := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out = append(out, ' ')
+		}
+		out = append(out, digits[i])
+	}
+	return sign + string(out)
+}
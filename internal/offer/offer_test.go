@@ -0,0 +1,124 @@
+package offer
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"sber/internal/pdf"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testEntry returns a stored calculation with a schedule of the given number of payments.
+func testEntry(months int32) (models.CacheStorageFormat, []models.Payment) {
+	entry := models.CacheStorageFormat{
+		ID:      7,
+		Params:  models.Params{ObjectCost: 5000000, InitialPayment: 1000000, Months: months},
+		Program: models.Program{Salary: true},
+		Aggregates: models.Aggregates{
+			Rate: 8, LoanSum: 4000000, MonthlyPayment: 33458, Overpayment: 4029920, LastPaymentDate: "2044-02-29",
+		},
+	}
+	schedule := make([]models.Payment, months)
+	for i := range schedule {
+		schedule[i] = models.Payment{Number: int32(i + 1), Date: "2024-03-29", Amount: 33458}
+	}
+	return entry, schedule
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name          string
+		months        int32
+		expectedPages int
+	}{
+		{"Short schedule fits on one page", 12, 1},
+		{"Long schedule continues over pages", 240, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, schedule := testEntry(tt.months)
+			var buf bytes.Buffer
+			err := Render(&buf, entry, schedule, Options{
+				Title:      "Test Bank offer",
+				Disclaimer: "Not a public offer.",
+				IssuedAt:   time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			data := buf.String()
+
+			if !strings.HasPrefix(data, "%PDF-") {
+				t.Error("Expected a PDF document")
+			}
+			if !strings.Contains(data, fmt.Sprintf("/Count %d ", tt.expectedPages)) {
+				t.Errorf("Expected %d pages", tt.expectedPages)
+			}
+
+			// The header and footer are repeated on every page
+			for _, text := range []string{"(Test Bank offer)", "(Not a public offer.)", "(Amortization schedule)"} {
+				count := strings.Count(data, text)
+				if text == "(Amortization schedule)" {
+					if count != 1 {
+						t.Errorf("Expected the schedule heading once, got %d", count)
+					}
+					continue
+				}
+				if count != tt.expectedPages {
+					t.Errorf("Expected %s on each of %d pages, got %d", text, tt.expectedPages, count)
+				}
+			}
+			for _, text := range []string{"(Issued 2026-10-18)", "(Calculation No. 7)", "(Salary)", "(33 458)", "(4 029 920)",
+				fmt.Sprintf("(Page %d of %d)", tt.expectedPages, tt.expectedPages)} {
+				if !strings.Contains(data, text) {
+					t.Errorf("Expected %s in the document", text)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderLogo(t *testing.T) {
+	entry, schedule := testEntry(12)
+
+	var logo bytes.Buffer
+	if err := png.Encode(&logo, image.NewRGBA(image.Rect(0, 0, 300, 60))); err != nil {
+		t.Fatalf("failed to encode logo: %v", err)
+	}
+	decoded, err := pdf.DecodeImage(logo.Bytes())
+	if err != nil {
+		t.Fatalf("failed to decode logo: %v", err)
+	}
+
+	// The decoded logo is shared by the documents
+	for i := 0; i < 2; i++ {
+		var buf bytes.Buffer
+		if err = Render(&buf, entry, schedule, Options{Title: "Offer", Logo: decoded}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(buf.String(), "/Im1 Do") {
+			t.Error("Expected the logo to be drawn")
+		}
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := map[int32]string{
+		0:        "0",
+		999:      "999",
+		1000:     "1 000",
+		33458:    "33 458",
+		5000000:  "5 000 000",
+		-1234567: "-1 234 567",
+	}
+	for amount, expected := range tests {
+		if formatted := formatAmount(amount); formatted != expected {
+			t.Errorf("Expected %d to be formatted as %q, got %q", amount, expected, formatted)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // Register the JPEG decoder for images
+	_ "image/png"  // Register the PNG decoder for images
+)
+
+// Image is an image embedded in a document.
+type Image struct {
+	name       string // Name of the image in the page resources
+	data       []byte // Encoded image data
+	filter     string // PDF filter decoding the data
+	colorSpace string // PDF color space of the pixels
+	Width      int    // Width in pixels
+	Height     int    // Height in pixels
+}
+
+// AddImage adds a JPEG or PNG image to the document, so that it can be drawn on the pages. The image is decoded
+// as by DecodeImage.
+func (d *Document) AddImage(data []byte) (*Image, error) {
+	img, err := DecodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	return d.AddDecodedImage(img), nil
+}
+
+// AddDecodedImage adds an image decoded with DecodeImage to the document, so that it can be drawn on the pages.
+// The image itself is not changed, so that it can be added to documents rendered concurrently.
+func (d *Document) AddDecodedImage(img *Image) *Image {
+	added := *img
+	added.name = fmt.Sprintf("Im%d", len(d.images)+1)
+	d.images = append(d.images, &added)
+	return &added
+}
+
+// DecodeImage decodes a JPEG or PNG image for AddDecodedImage, so that an image used in many documents is
+// decoded once. JPEG data is embedded as is, other images are decoded and embedded as compressed RGB pixels with
+// transparency flattened onto a white background.
+func DecodeImage(data []byte) (*Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	img := &Image{Width: cfg.Width, Height: cfg.Height}
+	if format == "jpeg" && cfg.ColorModel != color.CMYKModel {
+		img.data, img.filter = data, "DCTDecode"
+		img.colorSpace = "DeviceRGB"
+		if cfg.ColorModel == color.GrayModel {
+			img.colorSpace = "DeviceGray"
+		}
+	} else if err := img.encodePixels(data); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// encodePixels decodes the image and stores its pixels as zlib-compressed RGB.
+func (img *Image) encodePixels(data []byte) error {
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	bounds := decoded.Bounds()
+	row := make([]byte, 0, 3*bounds.Dx())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row = row[:0]
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			// Flatten transparency onto white: premultiplied color plus the uncovered white
+			r, g, b, a := decoded.At(x, y).RGBA()
+			white := 0xffff - a
+			row = append(row, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+		if _, err := zw.Write(row); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	img.data, img.filter, img.colorSpace = buf.Bytes(), "FlateDecode", "DeviceRGB"
+	return nil
+}
+
+// dictionary returns the stream dictionary entries of the image XObject.
+func (img *Image) dictionary() string {
+	return fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /%s ",
+		img.Width, img.Height, img.colorSpace, img.filter)
+}
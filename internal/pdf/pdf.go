@@ -0,0 +1,216 @@
+// Package pdf provides a minimal writer of PDF documents implemented with the standard library only.
+//
+// It supports what the service needs for printable documents: A4 pages with text in the standard Helvetica
+// fonts, lines, filled rectangles and JPEG or PNG images. Coordinates are given in points (1/72 inch) from
+// the bottom left corner of the page, as in PDF itself. Text is encoded with WinAnsiEncoding, so characters
+// outside of Latin-1 are replaced with a question mark.
+//
+// Types and Functions:
+//   - Document: A document with its pages and images, written with WriteTo.
+//   - Page: A page of the document with its drawing operations.
+//   - Image: An image added to the document, which can be drawn on any page.
+//   - DecodeImage: Decodes an image once for adding it to many documents.
+//   - Font: One of the standard fonts.
+//   - TextWidth: Measures the width of a text.
+//   - WrapText: Splits a text into lines that fit into a width.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Size of A4 pages in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Font is one of the standard Type 1 fonts available in every PDF reader.
+type Font int
+
+// Supported fonts.
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+// resourceName returns the name of the font in the page resources.
+func (f Font) resourceName() string {
+	return "F" + strconv.Itoa(int(f)+1)
+}
+
+// baseName returns the PostScript name of the font.
+func (f Font) baseName() string {
+	if f == HelveticaBold {
+		return "Helvetica-Bold"
+	}
+	return "Helvetica"
+}
+
+// Document is a PDF document under construction.
+type Document struct {
+	pages  []*Page  // Pages in order
+	images []*Image // Images referenced by the pages
+}
+
+// New creates an empty document.
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage appends a new A4 page to the document and returns it.
+func (d *Document) AddPage() *Page {
+	p := &Page{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Pages returns the pages of the document in order.
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+// Page is a page of a document. Drawing operations are recorded in its content stream.
+type Page struct {
+	content bytes.Buffer // The content stream
+	images  []*Image     // Images drawn on the page
+}
+
+// Text draws a single line of text with its baseline starting at (x, y).
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		font.resourceName(), num(size), num(x), num(y), escapeText(encodeText(text)))
+}
+
+// TextRight draws a single line of text ending at x, which aligns numbers in table columns.
+func (p *Page) TextRight(x, y float64, font Font, size float64, text string) {
+	p.Text(x-TextWidth(font, size, text), y, font, size, text)
+}
+
+// Line draws a black line of the given width from (x1, y1) to (x2, y2).
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+// FillRect fills a rectangle with its bottom left corner at (x, y) with a gray level from 0 (black) to 1 (white).
+func (p *Page) FillRect(x, y, width, height, gray float64) {
+	fmt.Fprintf(&p.content, "q %s g %s %s %s %s re f Q\n", num(gray), num(x), num(y), num(width), num(height))
+}
+
+// DrawImage draws the image scaled into the rectangle with its bottom left corner at (x, y).
+func (p *Page) DrawImage(img *Image, x, y, width, height float64) {
+	p.images = append(p.images, img)
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /%s Do Q\n", num(width), num(height), num(x), num(y), img.name)
+}
+
+// WriteTo writes the document to w. It implements io.WriterTo.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	out := &objectWriter{}
+	out.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Object numbers: catalog, page tree, fonts, images, then a page and its content stream per page
+	const catalogID, pagesID, firstFontID = 1, 2, 3
+	fontCount := 2
+	firstImageID := firstFontID + fontCount
+	firstPageID := firstImageID + len(d.images)
+
+	out.object(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageID+2*i)
+	}
+	out.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		strings.Join(kids, " "), len(d.pages), num(PageWidth), num(PageHeight)))
+
+	fonts := make([]string, fontCount)
+	for i := 0; i < fontCount; i++ {
+		font := Font(i)
+		out.object(firstFontID+i, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>",
+			font.baseName()))
+		fonts[i] = fmt.Sprintf("/%s %d 0 R", font.resourceName(), firstFontID+i)
+	}
+
+	imageIDs := make(map[*Image]int, len(d.images))
+	for i, img := range d.images {
+		imageIDs[img] = firstImageID + i
+		out.stream(firstImageID+i, img.dictionary(), img.data)
+	}
+
+	for i, page := range d.pages {
+		// Reference the images drawn on the page
+		var xObjects []string
+		seen := map[*Image]bool{}
+		for _, img := range page.images {
+			if !seen[img] {
+				seen[img] = true
+				xObjects = append(xObjects, fmt.Sprintf("/%s %d 0 R", img.name, imageIDs[img]))
+			}
+		}
+		resources := fmt.Sprintf("/Font << %s >>", strings.Join(fonts, " "))
+		if len(xObjects) > 0 {
+			resources += fmt.Sprintf(" /XObject << %s >>", strings.Join(xObjects, " "))
+		}
+
+		pageID := firstPageID + 2*i
+		out.object(pageID, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Resources << %s >> /Contents %d 0 R >>",
+			pagesID, resources, pageID+1))
+		out.stream(pageID+1, "", page.content.Bytes())
+	}
+
+	// Cross-reference table with the byte offset of every object
+	xrefOffset := out.buf.Len()
+	out.printf("xref\n0 %d\n0000000000 65535 f \n", len(out.offsets)+1)
+	for id := 1; id <= len(out.offsets); id++ {
+		out.printf("%010d 00000 n \n", out.offsets[id])
+	}
+	out.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(out.offsets)+1, catalogID, xrefOffset)
+
+	n, err := w.Write(out.buf.Bytes())
+	return int64(n), err
+}
+
+// objectWriter writes the objects of a document and records their offsets for the cross-reference table.
+type objectWriter struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+// printf writes formatted output.
+func (o *objectWriter) printf(format string, args ...any) {
+	fmt.Fprintf(&o.buf, format, args...)
+}
+
+// object writes an indirect object with the given body.
+func (o *objectWriter) object(id int, body string) {
+	if o.offsets == nil {
+		o.offsets = map[int]int{}
+	}
+	o.offsets[id] = o.buf.Len()
+	o.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream writes an indirect stream object. The extra dictionary entries are added to the stream length.
+func (o *objectWriter) stream(id int, dictionary string, data []byte) {
+	if o.offsets == nil {
+		o.offsets = map[int]int{}
+	}
+	o.offsets[id] = o.buf.Len()
+	o.printf("%d 0 obj\n<< %s/Length %d >>\nstream\n", id, dictionary, len(data))
+	o.buf.Write(data)
+	o.printf("\nendstream\nendobj\n")
+}
+
+// num formats a number for the content stream with at most two decimals.
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-" {
+		return "0"
+	}
+	return s
+}
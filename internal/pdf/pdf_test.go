@@ -0,0 +1,170 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// checkXref verifies that every entry of the cross-reference table points to the start of its object.
+func checkXref(t *testing.T, data []byte) {
+	t.Helper()
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if match == nil {
+		t.Fatal("Expected startxref at the end of the document")
+	}
+	xrefOffset, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(data[xrefOffset:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point to the xref table", xrefOffset)
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xrefOffset:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		prefix := strconv.Itoa(i+1) + " 0 obj\n"
+		if !bytes.HasPrefix(data[offset:], []byte(prefix)) {
+			t.Errorf("xref entry of object %d points to %q", i+1, data[offset:offset+10])
+		}
+	}
+}
+
+// pngImage encodes a 2x2 PNG image with a transparent pixel.
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	img.Set(1, 0, color.NRGBA{G: 255, A: 255})
+	img.Set(0, 1, color.NRGBA{B: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteTo(t *testing.T) {
+	doc := New()
+	logo, err := doc.AddImage(pngImage(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first := doc.AddPage()
+	first.Text(50, 800, HelveticaBold, 18, "Offer (draft)")
+	first.Line(50, 790, 545, 790, 1)
+	first.FillRect(50, 700, 100, 20, 0.9)
+	first.DrawImage(logo, 50, 600, 40, 40)
+	second := doc.AddPage()
+	second.TextRight(545, 800, Helvetica, 10, "Page 2")
+
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := buf.Bytes()
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) {
+		t.Error("Expected PDF header")
+	}
+	checkXref(t, data)
+
+	for _, expected := range []string{
+		"/Type /Pages /Kids [6 0 R 8 0 R] /Count 2",
+		"/BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding",
+		"/Subtype /Image /Width 2 /Height 2 /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode",
+		"/XObject << /Im1 5 0 R >>",
+		`(Offer \(draft\)) Tj`,
+		"/Im1 Do",
+	} {
+		if !bytes.Contains(data, []byte(expected)) {
+			t.Errorf("Expected %q in the document", expected)
+		}
+	}
+
+	// Only pages that draw the image reference it
+	if bytes.Count(data, []byte("/XObject <<")) != 1 {
+		t.Error("Expected the image to be referenced by the first page only")
+	}
+}
+
+func TestAddImage(t *testing.T) {
+	// JPEG images are embedded as is
+	var jpegData bytes.Buffer
+	if err := jpeg.Encode(&jpegData, image.NewGray(image.Rect(0, 0, 4, 3)), nil); err != nil {
+		t.Fatalf("failed to encode JPEG: %v", err)
+	}
+	doc := New()
+	img, err := doc.AddImage(jpegData.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if img.Width != 4 || img.Height != 3 || img.filter != "DCTDecode" || img.colorSpace != "DeviceGray" {
+		t.Errorf("Unexpected JPEG image %+v", img)
+	}
+	if !bytes.Equal(img.data, jpegData.Bytes()) {
+		t.Error("Expected JPEG data to be embedded unchanged")
+	}
+
+	// Invalid images are rejected
+	if _, err := doc.AddImage([]byte("not an image")); err == nil {
+		t.Error("Expected error for invalid image")
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	tests := []struct {
+		font     Font
+		text     string
+		expected float64
+	}{
+		{Helvetica, "", 0},
+		{Helvetica, "100", 16.68},
+		{HelveticaBold, "Wi", 12.22},
+		{Helvetica, "Привет", 33.36},
+	}
+
+	for _, tt := range tests {
+		if width := TextWidth(tt.font, 10, tt.text); num(width) != num(tt.expected) {
+			t.Errorf("Expected width of %q to be %v, got %v", tt.text, tt.expected, width)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	lines := WrapText(Helvetica, 10, "one two three four\nfive", TextWidth(Helvetica, 10, "one two three"))
+	expected := []string{"one two three", "four", "five"}
+	if strings.Join(lines, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %v, got %v", expected, lines)
+	}
+
+	// Words longer than the width are kept whole
+	if lines := WrapText(Helvetica, 10, "extraordinarily long", 10); len(lines) != 2 || lines[0] != "extraordinarily" {
+		t.Errorf("Unexpected lines %v", lines)
+	}
+}
+
+func TestEncodeText(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"plain", "plain"},
+		{"(a\\b)", `\(a\\b\)`},
+		{"café – 5 €", "caf\xe9 \x96 5 \x80"},
+		{"Ипотека", "???????"},
+	}
+
+	for _, tt := range tests {
+		if encoded := escapeText(encodeText(tt.text)); encoded != tt.expected {
+			t.Errorf("Expected %q to be encoded as %q, got %q", tt.text, tt.expected, encoded)
+		}
+	}
+}
@@ -1,81 +1,416 @@
 // Package server sets up and runs the HTTP server for the mortgage calculation service.
 //
 // It creates a new HTTP server, initializes the necessary handlers, and manages graceful shutdown.
-// The server handles incoming requests for mortgage calculation and cache management.
-// It also listens for system interrupts to initiate a clean shutdown of the server.
+// The server handles incoming requests for mortgage calculation and cache management, listening on the
+// configured address with the configured timeouts, optionally over TLS with automatic certificate reload.
+// The server does not install signal handlers itself, so that it can be started in tests or embedded in
+// another program; on shutdown it marks the service as not ready before draining connections so that
+// load balancers stop routing traffic to it.
 //
-// Functions:
-//   - New: Initializes the server with provided handlers and configuration, starts it, and manages graceful shutdown.
+// With WithGRPC, the gRPC API is served from the same process on the configured gRPC port, sharing the
+// authenticator (and so the daily quotas), the rate limiter and the TLS certificate with the HTTP server.
+// The gRPC server also serves the standard health service, which reports NOT_SERVING during shutdown.
+//
+// Types and Functions:
+//   - Server: The HTTP server with its Start, Shutdown, Addr, GRPCAddr and Err methods.
+//   - New: Builds the server from the provided handlers and configuration without starting it.
+//   - WithGRPC: Serves the gRPC API next to the HTTP server.
+//   - serve: Serves HTTPS when TLS is configured, plain HTTP otherwise.
+//   - newTLSConfig: Builds the TLS configuration with automatic certificate reload.
+//   - newAuthenticator: Builds the API key authenticator from the configuration.
+//   - newAdminAuthenticator: Builds the authenticator of the administrative endpoints from the admin key.
 //   - initHandlers: Sets up the HTTP request handlers and applies middleware.
 package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
 	"sber/internal/config"
+	"sber/internal/grpcapi"
 	"sber/internal/handlers"
+	"sber/internal/metrics"
 	"sber/internal/middleware"
-	"syscall"
+	mortgagev1 "sber/pkg/api/mortgage/v1"
+	"strconv"
+	"sync"
 	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
-// New initializes the HTTP server with the provided handlers and configuration.
-// It listens for system interrupts to shut down gracefully.
-func New(h *handlers.Handlers, cfg *config.Config) {
+// adminClientID is the client ID attached to the requests authenticated with the admin key.
+const adminClientID = "admin"
+
+// Server is the HTTP server of the mortgage calculation service, optionally accompanied by the gRPC server.
+type Server struct {
+	srv          *http.Server       // The underlying HTTP server
+	h            *handlers.Handlers // The handlers, used to flip readiness
+	grpc         *grpc.Server       // The gRPC server, nil without WithGRPC
+	grpcAddr     string             // The address the gRPC server listens on
+	health       *health.Server     // The gRPC health service, nil without WithGRPC
+	preStopDelay time.Duration      // The time to keep serving after failing the readiness probe on shutdown
+	mu           sync.Mutex         // Protects listener and grpcListener
+	listener     net.Listener       // The listener bound by Start
+	grpcListener net.Listener       // The gRPC listener bound by Start
+	errCh        chan error         // Receives the errors that stopped serving, if any
+}
+
+// Option configures optional parts of the Server.
+type Option func(o *options)
+
+// options holds the values set by the options of New.
+type options struct {
+	grpcService mortgagev1.MortgageServiceServer // The gRPC API served next to HTTP, if any
+}
+
+// WithGRPC makes the server serve the gRPC API implemented by svc on the configured gRPC port.
+func WithGRPC(svc mortgagev1.MortgageServiceServer) Option {
+	return func(o *options) {
+		o.grpcService = svc
+	}
+}
+
+// New builds the HTTP server with the provided handlers and configuration. It returns an error if the
+// authentication or TLS settings cannot be initialized. The server is not started until Start is called.
+func New(h *handlers.Handlers, cfg *config.Config, opts ...Option) (*Server, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Build the API key authenticator from the configuration
+	auth, err := newAuthenticator(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize authentication: %w", err)
+	}
+
+	// Build the authenticator of the administrative endpoints from the configuration
+	admin, err := newAdminAuthenticator(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize admin authentication: %w", err)
+	}
+
+	// Build the rate limiter from the configuration
+	var limiter *middleware.RateLimiter
+	if cfg.RateLimit.Enabled {
+		limiter = middleware.NewRateLimiter(cfg.RateLimit)
+	}
+
+	// Build the idempotency key store from the configuration
+	idempotency := middleware.NewIdempotency(cfg.Idempotency)
+
+	// Build the CORS middleware from the configuration
+	var cors *middleware.CORS
+	if cfg.CORS.Enabled {
+		cors = middleware.NewCORS(cfg.CORS)
+	}
+
 	// Create a new HTTP server with the specified configuration and timeouts
 	srv := &http.Server{
-		Addr:              fmt.Sprintf(":%d", cfg.Server.Port), // Set the port for the server
-		Handler:           initHandlers(h),                     // Initialize handlers
-		ReadHeaderTimeout: 5 * time.Second,                     // Timeout for reading headers
-		WriteTimeout:      10 * time.Second,                    // Timeout for writing the response
-		ReadTimeout:       10 * time.Second,                    // Timeout for reading the request body
+		Addr:              net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)), // Set the address for the server
+		Handler:           initHandlers(h, auth, admin, limiter, idempotency, cors),         // Initialize handlers
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,                                     // Timeout for reading headers
+		WriteTimeout:      cfg.Server.WriteTimeout,                                          // Timeout for writing the response
+		ReadTimeout:       cfg.Server.ReadTimeout,                                           // Timeout for reading the request body
+	}
+
+	// Serve HTTPS with a certificate that is reloaded when its files change
+	srv.TLSConfig, err = newTLSConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize TLS: %w", err)
 	}
 
-	// Channel to receive signals for stopping the server (e.g., SIGTERM or SIGINT)
-	stopChan := make(chan os.Signal, 1)
-	signal.Notify(stopChan, os.Interrupt, syscall.SIGTERM)
+	s := &Server{srv: srv, h: h, preStopDelay: cfg.Server.PreStopDelay, errCh: make(chan error, 2)}
 
-	// Start the server in a goroutine for asynchronous request handling
+	// Serve the gRPC API with the same authenticator, rate limiter and certificate
+	if o.grpcService != nil {
+		var grpcOpts []grpc.ServerOption
+		if srv.TLSConfig != nil {
+			grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(srv.TLSConfig)))
+		}
+		s.grpc = grpcapi.NewServer(o.grpcService, auth, limiter, grpcOpts...)
+		s.grpcAddr = net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.GRPC.Port))
+
+		// The health service reports SERVING until shutdown
+		s.health = health.NewServer()
+		healthpb.RegisterHealthServer(s.grpc, s.health)
+	}
+
+	return s, nil
+}
+
+// Start binds the listen address and starts serving requests in the background. It returns once the
+// server accepts connections, or with an error if the address cannot be bound. Errors that stop serving
+// later are delivered on Err. The context only bounds binding the address.
+func (s *Server) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener != nil {
+		return errors.New("server already started")
+	}
+
+	// Bind the addresses synchronously, so that the caller knows the servers are reachable
+	var lc net.ListenConfig
+	listener, err := lc.Listen(ctx, "tcp", s.srv.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.srv.Addr, err)
+	}
+	var grpcListener net.Listener
+	if s.grpc != nil {
+		grpcListener, err = lc.Listen(ctx, "tcp", s.grpcAddr)
+		if err != nil {
+			listener.Close()
+			return fmt.Errorf("failed to listen on %s: %w", s.grpcAddr, err)
+		}
+	}
+	s.listener, s.grpcListener = listener, grpcListener
+
+	// Read the TLS configuration before serving, which sets up HTTP/2 on it
+	tlsEnabled := s.srv.TLSConfig != nil
+
+	// Start the servers in goroutines for asynchronous request handling
+	var wg sync.WaitGroup
+	wg.Add(1)
 	go func() {
-		log.Printf("Server started on port %d", cfg.Server.Port)
-		// Start the server, log and terminate if an error occurs (except for server closure)
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("Failed to start server with error: %v", err)
+		defer wg.Done()
+		// Report the error that stopped the server (except for server closure)
+		if err := serve(s.srv, listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.errCh <- err
 		}
 	}()
+	if grpcListener != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.grpc.Serve(grpcListener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+				s.errCh <- fmt.Errorf("grpc: %w", err)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(s.errCh)
+	}()
+
+	// Mark the service as ready to receive traffic
+	s.h.SetReady(true)
+	slog.Info("server started", "addr", listener.Addr().String(), "tls", tlsEnabled)
+	if grpcListener != nil {
+		slog.Info("grpc server started", "addr", grpcListener.Addr().String())
+	}
+	return nil
+}
+
+// Shutdown gracefully stops the server. It fails the readiness probe first and keeps serving for the configured
+// pre-stop delay, so that load balancers notice it, then stops accepting new connections and waits for in-flight
+// requests to finish until ctx is done. The delay counts against ctx: when ctx is done first, the connections
+// are still closed and the error of ctx is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	slog.Info("shutting down server")
+
+	// Fail the readiness probe and the gRPC health checks before draining connections
+	s.h.SetReady(false)
+	if s.health != nil {
+		s.health.Shutdown()
+	}
 
-	// Wait for a signal to stop the server
-	<-stopChan
-	log.Println("Shutting down server")
+	// Keep serving until the load balancers have stopped routing new requests here
+	var interrupted error
+	if s.preStopDelay > 0 {
+		delay := time.NewTimer(s.preStopDelay)
+		select {
+		case <-delay.C:
+		case <-ctx.Done():
+			delay.Stop()
+			interrupted = fmt.Errorf("pre-stop delay interrupted: %w", ctx.Err())
+		}
+	}
 
-	// Create a context with a timeout for shutting down the server
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// End the event streams, which would otherwise keep their connections open until the context is done
+	s.h.CloseStreams()
 
 	// Attempt to gracefully shut down the server
-	if err := srv.Shutdown(ctx); err != nil {
-		// Log the error and terminate the program
-		log.Println("Server shutdown error:", err)
-		return // Return to indicate failure
+	if err := s.srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("server shutdown error: %w", err)
+	}
+
+	// Let in-flight RPCs finish, and cancel them when the context is done
+	if s.grpc != nil {
+		stopped := make(chan struct{})
+		go func() {
+			s.grpc.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			s.grpc.Stop()
+			<-stopped
+			return fmt.Errorf("grpc server shutdown error: %w", ctx.Err())
+		}
+	}
+	if interrupted != nil {
+		return interrupted
 	}
+
 	// Log successful server stop
-	log.Println("Server stopped")
+	slog.Info("server stopped")
+	return nil
+}
+
+// Addr returns the address the server listens on, which includes the actual port when port 0 was configured.
+// It returns nil before Start.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// GRPCAddr returns the address the gRPC server listens on, which includes the actual port when port 0 was
+// configured. It returns nil before Start and without WithGRPC.
+func (s *Server) GRPCAddr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.grpcListener == nil {
+		return nil
+	}
+	return s.grpcListener.Addr()
+}
+
+// Err returns a channel that receives the errors that stopped serving, if any, and is closed when
+// the servers stop.
+func (s *Server) Err() <-chan error {
+	return s.errCh
+}
+
+// serve serves HTTPS on the listener if the server has a TLS configuration, and plain HTTP otherwise.
+func serve(srv *http.Server, listener net.Listener) error {
+	if srv.TLSConfig != nil {
+		// The certificate is provided by TLSConfig.GetCertificate
+		return srv.ServeTLS(listener, "", "")
+	}
+	return srv.Serve(listener)
+}
+
+// newTLSConfig creates the TLS configuration serving the configured certificate, reloading it when its files
+// change. It returns nil when TLS is not configured.
+func newTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	if !cfg.Server.TLS.Enabled() {
+		return nil, nil
+	}
+
+	certs, err := newCertReloader(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}, nil
+}
+
+// newAdminAuthenticator creates the authenticator of the administrative endpoints from the configured admin key.
+// It returns nil when no admin key is configured, in which case the administrative endpoints are not served.
+func newAdminAuthenticator(cfg *config.Config) (*middleware.Authenticator, error) {
+	if cfg.Admin.KeyHash == "" {
+		return nil, nil
+	}
+
+	return middleware.NewAuthenticator([]config.APIKey{{ClientID: adminClientID, KeyHash: cfg.Admin.KeyHash}})
+}
+
+// newAuthenticator creates the API key authenticator from the configured keys and keys file.
+// It returns nil when authentication is disabled.
+func newAuthenticator(cfg *config.Config) (*middleware.Authenticator, error) {
+	if !cfg.Auth.Enabled {
+		return nil, nil
+	}
+
+	keys := cfg.Auth.Keys
+	if cfg.Auth.KeysFile != "" {
+		fileKeys, err := config.LoadKeysFile(cfg.Auth.KeysFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, fileKeys...)
+	}
+
+	return middleware.NewAuthenticator(keys)
 }
 
 // initHandlers initializes the HTTP handlers for the service and applies middleware.
-func initHandlers(h *handlers.Handlers) http.Handler {
+// The API routes require authentication and are rate limited when an authenticator or a limiter is given,
+// and /execute replays the responses to repeated idempotency keys,
+// while the probes and metrics stay open for the infrastructure. The administrative routes are only served when
+// an admin authenticator is given, and require the admin key. When CORS is given, preflight requests
+// are answered before they reach the routes.
+func initHandlers(h *handlers.Handlers, auth, admin *middleware.Authenticator, limiter *middleware.RateLimiter,
+	idempotency *middleware.Idempotency, cors *middleware.CORS) http.Handler {
 	// Create a new router to handle incoming requests
 	r := http.NewServeMux()
 
+	// Protect the API routes with API key authentication and rate limiting if they are enabled.
+	// Authentication runs first, so that authenticated clients are limited by their identity, and the daily quota
+	// is counted last, so that the requests rejected for their rate do not use it up.
+	api := func(handler http.HandlerFunc) http.Handler {
+		var protected http.Handler = handler
+		if auth != nil {
+			protected = auth.Quota(protected)
+		}
+		if limiter != nil {
+			protected = limiter.Middleware(protected)
+		}
+		if auth != nil {
+			protected = auth.Authenticate(protected)
+		}
+		return protected
+	}
+
 	// Register handlers for specific routes
-	r.HandleFunc("/execute", h.Execute) // Handler for the /execute route
-	r.HandleFunc("/cache", h.Cache)     // Handler for the /cache route
+	execute := idempotency.Middleware(http.HandlerFunc(h.Execute)) // Retried safely with Idempotency-Key
+	r.Handle("/execute", api(execute.ServeHTTP))                   // Handler for the /execute route
+	r.Handle("/cache", api(h.Cache))                               // Handler for the /cache route
+	r.Handle("/cache/history", api(h.History))                     // Recorded calculation requests
+	r.Handle("/cache/stream", api(h.Stream))                       // New calculations as server-sent events
+	r.Handle("/stats", api(h.Stats))                               // Statistics of the cached calculations
+	r.Handle("/cache/export", api(h.ExportCache))                  // Export of the cached data as CSV or XLSX
+	r.Handle("/cache/{id}/export", api(h.ExportCalculation))       // Export of a calculation with its schedule
+	r.Handle("/cache/{id}/offer", api(h.Offer))                    // Printable PDF offer of a calculation
+	r.Handle("/cache/{id}/calendar", api(h.Calendar))              // Payment dates of a calculation as iCalendar
+	r.Handle("/metrics", metrics.Handler())                        // Prometheus metrics in text exposition format
+	r.HandleFunc("/healthz", h.Healthz)                            // Liveness probe
+	r.HandleFunc("/readyz", h.Readyz)                              // Readiness probe
+
+	// Register the administrative routes, protected by the admin key
+	if admin != nil {
+		r.Handle("/admin/webhooks", admin.Middleware(http.HandlerFunc(h.Webhooks)))     // Webhook endpoints
+		r.Handle("/admin/webhooks/{id}", admin.Middleware(http.HandlerFunc(h.Webhook))) // Removal of a webhook endpoint
+		r.Handle("/admin/snapshot", admin.Middleware(http.HandlerFunc(h.Snapshot)))     // Download and restore of snapshots
+	}
+
+	// Apply middleware to recover from panics and collect metrics
+	handler := middleware.RecoveryMiddleware(middleware.MetricsMiddleware(r))
+
+	// Answer CORS preflight requests before the method checks of the handlers
+	if cors != nil {
+		handler = cors.Middleware(handler)
+	}
 
-	// Apply middleware to log request information
-	return middleware.RequestInfoMiddleware(r)
+	// Apply middleware to assign request IDs and log request information
+	return middleware.RequestIDMiddleware(middleware.RequestInfoMiddleware(handler))
 }
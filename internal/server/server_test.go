@@ -0,0 +1,498 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sber/internal/cache"
+	"sber/internal/config"
+	"sber/internal/grpcapi"
+	"sber/internal/handlers"
+	mortgagev1 "sber/pkg/api/mortgage/v1"
+	"sber/pkg/models"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// startTestServer boots the real server on a random local port and shuts it down when the test ends.
+func startTestServer(t *testing.T, cfg *config.Config) (*Server, *handlers.Handlers) {
+	t.Helper()
+
+	h := handlers.NewHandlers(cache.New(), handlers.WithConfig(config.NewCurrent(cfg)))
+	srv, err := New(h, cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	})
+
+	return srv, h
+}
+
+// testConfig returns the default configuration listening on a random local port.
+func testConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Server.Port = 0
+	cfg.GRPC.Port = 0
+	return cfg
+}
+
+// TestServerLifecycle verifies that the real server serves calculations, the cache and the probes,
+// and that readiness fails after shutdown.
+func TestServerLifecycle(t *testing.T) {
+	srv, h := startTestServer(t, testConfig())
+	baseURL := "http://" + srv.Addr().String()
+
+	// Keep-alive connections left over by the requests would delay the shutdown
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	// The server is ready once started
+	resp, err := client.Get(baseURL + "/readyz")
+	if err != nil {
+		t.Fatalf("readyz request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected readyz status 200, got %d", resp.StatusCode)
+	}
+
+	// Perform a calculation
+	body, _ := json.Marshal(models.ExecuteReqeust{
+		ObjectCost:     5000000,
+		InitialPayment: 1000000,
+		Months:         240,
+		Program:        models.Program{Salary: true},
+	})
+	execute := func() (*http.Response, models.ExecuteResponse) {
+		req, _ := http.NewRequest(http.MethodPost, baseURL+"/execute", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "lifecycle-1")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("execute request failed: %v", err)
+		}
+		var result models.ExecuteResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected execute status 200, got %d", resp.StatusCode)
+		}
+		return resp, result
+	}
+	resp, result := execute()
+	if result.Result.Aggregates.MonthlyPayment != 33458 {
+		t.Errorf("Expected monthly payment 33458, got %d", result.Result.Aggregates.MonthlyPayment)
+	}
+	if resp.Header.Get("X-Request-ID") == "" {
+		t.Error("Expected X-Request-ID header in response")
+	}
+
+	// A retry with the same idempotency key replays the response instead of storing the calculation again
+	replayed, _ := execute()
+	if replayed.Header.Get("Idempotent-Replayed") != "true" {
+		t.Error("Expected the retry to be replayed")
+	}
+
+	// The calculation is stored in the cache once
+	resp, err = client.Get(baseURL + "/cache")
+	if err != nil {
+		t.Fatalf("cache request failed: %v", err)
+	}
+	var cached []models.CacheStorageFormat
+	json.NewDecoder(resp.Body).Decode(&cached)
+	resp.Body.Close()
+	if len(cached) != 1 {
+		t.Errorf("Expected 1 cached entry, got %d", len(cached))
+	}
+
+	// The calculation can be exported by its ID
+	resp, err = client.Get(baseURL + "/cache/0/export?format=xlsx")
+	if err != nil {
+		t.Fatalf("export request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Type"), "spreadsheetml") {
+		t.Errorf("Expected XLSX export, got status %d and content type %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// The request is visible in the metrics
+	resp, err = client.Get(baseURL + "/metrics")
+	if err != nil {
+		t.Fatalf("metrics request failed: %v", err)
+	}
+	metricsBody, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(metricsBody), `mortgage_http_requests_total{route="/execute",code="200"}`) {
+		t.Errorf("Expected /execute requests in metrics, got:\n%s", metricsBody)
+	}
+
+	// Shut down and verify that readiness was flipped and the server no longer accepts connections
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+	if _, err := client.Get(baseURL + "/healthz"); err == nil {
+		t.Error("Expected connection error after shutdown")
+	}
+
+	w := httptest.NewRecorder()
+	h.Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected readiness to fail after shutdown, got %d", w.Code)
+	}
+	if err, ok := <-srv.Err(); ok {
+		t.Errorf("Expected no serve error, got %v", err)
+	}
+}
+
+// TestServerStartTwice verifies that a server cannot be started twice.
+func TestServerStartTwice(t *testing.T) {
+	srv, _ := startTestServer(t, testConfig())
+
+	if err := srv.Start(context.Background()); err == nil {
+		t.Error("Expected error when starting the server twice")
+	}
+}
+
+// TestServerAddressInUse verifies that a bind failure is returned from Start.
+func TestServerAddressInUse(t *testing.T) {
+	first, _ := startTestServer(t, testConfig())
+
+	cfg := testConfig()
+	cfg.Server.Port = first.Addr().(*net.TCPAddr).Port
+
+	srv, err := New(handlers.NewHandlers(cache.New()), cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if err := srv.Start(context.Background()); err == nil {
+		t.Error("Expected error when the address is in use")
+	}
+}
+
+// TestServerTLS verifies that the server serves HTTPS with the configured certificate.
+func TestServerTLS(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig()
+	cfg.Server.TLS.CertFile = filepath.Join(dir, "cert.pem")
+	cfg.Server.TLS.KeyFile = filepath.Join(dir, "key.pem")
+	writeSelfSignedCert(t, cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile, "localhost")
+
+	srv, _ := startTestServer(t, cfg)
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // self-signed test certificate
+	}}
+	resp, err := client.Get("https://" + srv.Addr().String() + "/healthz")
+	if err != nil {
+		t.Fatalf("healthz request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected healthz status 200, got %d", resp.StatusCode)
+	}
+	if resp.TLS == nil {
+		t.Error("Expected TLS connection")
+	}
+}
+
+// TestNewInvalidTLS verifies that missing certificate files are reported by New.
+func TestNewInvalidTLS(t *testing.T) {
+	cfg := testConfig()
+	cfg.Server.TLS.CertFile = filepath.Join(t.TempDir(), "cert.pem")
+	cfg.Server.TLS.KeyFile = filepath.Join(t.TempDir(), "key.pem")
+
+	if _, err := New(handlers.NewHandlers(cache.New()), cfg); err == nil {
+		t.Error("Expected error for missing certificate files")
+	}
+}
+
+// TestServerGRPC verifies that the gRPC API is served on its own port from the storage of the HTTP API,
+// and that its health service fails during shutdown.
+func TestServerGRPC(t *testing.T) {
+	cfg := testConfig()
+	current := config.NewCurrent(cfg)
+	store := cache.New()
+	h := handlers.NewHandlers(store, handlers.WithConfig(current))
+	srv, err := New(h, cfg, WithGRPC(grpcapi.NewService(store, current)))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if err = srv.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+
+	// The gRPC API listens on its own port
+	if srv.GRPCAddr() == nil || srv.GRPCAddr().String() == srv.Addr().String() {
+		t.Fatalf("Expected a separate gRPC address, got %v", srv.GRPCAddr())
+	}
+	conn, err := grpc.NewClient(srv.GRPCAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	// The health service reports SERVING
+	healthClient := healthpb.NewHealthClient(conn)
+	check, err := healthClient.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil || check.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("Expected SERVING, got %v, %v", check, err)
+	}
+
+	// A calculation made over gRPC is listed by the HTTP API
+	client := mortgagev1.NewMortgageServiceClient(conn)
+	if _, err = client.Calculate(context.Background(), &mortgagev1.CalculateRequest{
+		ObjectCost: 5000000, InitialPayment: 1000000, Months: 240, Program: &mortgagev1.Program{Salary: true},
+	}); err != nil {
+		t.Fatalf("calculate request failed: %v", err)
+	}
+	resp, err := http.Get("http://" + srv.Addr().String() + "/cache")
+	if err != nil {
+		t.Fatalf("cache request failed: %v", err)
+	}
+	var cached []models.CacheStorageFormat
+	json.NewDecoder(resp.Body).Decode(&cached)
+	resp.Body.Close()
+	if len(cached) != 1 || cached[0].Aggregates.MonthlyPayment != 33458 {
+		t.Errorf("Expected the gRPC calculation in the cache, got %+v", cached)
+	}
+
+	// Watch the health status, then shut down
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	watch, err := healthClient.Watch(watchCtx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("watch request failed: %v", err)
+	}
+	if update, recvErr := watch.Recv(); recvErr != nil || update.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("Expected SERVING, got %v, %v", update, recvErr)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- srv.Shutdown(ctx) }()
+
+	// The watcher learns that the service is going away, and closes its stream to let the shutdown finish
+	if update, recvErr := watch.Recv(); recvErr != nil || update.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expected NOT_SERVING during shutdown, got %v, %v", update, recvErr)
+	}
+	stopWatch()
+	if err = <-shutdown; err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+	if err, ok := <-srv.Err(); ok {
+		t.Errorf("Expected no serve error, got %v", err)
+	}
+}
+
+// TestServerGRPCAddressInUse verifies that a gRPC bind failure is returned from Start and releases the HTTP port.
+func TestServerGRPCAddressInUse(t *testing.T) {
+	first, _ := startTestServer(t, testConfig())
+
+	cfg := testConfig()
+	cfg.GRPC.Port = first.Addr().(*net.TCPAddr).Port
+	store := cache.New()
+	srv, err := New(handlers.NewHandlers(store), cfg, WithGRPC(grpcapi.NewService(store, config.NewCurrent(cfg))))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if err := srv.Start(context.Background()); err == nil {
+		t.Error("Expected error when the gRPC address is in use")
+	}
+	if srv.Addr() != nil {
+		t.Error("Expected no HTTP listener after a failed start")
+	}
+}
+
+// TestServerShutdownEndsStreams verifies that open event streams outlive the write timeout and do not delay
+// the shutdown.
+func TestServerShutdownEndsStreams(t *testing.T) {
+	cfg := testConfig()
+	cfg.Server.WriteTimeout = 100 * time.Millisecond
+	cfg.Stream.HeartbeatInterval = 50 * time.Millisecond
+	srv, _ := startTestServer(t, cfg)
+
+	resp, err := http.Get("http://" + srv.Addr().String() + "/cache/stream")
+	if err != nil {
+		t.Fatalf("stream request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Heartbeats are still received after the write timeout
+	time.Sleep(2 * cfg.Server.WriteTimeout)
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != ": keep-alive\n" {
+		t.Fatalf("Expected a heartbeat after the write timeout, got %q (%v)", line, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the shutdown not to wait for the stream, took %v", elapsed)
+	}
+}
+
+// TestServerPreStopDelay verifies that on shutdown the server keeps serving requests for the pre-stop delay
+// while failing the readiness probe, and that the delay ends early when the context is done.
+func TestServerPreStopDelay(t *testing.T) {
+	cfg := testConfig()
+	cfg.Server.PreStopDelay = 300 * time.Millisecond
+	srv, _ := startTestServer(t, cfg)
+	addr := "http://" + srv.Addr().String()
+
+	// Keep-alive connections left idle by the probes would delay the shutdown
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func(path string) int {
+		t.Helper()
+		resp, err := client.Get(addr + path)
+		if err != nil {
+			t.Fatalf("%s request failed during the pre-stop delay: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.PreStopDelay+10*time.Second)
+	defer cancel()
+	start := time.Now()
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- srv.Shutdown(ctx) }()
+
+	// Wait for the readiness probe to fail, the server still answers it during the delay
+	deadline := time.Now().Add(cfg.Server.PreStopDelay)
+	for code := get("/readyz"); code != http.StatusServiceUnavailable; code = get("/readyz") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the readiness probe to fail, got %d", code)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Requests are still served
+	if code := get("/healthz"); code != http.StatusOK {
+		t.Errorf("Expected status 200 during the pre-stop delay, got %d", code)
+	}
+
+	if err := <-shutdown; err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < cfg.Server.PreStopDelay {
+		t.Errorf("Expected the shutdown to wait for the pre-stop delay, took %v", elapsed)
+	}
+}
+
+// TestServerPreStopDelayInterrupted verifies that a context that is done ends the pre-stop delay early, still
+// stops the server and is reported.
+func TestServerPreStopDelayInterrupted(t *testing.T) {
+	cfg := testConfig()
+	cfg.Server.PreStopDelay = time.Hour
+	srv, err := New(handlers.NewHandlers(cache.New()), cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if err = srv.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		srv.Shutdown(ctx)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err = srv.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the shutdown to stop waiting when the context is done, took %v", elapsed)
+	}
+	if _, err = net.Dial("tcp", srv.Addr().String()); err == nil {
+		t.Error("Expected the server to stop listening")
+	}
+}
+
+// TestServerAdminRoutes verifies that the administrative routes require the admin key, and are not served
+// without one.
+func TestServerAdminRoutes(t *testing.T) {
+	sum := sha256.Sum256([]byte("admin-key"))
+	cfg := testConfig()
+	cfg.Admin.KeyHash = hex.EncodeToString(sum[:])
+	srv, _ := startTestServer(t, cfg)
+	withoutAdmin, _ := startTestServer(t, testConfig())
+
+	tests := []struct {
+		name         string
+		srv          *Server
+		path         string
+		key          string
+		expectedCode int
+	}{
+		{"Admin key", srv, "/admin/webhooks", "admin-key", http.StatusNotFound}, // Answered by the handler, webhooks are disabled
+		{"Missing key", srv, "/admin/webhooks", "", http.StatusUnauthorized},
+		{"Wrong key", srv, "/admin/webhooks", "client-key", http.StatusUnauthorized},
+		{"No admin key configured", withoutAdmin, "/admin/webhooks", "admin-key", http.StatusNotFound},
+		{"Snapshot", srv, "/admin/snapshot", "admin-key", http.StatusOK},
+		{"Snapshot without key", srv, "/admin/snapshot", "", http.StatusUnauthorized},
+		{"Snapshot without admin key configured", withoutAdmin, "/admin/snapshot", "admin-key", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "http://"+tt.srv.Addr().String()+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.key != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedCode, resp.StatusCode, body)
+			}
+		})
+	}
+}
+
+func TestNewInvalidAdminKey(t *testing.T) {
+	cfg := testConfig()
+	cfg.Admin.KeyHash = "not-a-hash"
+
+	if _, err := New(handlers.NewHandlers(cache.New()), cfg); err == nil {
+		t.Error("Expected error for an invalid admin key hash")
+	}
+}
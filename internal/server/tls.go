@@ -0,0 +1,106 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// certCheckInterval limits how often the certificate files are checked for changes.
+const certCheckInterval = time.Second
+
+// certReloader serves a TLS certificate loaded from files and reloads it when the files change,
+// so that certificates can be rotated without restarting the server.
+type certReloader struct {
+	certFile  string           // Path to the PEM-encoded certificate chain
+	keyFile   string           // Path to the PEM-encoded private key
+	mu        sync.Mutex       // Protects the fields below
+	cert      *tls.Certificate // The certificate currently served
+	certMod   time.Time        // Modification time of the certificate file when it was loaded
+	keyMod    time.Time        // Modification time of the key file when it was loaded
+	lastCheck time.Time        // Time the files were last checked for changes
+	now       func() time.Time // Clock used to throttle checks, replaceable in tests
+}
+
+// newCertReloader loads the certificate and key and returns a reloader serving them.
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, now: time.Now}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate, reloading it first if the files have changed.
+// It is used as tls.Config.GetCertificate. If reloading fails, the previous certificate keeps being served.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Check the files at most once per interval, handshakes can be frequent
+	if now := r.now(); now.Sub(r.lastCheck) >= certCheckInterval {
+		r.lastCheck = now
+		if r.changed() {
+			if err := r.reloadLocked(); err != nil {
+				slog.Error("failed to reload TLS certificate, keeping the previous one", "error", err)
+			} else {
+				slog.Info("TLS certificate reloaded", "cert_file", r.certFile)
+			}
+		}
+	}
+
+	return r.cert, nil
+}
+
+// reload loads the certificate and key from the files.
+func (r *certReloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.reloadLocked()
+}
+
+// reloadLocked loads the certificate and key from the files. The caller must hold r.mu.
+func (r *certReloader) reloadLocked() error {
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	r.cert = &cert
+	r.certMod = certMod
+	r.keyMod = keyMod
+	return nil
+}
+
+// changed reports whether either file has a different modification time than when it was loaded.
+// The caller must hold r.mu.
+func (r *certReloader) changed() bool {
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		// A missing file is usually a rotation in progress, check again later
+		return false
+	}
+	return !certMod.Equal(r.certMod) || !keyMod.Equal(r.keyMod)
+}
+
+// modTimes returns the modification times of the certificate and key files.
+func (r *certReloader) modTimes() (certMod, keyMod time.Time, err error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to stat certificate file: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to stat key file: %w", err)
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
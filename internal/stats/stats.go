@@ -0,0 +1,109 @@
+// Package stats computes statistics of the stored mortgage calculations for product analysis: how often each
+// loan program is chosen and what typical loans look like.
+//
+// Types and Functions:
+//   - LoanSumBounds: The lower bounds of the loan amount ranges of the histogram.
+//   - Compute: Computes the statistics of the given calculations.
+package stats
+
+import (
+	"math"
+	"sber/pkg/models"
+	"sort"
+)
+
+// LoanSumBounds are the lower bounds of the loan amount ranges of the histogram. Each range ends at the next
+// bound, and the last one has no upper bound.
+var LoanSumBounds = []int32{0, 1_000_000, 3_000_000, 5_000_000, 10_000_000, 20_000_000}
+
+// Compute computes the statistics of the given calculations: the number of calculations per program, the
+// distributions of the loan amount, the term, the initial payment share and the monthly payment, and the
+// histogram of the loan amounts over LoanSumBounds.
+func Compute(entries []models.CacheStorageFormat) models.Stats {
+	stats := models.Stats{
+		Count:            len(entries),
+		LoanSumHistogram: make([]models.HistogramBucket, len(LoanSumBounds)),
+	}
+	for i, from := range LoanSumBounds {
+		stats.LoanSumHistogram[i].From = from
+		if i+1 < len(LoanSumBounds) {
+			stats.LoanSumHistogram[i].To = LoanSumBounds[i+1]
+		}
+	}
+
+	// Collect the values of every calculation
+	loanSums := make([]float64, 0, len(entries))
+	months := make([]float64, 0, len(entries))
+	shares := make([]float64, 0, len(entries))
+	payments := make([]float64, 0, len(entries))
+	for _, entry := range entries {
+		switch {
+		case entry.Program.Base:
+			stats.Programs.Base++
+		case entry.Program.Military:
+			stats.Programs.Military++
+		case entry.Program.Salary:
+			stats.Programs.Salary++
+		}
+
+		loanSums = append(loanSums, float64(entry.Aggregates.LoanSum))
+		months = append(months, float64(entry.Params.Months))
+		payments = append(payments, float64(entry.Aggregates.MonthlyPayment))
+		// The share is undefined for free objects, which are left out of its distribution
+		if entry.Params.ObjectCost > 0 {
+			shares = append(shares, float64(entry.Params.InitialPayment)/float64(entry.Params.ObjectCost)*100)
+		}
+
+		if i := bucket(entry.Aggregates.LoanSum); i >= 0 {
+			stats.LoanSumHistogram[i].Count++
+		}
+	}
+
+	stats.LoanSum = distribution(loanSums)
+	stats.Months = distribution(months)
+	stats.InitialPaymentShare = distribution(shares)
+	stats.MonthlyPayment = distribution(payments)
+	return stats
+}
+
+// bucket returns the index of the histogram range containing the loan amount, or -1 if it is below all bounds.
+func bucket(loanSum int32) int {
+	return sort.Search(len(LoanSumBounds), func(i int) bool { return LoanSumBounds[i] > loanSum }) - 1
+}
+
+// distribution describes the distribution of the values. The values are sorted in place.
+func distribution(values []float64) models.Distribution {
+	if len(values) == 0 {
+		return models.Distribution{}
+	}
+	sort.Float64s(values)
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return models.Distribution{
+		Min:    round(values[0]),
+		Mean:   round(sum / float64(len(values))),
+		Median: round(percentile(values, 50)),
+		P25:    round(percentile(values, 25)),
+		P75:    round(percentile(values, 75)),
+		P90:    round(percentile(values, 90)),
+		P95:    round(percentile(values, 95)),
+		Max:    round(values[len(values)-1]),
+	}
+}
+
+// percentile returns the p-th percentile of the sorted values, interpolating linearly between the closest
+// ranks, as spreadsheet applications do.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// round rounds the value to two decimal places.
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,116 @@
+package stats
+
+import (
+	"sber/pkg/models"
+	"testing"
+)
+
+// entry builds a stored calculation with the given parameters.
+func entry(program models.Program, cost, initial, months, loanSum, payment int32) models.CacheStorageFormat {
+	return models.CacheStorageFormat{
+		Params:     models.Params{ObjectCost: cost, InitialPayment: initial, Months: months},
+		Program:    program,
+		Aggregates: models.Aggregates{LoanSum: loanSum, MonthlyPayment: payment},
+	}
+}
+
+func TestCompute(t *testing.T) {
+	base := models.Program{Base: true}
+	salary := models.Program{Salary: true}
+	entries := []models.CacheStorageFormat{
+		entry(base, 1_000_000, 200_000, 12, 800_000, 70_000),
+		entry(base, 5_000_000, 1_000_000, 240, 4_000_000, 38_000),
+		entry(salary, 5_000_000, 2_500_000, 120, 2_500_000, 30_000),
+		entry(salary, 20_000_000, 4_000_000, 360, 16_000_000, 117_000),
+		entry(salary, 40_000_000, 10_000_000, 360, 30_000_000, 220_000),
+	}
+
+	stats := Compute(entries)
+
+	if stats.Count != 5 {
+		t.Errorf("Expected count 5, got %d", stats.Count)
+	}
+	if stats.Programs != (models.ProgramCounts{Base: 2, Salary: 3}) {
+		t.Errorf("Unexpected program counts %+v", stats.Programs)
+	}
+
+	tests := []struct {
+		name     string
+		got      models.Distribution
+		expected models.Distribution
+	}{
+		{"Loan sum", stats.LoanSum, models.Distribution{
+			Min: 800_000, Mean: 10_660_000, Median: 4_000_000, P25: 2_500_000, P75: 16_000_000,
+			P90: 24_400_000, P95: 27_200_000, Max: 30_000_000,
+		}},
+		{"Months", stats.Months, models.Distribution{
+			Min: 12, Mean: 218.4, Median: 240, P25: 120, P75: 360, P90: 360, P95: 360, Max: 360,
+		}},
+		{"Initial payment share", stats.InitialPaymentShare, models.Distribution{
+			Min: 20, Mean: 27, Median: 20, P25: 20, P75: 25, P90: 40, P95: 45, Max: 50,
+		}},
+		{"Monthly payment", stats.MonthlyPayment, models.Distribution{
+			Min: 30_000, Mean: 95_000, Median: 70_000, P25: 38_000, P75: 117_000,
+			P90: 178_800, P95: 199_400, Max: 220_000,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, tt.got)
+			}
+		})
+	}
+
+	expectedHistogram := []models.HistogramBucket{
+		{From: 0, To: 1_000_000, Count: 1},
+		{From: 1_000_000, To: 3_000_000, Count: 1},
+		{From: 3_000_000, To: 5_000_000, Count: 1},
+		{From: 5_000_000, To: 10_000_000, Count: 0},
+		{From: 10_000_000, To: 20_000_000, Count: 1},
+		{From: 20_000_000, Count: 1},
+	}
+	if len(stats.LoanSumHistogram) != len(expectedHistogram) {
+		t.Fatalf("Expected %d buckets, got %d", len(expectedHistogram), len(stats.LoanSumHistogram))
+	}
+	for i, expected := range expectedHistogram {
+		if stats.LoanSumHistogram[i] != expected {
+			t.Errorf("Bucket %d: expected %+v, got %+v", i, expected, stats.LoanSumHistogram[i])
+		}
+	}
+}
+
+func TestComputeEmpty(t *testing.T) {
+	stats := Compute(nil)
+
+	if stats.Count != 0 || stats.LoanSum != (models.Distribution{}) || stats.InitialPaymentShare != (models.Distribution{}) {
+		t.Errorf("Expected zero statistics, got %+v", stats)
+	}
+	if len(stats.LoanSumHistogram) != len(LoanSumBounds) {
+		t.Errorf("Expected %d empty buckets, got %d", len(LoanSumBounds), len(stats.LoanSumHistogram))
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		p        float64
+		expected float64
+	}{
+		{"Single value", []float64{7}, 90, 7},
+		{"Median of even count", []float64{1, 2, 3, 4}, 50, 2.5},
+		{"Exact rank", []float64{10, 20, 30}, 50, 20},
+		{"Interpolated", []float64{10, 20, 30, 40, 50}, 90, 46},
+		{"Maximum", []float64{10, 20, 30}, 100, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.values, tt.p); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// Headers of the requests sent to the webhook endpoints.
+const (
+	// IDHeader carries the ID of the event, the same in every attempt, so that receivers can skip duplicates.
+	IDHeader = "X-Webhook-ID"
+	// TimestampHeader carries the time of the attempt as Unix seconds, so that receivers can reject replays.
+	TimestampHeader = "X-Webhook-Timestamp"
+	// SignatureHeader carries the signature of the timestamp and the body computed by Sign.
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// signaturePrefix names the algorithm of the signatures.
+const signaturePrefix = "sha256="
+
+// Sign returns the signature of a request: "sha256=" followed by the hex-encoded HMAC-SHA256 of the timestamp,
+// a dot and the body, keyed with the secret of the endpoint.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature matches the timestamp and the body of a request, as received in the
+// TimestampHeader and SignatureHeader headers. Receivers written in Go can use it to check the requests.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// stateVersion is the version of the state file format, increased on incompatible changes.
+const stateVersion = 1
+
+// state is the content of the state file: the endpoints registered through the API and the undelivered events.
+// The configured endpoints are not saved, since they are read from the configuration on every start.
+type state struct {
+	Version   int         `json:"version"`   // Format version, stateVersion
+	Endpoints []*endpoint `json:"endpoints"` // Endpoints registered through the API
+	Queue     []delivery  `json:"queue"`     // Undelivered events in the order they occurred
+}
+
+// readState reads the state file. A missing file is an empty state, so that the first start needs no file.
+func readState(path string) (state, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return state{Version: stateVersion}, nil
+	}
+	if err != nil {
+		return state{}, fmt.Errorf("failed to read webhook state %s: %w", path, err)
+	}
+
+	var st state
+	if err = json.Unmarshal(data, &st); err != nil {
+		return state{}, fmt.Errorf("failed to parse webhook state %s: %w", path, err)
+	}
+	if st.Version != stateVersion {
+		return state{}, fmt.Errorf("unsupported webhook state version %d in %s", st.Version, path)
+	}
+	return st, nil
+}
+
+// writeState replaces the state file. The state is written to a temporary file in the same directory first and
+// renamed over the old one, so that a crash never leaves a partially written file. The file is only readable by
+// its owner, since it contains the secrets of the endpoints.
+func writeState(path string, st state) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
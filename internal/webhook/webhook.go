@@ -0,0 +1,583 @@
+// Package webhook notifies external systems, such as a CRM, of the new calculations stored in the cache, so that
+// they do not have to poll /cache.
+//
+// Every new calculation is posted as a JSON models.WebhookEvent to the registered endpoints, signed with the
+// HMAC-SHA256 of the endpoint secret (see Sign). The events of an endpoint are delivered one at a time, in the
+// order they occurred; when the endpoint fails, the delivery is retried with exponential backoff, and an event
+// that still fails after the configured number of attempts is dropped. The undelivered events and the endpoints
+// registered through the API are saved in the optional state file, so that they survive restarts.
+//
+// Types and Functions:
+//   - Dispatcher: Queues and delivers the events, with its Run, Register, Remove, Endpoints and Pending methods.
+//   - New: Creates a dispatcher with the configured endpoints and the saved state.
+//   - WithHTTPClient: Makes the dispatcher send the requests with the given client.
+//   - Sign, Verify: Compute and check the signatures of the requests.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sber/internal/cache"
+	"sber/internal/config"
+	"sber/internal/metrics"
+	"sber/pkg/models"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// subscriptionBuffer is how many new calculations are queued for the dispatcher before it falls behind and
+// reads the missed ones from the storage.
+const subscriptionBuffer = 256
+
+// maxResponseSize is how much of a response body is read, so that the connection can be reused.
+const maxResponseSize = 64 << 10
+
+// saveDelay is how long changes are collected before the state file is written, so that a burst of events costs
+// one write.
+const saveDelay = 500 * time.Millisecond
+
+// Errors returned by the Dispatcher for the requests of the administrative API.
+var (
+	// ErrNotFound is returned when no endpoint has the given ID.
+	ErrNotFound = errors.New("webhook not found")
+
+	// ErrConfigured is returned when removing an endpoint listed in the configuration file.
+	ErrConfigured = errors.New("webhook is configured in the config file and cannot be removed")
+
+	// ErrInvalid is returned when registering an endpoint with an invalid URL.
+	ErrInvalid = errors.New("invalid webhook")
+)
+
+// Dispatcher queues an event for every new calculation and delivers it to the endpoints. Each endpoint is served
+// by its own worker, so that a failing endpoint does not delay the others.
+type Dispatcher struct {
+	cfg    config.Webhooks // The webhook settings
+	client *http.Client    // Client sending the requests
+	dirty  chan struct{}   // Signals the saver that the state changed
+
+	mu        sync.Mutex      // Protects the fields below
+	endpoints []*endpoint     // Endpoints in the order they were added, the configured ones first
+	queue     []delivery      // Undelivered events in the order they occurred
+	ctx       context.Context // Context of Run, nil before Run is called
+	stopped   bool            // Whether Run has returned, after which no workers are started
+	wg        sync.WaitGroup  // Waits for the workers
+}
+
+// endpoint is an endpoint receiving the events. The exported fields are saved in the state file.
+type endpoint struct {
+	ID       string             `json:"id"`                  // Unique identifier of the endpoint
+	URL      string             `json:"url"`                 // URL the events are posted to
+	Secret   string             `json:"secret"`              // Key of the signatures
+	ClientID string             `json:"client_id,omitempty"` // Client whose calculations are sent, all when empty
+	source   string             // Where the endpoint was registered
+	wake     chan struct{}      // Signals the worker that an event was queued
+	cancel   context.CancelFunc // Stops the worker, nil when it is not running
+}
+
+// delivery is an event waiting to be delivered to an endpoint.
+type delivery struct {
+	EventID    string          `json:"event_id"`    // ID of the event
+	EndpointID string          `json:"endpoint_id"` // ID of the endpoint to deliver to
+	Body       json.RawMessage `json:"body"`        // The encoded event, the same in every attempt
+	Attempts   int             `json:"attempts"`    // Number of failed attempts
+}
+
+// Option configures optional dependencies of the Dispatcher.
+type Option func(d *Dispatcher)
+
+// WithHTTPClient makes the dispatcher send the requests with the given client instead of a default one, e.g. to
+// trust a private certificate authority.
+func WithHTTPClient(client *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+// New creates a dispatcher delivering the events to the configured endpoints and to the endpoints saved in the
+// state file, with the saved undelivered events queued. It returns an error if the state file cannot be read.
+// The events of endpoints that are no longer configured are dropped.
+func New(cfg config.Webhooks, opts ...Option) (*Dispatcher, error) {
+	d := &Dispatcher{cfg: cfg, client: &http.Client{}, dirty: make(chan struct{}, 1)}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	// The IDs of the configured endpoints are derived from their settings, so that they are stable across restarts
+	for _, e := range cfg.Endpoints {
+		d.add(&endpoint{ID: configuredID(e), URL: e.URL, Secret: e.Secret, ClientID: e.ClientID, source: models.WebhookSourceConfig})
+	}
+
+	if cfg.StateFile == "" {
+		return d, nil
+	}
+	st, err := readState(cfg.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, ep := range st.Endpoints {
+		ep.source = models.WebhookSourceAPI
+		d.add(ep)
+	}
+	for _, del := range st.Queue {
+		if d.endpoint(del.EndpointID) == nil {
+			slog.Warn("dropping webhook event of an unknown endpoint", "endpoint", del.EndpointID, "event", del.EventID)
+			continue
+		}
+		d.queue = append(d.queue, del)
+	}
+	return d, nil
+}
+
+// Run delivers the events of the calculations stored in the storage from now on, until ctx is done. The changes
+// are saved in the state file in the background, and once more before Run returns, so that the events that are
+// not delivered by then stay queued for the next run. Run must be called only once.
+func (d *Dispatcher) Run(ctx context.Context, store *cache.Storage) {
+	sub := store.Subscribe("", subscriptionBuffer)
+	lastID := sub.After()
+	defer func() {
+		sub.Close()
+	}()
+
+	// Save the changes in the background, and the final state after the workers stop
+	saved := make(chan struct{})
+	go func() {
+		d.saver(ctx)
+		close(saved)
+	}()
+	defer func() {
+		<-saved
+		d.save()
+	}()
+
+	// Start the workers of the endpoints, which deliver the saved events first
+	d.mu.Lock()
+	d.ctx = ctx
+	for _, ep := range d.endpoints {
+		d.start(ep)
+	}
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		d.stopped = true
+		d.mu.Unlock()
+		d.wg.Wait()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry, ok := <-sub.C():
+			if !ok {
+				// The subscription was closed because the dispatcher fell behind: subscribe again and read the
+				// calculations stored in the meantime from the storage
+				slog.Warn("webhook dispatcher fell behind, reading the missed calculations from the cache", "after", lastID)
+				sub = store.Subscribe("", subscriptionBuffer)
+				for _, missed := range store.ReadAfter(lastID) {
+					d.enqueue(missed)
+					lastID = missed.ID
+				}
+				continue
+			}
+			// Skip the calculations already read from the storage
+			if entry.ID <= lastID {
+				continue
+			}
+			lastID = entry.ID
+			d.enqueue(entry)
+		}
+	}
+}
+
+// Register adds an endpoint receiving the events of the calculations stored from now on. A secret is generated
+// when none is given. It returns the endpoint including its secret, or an error wrapping ErrInvalid if the URL
+// is not an absolute HTTP or HTTPS URL.
+func (d *Dispatcher) Register(req models.WebhookRequest) (models.Webhook, error) {
+	if err := config.ValidateWebhookURL(req.URL); err != nil {
+		return models.Webhook{}, fmt.Errorf("%w: url %w", ErrInvalid, err)
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = randomHex(32); err != nil {
+			return models.Webhook{}, err
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ep := &endpoint{ID: id, URL: req.URL, Secret: secret, ClientID: req.ClientID, source: models.WebhookSourceAPI}
+	d.add(ep)
+	d.start(ep)
+	d.changed()
+
+	view := d.view(ep)
+	view.Secret = ep.Secret
+	return view, nil
+}
+
+// Remove removes an endpoint registered through the API and drops its undelivered events. It returns ErrNotFound
+// for an unknown ID, and ErrConfigured for an endpoint listed in the configuration file.
+func (d *Dispatcher) Remove(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ep := d.endpoint(id)
+	if ep == nil {
+		return ErrNotFound
+	}
+	if ep.source == models.WebhookSourceConfig {
+		return ErrConfigured
+	}
+
+	// Stop the worker, which cancels an attempt in progress
+	if ep.cancel != nil {
+		ep.cancel()
+	}
+	for i, e := range d.endpoints {
+		if e == ep {
+			d.endpoints = append(d.endpoints[:i], d.endpoints[i+1:]...)
+			break
+		}
+	}
+	queue := d.queue[:0]
+	for _, del := range d.queue {
+		if del.EndpointID != id {
+			queue = append(queue, del)
+		}
+	}
+	d.queue = queue
+	d.changed()
+	return nil
+}
+
+// Endpoints returns the endpoints with the number of their undelivered events. The secrets are left out.
+func (d *Dispatcher) Endpoints() []models.Webhook {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	webhooks := make([]models.Webhook, 0, len(d.endpoints))
+	for _, ep := range d.endpoints {
+		webhooks = append(webhooks, d.view(ep))
+	}
+	return webhooks
+}
+
+// Pending returns the number of undelivered events of all endpoints.
+func (d *Dispatcher) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.queue)
+}
+
+// enqueue queues the event of a new calculation for the endpoints receiving it.
+func (d *Dispatcher) enqueue(entry models.CacheStorageFormat) {
+	id, err := randomHex(16)
+	if err != nil {
+		slog.Error("failed to generate webhook event id", "entry", entry.ID, "error", err)
+		return
+	}
+	body, err := json.Marshal(models.WebhookEvent{
+		ID:          id,
+		Type:        models.WebhookCalculationCreated,
+		CreatedAt:   entry.CreatedAt,
+		Calculation: entry,
+	})
+	if err != nil {
+		slog.Error("failed to encode webhook event", "entry", entry.ID, "error", err)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	queued := false
+	for _, ep := range d.endpoints {
+		if ep.ClientID != "" && ep.ClientID != entry.ClientID {
+			continue
+		}
+		d.queue = append(d.queue, delivery{EventID: id, EndpointID: ep.ID, Body: body})
+		queued = true
+		notify(ep)
+	}
+	if !queued {
+		return
+	}
+
+	// Drop the oldest events beyond the limit, so that an endpoint that is down for long does not exhaust memory
+	if excess := len(d.queue) - d.cfg.MaxQueue; excess > 0 {
+		for _, del := range d.queue[:excess] {
+			metrics.WebhookDeliveriesTotal.Inc("dropped")
+			slog.Error("dropping webhook event, the queue is full", "endpoint", del.EndpointID, "event", del.EventID)
+		}
+		d.queue = append(d.queue[:0], d.queue[excess:]...)
+	}
+	d.changed()
+}
+
+// work delivers the events of the endpoint until ctx is done. A failed delivery is retried after a delay
+// doubling with every consecutive failure.
+func (d *Dispatcher) work(ctx context.Context, ep *endpoint) {
+	defer d.wg.Done()
+
+	failures := 0
+	timer := time.NewTimer(0)
+	<-timer.C
+	defer timer.Stop()
+
+	for {
+		var retry <-chan time.Time
+		wake := ep.wake
+		if del, ok := d.next(ep.ID); ok {
+			err := d.send(ctx, ep, del)
+			if ctx.Err() != nil {
+				// The event stays queued when the dispatcher stops or the endpoint is removed
+				return
+			}
+			d.settle(ep, del, err)
+			if err == nil {
+				failures = 0
+				continue
+			}
+
+			// Wait for the backoff, even if new events are queued in the meantime
+			failures++
+			timer.Reset(d.backoff(failures))
+			retry, wake = timer.C, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-retry:
+		}
+	}
+}
+
+// send posts the event to the endpoint with the signature headers. Responses other than 2xx are failures.
+func (d *Dispatcher) send(ctx context.Context, ep *endpoint, del delivery) error {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(del.Body))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, del.EventID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(ep.Secret, timestamp, del.Body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+// settle removes a delivered event from the queue, or counts the failed attempt and drops the event after the
+// last one.
+func (d *Dispatcher) settle(ep *endpoint, del delivery, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// The event is gone if the queue overflowed in the meantime
+	i := d.index(del)
+	if i < 0 {
+		return
+	}
+
+	if err == nil {
+		metrics.WebhookDeliveriesTotal.Inc("delivered")
+		d.queue = append(d.queue[:i], d.queue[i+1:]...)
+		d.changed()
+		return
+	}
+
+	metrics.WebhookDeliveriesTotal.Inc("failed")
+	d.queue[i].Attempts++
+	if d.queue[i].Attempts >= d.cfg.MaxAttempts {
+		metrics.WebhookDeliveriesTotal.Inc("dropped")
+		slog.Error("dropping webhook event after the last attempt", "endpoint", ep.ID, "url", ep.URL,
+			"event", del.EventID, "attempts", d.queue[i].Attempts, "error", err)
+		d.queue = append(d.queue[:i], d.queue[i+1:]...)
+	} else {
+		slog.Warn("webhook delivery failed", "endpoint", ep.ID, "url", ep.URL, "event", del.EventID,
+			"attempt", d.queue[i].Attempts, "error", err)
+	}
+	d.changed()
+}
+
+// backoff returns the delay before the next attempt after the given number of consecutive failures.
+func (d *Dispatcher) backoff(failures int) time.Duration {
+	delay := d.cfg.InitialBackoff
+	for i := 1; i < failures && delay < d.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.cfg.MaxBackoff)
+}
+
+// next returns the oldest undelivered event of the endpoint.
+func (d *Dispatcher) next(endpointID string) (delivery, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, del := range d.queue {
+		if del.EndpointID == endpointID {
+			return del, true
+		}
+	}
+	return delivery{}, false
+}
+
+// index returns the position of the delivery in the queue, or -1. The caller must hold the mutex.
+func (d *Dispatcher) index(del delivery) int {
+	for i, queued := range d.queue {
+		if queued.EventID == del.EventID && queued.EndpointID == del.EndpointID {
+			return i
+		}
+	}
+	return -1
+}
+
+// add adds an endpoint, unless one with the same ID exists. The caller must hold the mutex, if Run was called.
+func (d *Dispatcher) add(ep *endpoint) {
+	if d.endpoint(ep.ID) != nil {
+		return
+	}
+	ep.wake = make(chan struct{}, 1)
+	d.endpoints = append(d.endpoints, ep)
+}
+
+// start starts the worker of the endpoint if Run is running. The caller must hold the mutex.
+func (d *Dispatcher) start(ep *endpoint) {
+	if d.ctx == nil || d.stopped {
+		return
+	}
+	ctx, cancel := context.WithCancel(d.ctx)
+	ep.cancel = cancel
+	d.wg.Add(1)
+	go d.work(ctx, ep)
+}
+
+// endpoint returns the endpoint with the ID, or nil. The caller must hold the mutex, if Run was called.
+func (d *Dispatcher) endpoint(id string) *endpoint {
+	for _, ep := range d.endpoints {
+		if ep.ID == id {
+			return ep
+		}
+	}
+	return nil
+}
+
+// view describes the endpoint without its secret. The caller must hold the mutex.
+func (d *Dispatcher) view(ep *endpoint) models.Webhook {
+	pending := 0
+	for _, del := range d.queue {
+		if del.EndpointID == ep.ID {
+			pending++
+		}
+	}
+	return models.Webhook{ID: ep.ID, URL: ep.URL, ClientID: ep.ClientID, Source: ep.source, Pending: pending}
+}
+
+// changed marks the state as changed, so that the saver writes it. It does not block, so that it can be called
+// with the mutex held.
+func (d *Dispatcher) changed() {
+	if d.cfg.StateFile == "" {
+		return
+	}
+	select {
+	case d.dirty <- struct{}{}:
+	default:
+	}
+}
+
+// saver writes the state file after changes, at most once per saveDelay, until ctx is done.
+func (d *Dispatcher) saver(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.dirty:
+		}
+
+		// Collect the changes made in the meantime into the same write
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(saveDelay):
+		}
+		d.save()
+	}
+}
+
+// save writes the endpoints registered through the API and the queue to the state file, if there is one. The
+// state is copied under the mutex and written without it, so that the workers and the API are not blocked by the
+// disk. Failures are logged, the state is kept in memory. Only the saver and Run call save, one at a time.
+func (d *Dispatcher) save() {
+	if d.cfg.StateFile == "" {
+		return
+	}
+
+	d.mu.Lock()
+	st := state{Version: stateVersion, Endpoints: []*endpoint{}, Queue: append([]delivery{}, d.queue...)}
+	for _, ep := range d.endpoints {
+		if ep.source == models.WebhookSourceAPI {
+			st.Endpoints = append(st.Endpoints, &endpoint{ID: ep.ID, URL: ep.URL, Secret: ep.Secret, ClientID: ep.ClientID})
+		}
+	}
+	d.mu.Unlock()
+
+	if err := writeState(d.cfg.StateFile, st); err != nil {
+		slog.Error("failed to save webhook state", "file", d.cfg.StateFile, "error", err)
+	}
+}
+
+// notify wakes the worker of the endpoint without blocking.
+func notify(ep *endpoint) {
+	select {
+	case ep.wake <- struct{}{}:
+	default:
+	}
+}
+
+// configuredID returns the ID of an endpoint listed in the configuration, derived from its URL and client.
+func configuredID(e config.WebhookEndpoint) string {
+	sum := sha256.Sum256([]byte(e.URL + "\n" + e.ClientID))
+	return "config-" + hex.EncodeToString(sum[:6])
+}
+
+// randomHex returns n random bytes encoded as hex.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,356 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sber/internal/cache"
+	"sber/internal/config"
+	"sber/pkg/models"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// received is a request received by the test receiver.
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver is a webhook endpoint recording the requests and answering with the status returned by status.
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []received
+	status   atomic.Int32
+}
+
+// newReceiver starts a receiver answering with the given status until it is changed.
+func newReceiver(t *testing.T, status int) *receiver {
+	rec := &receiver{}
+	rec.status.Store(int32(status))
+	rec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		rec.requests = append(rec.requests, received{header: r.Header.Clone(), body: body})
+		rec.mu.Unlock()
+		w.WriteHeader(int(rec.status.Load()))
+	}))
+	t.Cleanup(rec.Close)
+	return rec
+}
+
+// received returns the requests received so far.
+func (rec *receiver) received() []received {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]received(nil), rec.requests...)
+}
+
+// testConfig returns webhook settings with short delays.
+func testConfig(endpoints ...config.WebhookEndpoint) config.Webhooks {
+	cfg := config.Default().Webhooks
+	cfg.Enabled = true
+	cfg.Endpoints = endpoints
+	cfg.Timeout = time.Second
+	cfg.InitialBackoff = 10 * time.Millisecond
+	cfg.MaxBackoff = 40 * time.Millisecond
+	return cfg
+}
+
+// run runs the dispatcher until the test ends, and returns a function stopping it earlier.
+func run(t *testing.T, d *Dispatcher, store *cache.Storage) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx, store)
+		close(done)
+	}()
+	stop := func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(stop)
+
+	// Wait for the subscription, so that no calculation of the test is stored before it
+	waitFor(t, func() bool { return store.Subscribers() == 1 })
+	return stop
+}
+
+// waitFor polls the condition until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// calculate stores a calculation made by the client.
+func calculate(storage *cache.Storage, clientID string, cost int32) {
+	storage.Store(models.Result{Params: models.Params{ObjectCost: cost}}, models.RecordMeta{ClientID: clientID})
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	all := newReceiver(t, http.StatusOK)
+	bank := newReceiver(t, http.StatusNoContent)
+
+	d, err := New(testConfig(
+		config.WebhookEndpoint{URL: all.URL, Secret: "all-secret"},
+		config.WebhookEndpoint{URL: bank.URL, Secret: "bank-secret", ClientID: "bank"},
+	))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	storage := cache.New()
+	run(t, d, storage)
+
+	calculate(storage, "bank", 1_000_000)
+	calculate(storage, "crm", 2_000_000)
+	waitFor(t, func() bool { return len(all.received()) == 2 && len(bank.received()) == 1 && d.Pending() == 0 })
+
+	for i, req := range all.received() {
+		var event models.WebhookEvent
+		if err := json.Unmarshal(req.body, &event); err != nil {
+			t.Fatalf("invalid event: %v", err)
+		}
+		if event.Type != models.WebhookCalculationCreated || event.Calculation.ID != int32(i) {
+			t.Errorf("Unexpected event %+v", event)
+		}
+		if req.header.Get(IDHeader) != event.ID || req.header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected headers %v", req.header)
+		}
+		if !Verify("all-secret", req.header.Get(TimestampHeader), req.body, req.header.Get(SignatureHeader)) {
+			t.Errorf("Invalid signature %s", req.header.Get(SignatureHeader))
+		}
+	}
+
+	// The endpoint of a client only receives its calculations, with the same event ID
+	req := bank.received()[0]
+	if !Verify("bank-secret", req.header.Get(TimestampHeader), req.body, req.header.Get(SignatureHeader)) {
+		t.Errorf("Invalid signature %s", req.header.Get(SignatureHeader))
+	}
+	if req.header.Get(IDHeader) != all.received()[0].header.Get(IDHeader) {
+		t.Errorf("Expected the same event ID for every endpoint")
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	rec := newReceiver(t, http.StatusServiceUnavailable)
+	d, err := New(testConfig(config.WebhookEndpoint{URL: rec.URL, Secret: "secret"}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	storage := cache.New()
+	run(t, d, storage)
+
+	calculate(storage, "", 1_000_000)
+	waitFor(t, func() bool { return len(rec.received()) >= 3 })
+	if d.Pending() != 1 {
+		t.Errorf("Expected the event to stay queued, got %d", d.Pending())
+	}
+
+	// The endpoint recovers and receives the same event
+	rec.status.Store(http.StatusOK)
+	waitFor(t, func() bool { return d.Pending() == 0 })
+
+	requests := rec.received()
+	for _, req := range requests {
+		if req.header.Get(IDHeader) != requests[0].header.Get(IDHeader) || string(req.body) != string(requests[0].body) {
+			t.Errorf("Expected every attempt to send the same event")
+		}
+	}
+}
+
+func TestDispatcherDropsAfterMaxAttempts(t *testing.T) {
+	rec := newReceiver(t, http.StatusInternalServerError)
+	cfg := testConfig(config.WebhookEndpoint{URL: rec.URL, Secret: "secret"})
+	cfg.MaxAttempts = 2
+	d, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	storage := cache.New()
+	run(t, d, storage)
+
+	calculate(storage, "", 1_000_000)
+	waitFor(t, func() bool { return len(rec.received()) == 2 && d.Pending() == 0 })
+
+	// No attempt follows the last one
+	time.Sleep(3 * cfg.MaxBackoff)
+	if got := len(rec.received()); got != 2 {
+		t.Errorf("Expected 2 attempts, got %d", got)
+	}
+}
+
+func TestDispatcherPersistsState(t *testing.T) {
+	rec := newReceiver(t, http.StatusServiceUnavailable)
+	cfg := testConfig()
+	cfg.StateFile = filepath.Join(t.TempDir(), "webhooks.json")
+	cfg.InitialBackoff, cfg.MaxBackoff = time.Hour, time.Hour
+
+	d, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	storage := cache.New()
+	stop := run(t, d, storage)
+
+	registered, err := d.Register(models.WebhookRequest{URL: rec.URL})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if registered.Secret == "" || registered.Source != models.WebhookSourceAPI {
+		t.Errorf("Expected a generated secret for an API endpoint, got %+v", registered)
+	}
+
+	calculate(storage, "", 1_000_000)
+	waitFor(t, func() bool { return len(rec.received()) == 1 })
+	stop()
+
+	info, err := os.Stat(cfg.StateFile)
+	if err != nil {
+		t.Fatalf("Expected a state file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the state file to be private, got %v", info.Mode().Perm())
+	}
+
+	// A new dispatcher restores the endpoint and delivers the queued event
+	rec.status.Store(http.StatusOK)
+	restored, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	endpoints := restored.Endpoints()
+	if len(endpoints) != 1 || endpoints[0].ID != registered.ID || endpoints[0].Pending != 1 || endpoints[0].Secret != "" {
+		t.Fatalf("Unexpected restored endpoints %+v", endpoints)
+	}
+	run(t, restored, cache.New())
+	waitFor(t, func() bool { return restored.Pending() == 0 })
+
+	requests := rec.received()
+	if len(requests) != 2 || requests[1].header.Get(IDHeader) != requests[0].header.Get(IDHeader) {
+		t.Errorf("Expected the queued event to be delivered again, got %d requests", len(requests))
+	}
+	if !Verify(registered.Secret, requests[1].header.Get(TimestampHeader), requests[1].body, requests[1].header.Get(SignatureHeader)) {
+		t.Error("Expected the restored endpoint to sign with its secret")
+	}
+}
+
+func TestDispatcherSavesInBackground(t *testing.T) {
+	cfg := testConfig()
+	cfg.StateFile = filepath.Join(t.TempDir(), "webhooks.json")
+	d, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	run(t, d, cache.New())
+
+	// A burst of registrations is saved while the dispatcher runs, without blocking the API
+	for i := 0; i < 10; i++ {
+		if _, err = d.Register(models.WebhookRequest{URL: "http://127.0.0.1:1/hooks"}); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+	waitFor(t, func() bool {
+		st, readErr := readState(cfg.StateFile)
+		return readErr == nil && len(st.Endpoints) == 10
+	})
+}
+
+func TestDispatcherRemove(t *testing.T) {
+	d, err := New(testConfig(config.WebhookEndpoint{URL: "https://crm.example.com/hooks", Secret: "secret"}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	configured := d.Endpoints()[0]
+	registered, err := d.Register(models.WebhookRequest{URL: "https://crm.example.com/other", ClientID: "bank"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		expected error
+	}{
+		{"Configured endpoint", configured.ID, ErrConfigured},
+		{"Unknown endpoint", "unknown", ErrNotFound},
+		{"Registered endpoint", registered.ID, nil},
+		{"Removed endpoint", registered.ID, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := d.Remove(tt.id); !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	if endpoints := d.Endpoints(); len(endpoints) != 1 || endpoints[0].ID != configured.ID {
+		t.Errorf("Unexpected endpoints %+v", endpoints)
+	}
+}
+
+func TestRegisterInvalidURL(t *testing.T) {
+	d, err := New(testConfig())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, url := range []string{"", "crm.example.com/hooks", "ftp://crm.example.com/hooks"} {
+		if _, err := d.Register(models.WebhookRequest{URL: url}); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Expected ErrInvalid for %q, got %v", url, err)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{cfg: config.Webhooks{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}}
+
+	for failures, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 40: 5 * time.Second} {
+		if got := d.backoff(failures); got != expected {
+			t.Errorf("Expected %v after %d failures, got %v", expected, failures, got)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	signature := Sign("secret", 1700000000, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		signature string
+		expected  bool
+	}{
+		{"Valid", "secret", "1700000000", body, signature, true},
+		{"Wrong secret", "other", "1700000000", body, signature, false},
+		{"Changed timestamp", "secret", "1700000001", body, signature, false},
+		{"Changed body", "secret", "1700000000", []byte(`{"id":"2"}`), signature, false},
+		{"Invalid timestamp", "secret", "now", body, signature, false},
+		{"Missing prefix", "secret", "1700000000", body, signature[len("sha256="):], false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.timestamp, tt.body, tt.signature); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
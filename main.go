@@ -1,16 +1,17 @@
 // Package main is the entry point of the application for the mortgage calculation service.
 //
-// It initializes the application by calling the Run function from the app package,
-// which starts the server, loads configurations, and handles incoming HTTP requests.
+// It passes the command line to the cli package, which either runs the HTTP server (the default) or
+// calculates mortgages directly in the terminal, and exits with the code it returns.
 //
 // The main package is responsible for launching the application and is the entry point when the program is executed.
 package main
 
 import (
-	"sber/internal/app"
+	"os"
+	"sber/internal/cli"
 )
 
 func main() {
-	// Calls the Run function from the app package to start the application, which includes server setup and execution.
-	app.Run()
+	// Run the command given on the command line and exit with its code, e.g. 3 on validation failures
+	os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
 }
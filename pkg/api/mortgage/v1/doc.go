@@ -0,0 +1,9 @@
+// Package mortgagev1 contains the protobuf messages and the gRPC client and server stubs of the mortgage
+// calculation service, generated from mortgage.proto. Internal services import it to call the gRPC API:
+//
+//	conn, err := grpc.NewClient("mortgage:9090", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	client := mortgagev1.NewMortgageServiceClient(conn)
+//	resp, err := client.Calculate(ctx, &mortgagev1.CalculateRequest{...})
+//
+// Do not edit the generated files, change mortgage.proto and run `make proto` instead.
+package mortgagev1
@@ -0,0 +1,224 @@
+// Protobuf definitions of the gRPC API of the mortgage calculation service. The messages mirror the
+// structures of sber/pkg/models, so that both APIs return the same data.
+//
+// Regenerate the Go code with `make proto`.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: mortgage/v1/mortgage.proto
+
+package mortgagev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MortgageService_Calculate_FullMethodName        = "/mortgage.v1.MortgageService/Calculate"
+	MortgageService_Compare_FullMethodName          = "/mortgage.v1.MortgageService/Compare"
+	MortgageService_ListCalculations_FullMethodName = "/mortgage.v1.MortgageService/ListCalculations"
+)
+
+// MortgageServiceClient is the client API for MortgageService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MortgageService calculates mortgages and lists the cached calculations. It shares the storage and the
+// calculation engine with the HTTP API, so calculations made over one API are listed by the other.
+type MortgageServiceClient interface {
+	// Calculate validates the parameters, calculates the mortgage for the selected program and stores the
+	// result in the cache, as POST /execute does. A repeated calculation is answered with the stored entry
+	// when memoization is enabled. Invalid parameters are rejected with INVALID_ARGUMENT and
+	// a google.rpc.BadRequest detail naming the field.
+	Calculate(ctx context.Context, in *CalculateRequest, opts ...grpc.CallOption) (*CalculateResponse, error)
+	// Compare calculates the mortgage for every program with the same parameters. The results are not cached.
+	Compare(ctx context.Context, in *CompareRequest, opts ...grpc.CallOption) (*CompareResponse, error)
+	// ListCalculations returns the cached calculations ordered by ID, as GET /cache does. Authenticated clients
+	// only see the calculations they made themselves, optionally within a time window. An empty cache returns an
+	// empty list.
+	ListCalculations(ctx context.Context, in *ListCalculationsRequest, opts ...grpc.CallOption) (*ListCalculationsResponse, error)
+}
+
+type mortgageServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMortgageServiceClient(cc grpc.ClientConnInterface) MortgageServiceClient {
+	return &mortgageServiceClient{cc}
+}
+
+func (c *mortgageServiceClient) Calculate(ctx context.Context, in *CalculateRequest, opts ...grpc.CallOption) (*CalculateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CalculateResponse)
+	err := c.cc.Invoke(ctx, MortgageService_Calculate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mortgageServiceClient) Compare(ctx context.Context, in *CompareRequest, opts ...grpc.CallOption) (*CompareResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompareResponse)
+	err := c.cc.Invoke(ctx, MortgageService_Compare_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mortgageServiceClient) ListCalculations(ctx context.Context, in *ListCalculationsRequest, opts ...grpc.CallOption) (*ListCalculationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCalculationsResponse)
+	err := c.cc.Invoke(ctx, MortgageService_ListCalculations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MortgageServiceServer is the server API for MortgageService service.
+// All implementations must embed UnimplementedMortgageServiceServer
+// for forward compatibility.
+//
+// MortgageService calculates mortgages and lists the cached calculations. It shares the storage and the
+// calculation engine with the HTTP API, so calculations made over one API are listed by the other.
+type MortgageServiceServer interface {
+	// Calculate validates the parameters, calculates the mortgage for the selected program and stores the
+	// result in the cache, as POST /execute does. A repeated calculation is answered with the stored entry
+	// when memoization is enabled. Invalid parameters are rejected with INVALID_ARGUMENT and
+	// a google.rpc.BadRequest detail naming the field.
+	Calculate(context.Context, *CalculateRequest) (*CalculateResponse, error)
+	// Compare calculates the mortgage for every program with the same parameters. The results are not cached.
+	Compare(context.Context, *CompareRequest) (*CompareResponse, error)
+	// ListCalculations returns the cached calculations ordered by ID, as GET /cache does. Authenticated clients
+	// only see the calculations they made themselves, optionally within a time window. An empty cache returns an
+	// empty list.
+	ListCalculations(context.Context, *ListCalculationsRequest) (*ListCalculationsResponse, error)
+	mustEmbedUnimplementedMortgageServiceServer()
+}
+
+// UnimplementedMortgageServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMortgageServiceServer struct{}
+
+func (UnimplementedMortgageServiceServer) Calculate(context.Context, *CalculateRequest) (*CalculateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Calculate not implemented")
+}
+func (UnimplementedMortgageServiceServer) Compare(context.Context, *CompareRequest) (*CompareResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Compare not implemented")
+}
+func (UnimplementedMortgageServiceServer) ListCalculations(context.Context, *ListCalculationsRequest) (*ListCalculationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCalculations not implemented")
+}
+func (UnimplementedMortgageServiceServer) mustEmbedUnimplementedMortgageServiceServer() {}
+func (UnimplementedMortgageServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeMortgageServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MortgageServiceServer will
+// result in compilation errors.
+type UnsafeMortgageServiceServer interface {
+	mustEmbedUnimplementedMortgageServiceServer()
+}
+
+func RegisterMortgageServiceServer(s grpc.ServiceRegistrar, srv MortgageServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMortgageServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MortgageService_ServiceDesc, srv)
+}
+
+func _MortgageService_Calculate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CalculateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MortgageServiceServer).Calculate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MortgageService_Calculate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MortgageServiceServer).Calculate(ctx, req.(*CalculateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MortgageService_Compare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MortgageServiceServer).Compare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MortgageService_Compare_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MortgageServiceServer).Compare(ctx, req.(*CompareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MortgageService_ListCalculations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCalculationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MortgageServiceServer).ListCalculations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MortgageService_ListCalculations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MortgageServiceServer).ListCalculations(ctx, req.(*ListCalculationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MortgageService_ServiceDesc is the grpc.ServiceDesc for MortgageService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MortgageService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mortgage.v1.MortgageService",
+	HandlerType: (*MortgageServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Calculate",
+			Handler:    _MortgageService_Calculate_Handler,
+		},
+		{
+			MethodName: "Compare",
+			Handler:    _MortgageService_Compare_Handler,
+		},
+		{
+			MethodName: "ListCalculations",
+			Handler:    _MortgageService_ListCalculations_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "mortgage/v1/mortgage.proto",
+}
@@ -15,6 +15,9 @@ var (
 	// ErrMoreThanOneTrue is returned when more than one true value is found in a set
 	// where only one true value is expected.
 	ErrMoreThanOneTrue = errors.New("there are more that one true value")
+
+	// ErrUnknownProgram is returned when the selected loan program does not exist.
+	ErrUnknownProgram = errors.New("unknown loan program")
 )
 
 // Custom errors for initial payment validation.
@@ -24,8 +27,44 @@ var (
 	ErrInitalPaymentIsTooSmall = errors.New("the initial payment should be more")
 )
 
+// Custom errors for loan term validation.
+var (
+	// ErrInvalidTerm is returned when the loan term is not a positive number of months or is too long.
+	ErrInvalidTerm = errors.New("the loan term should be positive")
+)
+
+// Custom errors for loan amount validation.
+var (
+	// ErrLoanTooLarge is returned when the monthly payment or the overpayment of a loan do not fit in int32.
+	ErrLoanTooLarge = errors.New("the loan is too large")
+
+	// ErrInvalidRate is returned when the rate of the selected loan program is not positive.
+	ErrInvalidRate = errors.New("the program rate should be positive")
+)
+
 // Custom errors for cofig load.
 var (
 	// ErrInvalidPath is returned when file with filepath is not in safe directory.
 	ErrInvalidPath = errors.New("invalid path")
+
+	// ErrInvalidConfig is returned when the loaded configuration contains values the service cannot run with.
+	ErrInvalidConfig = errors.New("invalid config")
+)
+
+// Custom errors for authentication.
+var (
+	// ErrInvalidAPIKey is returned when a client presents a missing or unknown API key.
+	ErrInvalidAPIKey = errors.New("invalid api key")
+
+	// ErrQuotaExceeded is returned when a client has used up its daily request quota.
+	ErrQuotaExceeded = errors.New("daily quota exceeded")
+)
+
+// Custom errors for storage access.
+var (
+	// ErrStorageUnavailable is returned when the storage backend cannot serve requests.
+	ErrStorageUnavailable = errors.New("storage is unavailable")
+
+	// ErrInvalidSnapshot is returned when a snapshot of the storage cannot be decoded or is inconsistent.
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
 )
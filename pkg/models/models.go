@@ -12,74 +12,248 @@
 //   - Last payment date
 package models
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"encoding/xml"
+	"time"
+)
 
 // Aggregates represents the calculated financial aggregates based on the mortgage request.
 // It includes the interest rate, loan sum, monthly payment, total overpayment, and the last payment date.
 type Aggregates struct {
-	LastPaymentDate string `json:"last_payment_date"` // Date of the last payment
-	Rate            uint8  `json:"rate"`              // Interest rate
-	LoanSum         int32  `json:"loan_sum"`          // Loan amount
-	MonthlyPayment  int32  `json:"monthly_payment"`   // Monthly payment amount
-	Overpayment     int32  `json:"overpayment"`       // Total overpayment for the loan
+	LastPaymentDate string `json:"last_payment_date" xml:"last_payment_date" yaml:"last_payment_date"` // Date of the last payment
+	Rate            uint8  `json:"rate" xml:"rate" yaml:"rate"`                                        // Interest rate
+	LoanSum         int32  `json:"loan_sum" xml:"loan_sum" yaml:"loan_sum"`                            // Loan amount
+	MonthlyPayment  int32  `json:"monthly_payment" xml:"monthly_payment" yaml:"monthly_payment"`       // Monthly payment amount
+	Overpayment     int32  `json:"overpayment" xml:"overpayment" yaml:"overpayment"`                   // Total overpayment for the loan
 }
 
 // Program represents different mortgage programs with flags indicating whether they
 // apply to salary-based, military, or base programs.
 type Program struct {
-	Salary   bool `json:"salary,omitempty"`   // Indicates if the program is salary-based
-	Military bool `json:"military,omitempty"` // Indicates if the program is military
-	Base     bool `json:"base,omitempty"`     // Indicates if the program is base-based
+	Salary   bool `json:"salary,omitempty" xml:"salary,omitempty" yaml:"salary,omitempty"`       // Indicates if the program is salary-based
+	Military bool `json:"military,omitempty" xml:"military,omitempty" yaml:"military,omitempty"` // Indicates if the program is military
+	Base     bool `json:"base,omitempty" xml:"base,omitempty" yaml:"base,omitempty"`             // Indicates if the program is base-based
 }
 
 // Params contains the core parameters needed for mortgage calculations such as
 // object cost, initial payment, and the loan term in months.
 type Params struct {
-	ObjectCost     int32 `json:"object_cost"`     // The cost of the object being purchased
-	InitialPayment int32 `json:"initial_payment"` // The initial payment amount
-	Months         int32 `json:"months"`          // Loan term in months
+	ObjectCost     int32 `json:"object_cost" xml:"object_cost" yaml:"object_cost"`             // The cost of the object being purchased
+	InitialPayment int32 `json:"initial_payment" xml:"initial_payment" yaml:"initial_payment"` // The initial payment amount
+	Months         int32 `json:"months" xml:"months" yaml:"months"`                            // Loan term in months
 }
 
 // ExecuteReqeust represents the structure of a request to execute the mortgage calculation.
 // It contains the object cost, initial payment, loan term, and program details.
 type ExecuteReqeust struct {
-	ObjectCost     int32   `json:"object_cost"`     // Object cost for the loan
-	InitialPayment int32   `json:"initial_payment"` // Initial payment amount
-	Months         int32   `json:"months"`          // Loan term in months
-	Program        Program `json:"program"`         // Mortgage program details
+	XMLName        xml.Name `json:"-" xml:"request" yaml:"-"`                                     // Root element of XML documents
+	ObjectCost     int32    `json:"object_cost" xml:"object_cost" yaml:"object_cost"`             // Object cost for the loan
+	InitialPayment int32    `json:"initial_payment" xml:"initial_payment" yaml:"initial_payment"` // Initial payment amount
+	Months         int32    `json:"months" xml:"months" yaml:"months"`                            // Loan term in months
+	Program        Program  `json:"program" xml:"program" yaml:"program"`                         // Mortgage program details
 }
 
 // ExecuteResponse represents the structure of the response containing the mortgage calculation result.
 type ExecuteResponse struct {
-	Result Result `json:"result"` // The result of the mortgage calculation
+	XMLName xml.Name `json:"-" xml:"response" yaml:"-"`         // Root element of XML documents
+	Result  Result   `json:"result" xml:"result" yaml:"result"` // The result of the mortgage calculation
 }
 
 // Result contains the detailed mortgage calculation results, including parameters, the program,
 // and the aggregated financial data (interest rate, loan sum, etc.).
 type Result struct {
-	Aggregates Aggregates `json:"aggregates"` // Calculated aggregates (interest rate, overpayment, etc.)
-	Params     Params     `json:"params"`     // Mortgage parameters (object cost, initial payment, etc.)
-	Program    Program    `json:"program"`    // Mortgage program (salary, military, base, etc.)
+	Aggregates Aggregates `json:"aggregates" xml:"aggregates" yaml:"aggregates"` // Calculated aggregates (interest rate, overpayment, etc.)
+	Params     Params     `json:"params" xml:"params" yaml:"params"`             // Mortgage parameters (object cost, initial payment, etc.)
+	Program    Program    `json:"program" xml:"program" yaml:"program"`          // Mortgage program (salary, military, base, etc.)
 
 }
 
 // CacheStorageFormat represents the structure of a cached mortgage calculation.
-// It stores the ID, parameters, program, and calculated aggregates.
+// It stores the ID, the client that made the calculation, when and through which request it was made,
+// parameters, program, calculated aggregates, and the number of repeated requests answered with the entry.
 type CacheStorageFormat struct {
-	Aggregates Aggregates `json:"aggregates"` // Calculated aggregates (interest rate, overpayment, etc.)
-	Params     Params     `json:"params"`     // Mortgage parameters
-	Program    Program    `json:"program"`    // Mortgage program details
-	ID         int32      `json:"id"`         // Unique identifier for the cached entry
+	CreatedAt  time.Time  `json:"created_at" xml:"created_at" yaml:"created_at"`                               // Time the calculation was stored
+	Aggregates Aggregates `json:"aggregates" xml:"aggregates" yaml:"aggregates"`                               // Calculated aggregates (interest rate, overpayment, etc.)
+	Params     Params     `json:"params" xml:"params" yaml:"params"`                                           // Mortgage parameters
+	Program    Program    `json:"program" xml:"program" yaml:"program"`                                        // Mortgage program details
+	ClientID   string     `json:"client_id,omitempty" xml:"client_id,omitempty" yaml:"client_id,omitempty"`    // Authenticated client that made the calculation
+	ID         int32      `json:"id" xml:"id" yaml:"id"`                                                       // Unique identifier for the cached entry
+	Hits       int32      `json:"hits,omitempty" xml:"hits,omitempty" yaml:"hits,omitempty"`                   // Number of repeated requests answered with the entry
+	Source     string     `json:"source,omitempty" xml:"source,omitempty" yaml:"source,omitempty"`             // API the calculation was made through ("http" or "grpc")
+	RequestID  string     `json:"request_id,omitempty" xml:"request_id,omitempty" yaml:"request_id,omitempty"` // ID of the request that made the calculation
+	UserAgent  string     `json:"user_agent,omitempty" xml:"user_agent,omitempty" yaml:"user_agent,omitempty"` // User agent of the client that made the calculation
 }
 
-// CacheResponse is the structure for returning a list of cached mortgage calculations.
+// Sources of the requests that produce cached mortgage calculations.
+const (
+	SourceHTTP = "http" // POST /execute
+	SourceGRPC = "grpc" // MortgageService.Calculate
+)
+
+// RecordMeta contains information about the request that produced a cached mortgage calculation.
+type RecordMeta struct {
+	ClientID  string // Authenticated client that made the calculation, empty when authentication is disabled
+	Source    string // API the request was made through, SourceHTTP or SourceGRPC
+	RequestID string // Correlation ID of the request
+	UserAgent string // User agent of the client
+}
+
+// HistoryRecord represents a calculation request recorded in the request history of the cache, including
+// the requests answered with a previously stored entry.
+type HistoryRecord struct {
+	CreatedAt time.Time `json:"created_at" xml:"created_at" yaml:"created_at"`                               // Time the request was made
+	EntryID   int32     `json:"entry_id" xml:"entry_id" yaml:"entry_id"`                                     // ID of the cached entry that answered the request
+	ClientID  string    `json:"client_id,omitempty" xml:"client_id,omitempty" yaml:"client_id,omitempty"`    // Authenticated client that made the request
+	RequestID string    `json:"request_id,omitempty" xml:"request_id,omitempty" yaml:"request_id,omitempty"` // ID of the request
+	Cache     string    `json:"cache" xml:"cache" yaml:"cache"`                                              // Whether the entry was stored ("miss") or reused ("hit")
+}
+
+// HistoryResponse is the structure for returning the request history in XML documents, which need a single
+// root element. JSON and YAML documents contain the list itself.
+type HistoryResponse struct {
+	XMLName xml.Name        `xml:"history"` // Root element of XML documents
+	Records []HistoryRecord `xml:"request"` // Recorded calculation requests
+}
+
+// Stats contains the statistics of the stored mortgage calculations: how often each program is chosen and
+// what the typical loans look like.
+type Stats struct {
+	XMLName             xml.Name          `json:"-" xml:"stats" yaml:"-"`                                                         // Root element of XML documents
+	Count               int               `json:"count" xml:"count" yaml:"count"`                                                 // Number of calculations
+	Programs            ProgramCounts     `json:"programs" xml:"programs" yaml:"programs"`                                        // Number of calculations per program
+	LoanSum             Distribution      `json:"loan_sum" xml:"loan_sum" yaml:"loan_sum"`                                        // Distribution of the loan amounts
+	Months              Distribution      `json:"months" xml:"months" yaml:"months"`                                              // Distribution of the loan terms in months
+	InitialPaymentShare Distribution      `json:"initial_payment_share" xml:"initial_payment_share" yaml:"initial_payment_share"` // Distribution of the initial payment in percent of the object cost
+	MonthlyPayment      Distribution      `json:"monthly_payment" xml:"monthly_payment" yaml:"monthly_payment"`                   // Distribution of the monthly payments
+	LoanSumHistogram    []HistogramBucket `json:"loan_sum_histogram" xml:"loan_sum_histogram>bucket" yaml:"loan_sum_histogram"`   // Number of calculations per range of loan amounts
+}
+
+// ProgramCounts contains the number of calculations per mortgage program.
+type ProgramCounts struct {
+	Base     int `json:"base" xml:"base" yaml:"base"`             // Base program
+	Military int `json:"military" xml:"military" yaml:"military"` // Military mortgage
+	Salary   int `json:"salary" xml:"salary" yaml:"salary"`       // Salary (corporate) program
+}
+
+// Distribution describes the distribution of a value over the calculations. The values are rounded to two
+// decimal places and are all zero when there are no calculations.
+type Distribution struct {
+	Min    float64 `json:"min" xml:"min" yaml:"min"`          // Smallest value
+	Mean   float64 `json:"mean" xml:"mean" yaml:"mean"`       // Arithmetic mean
+	Median float64 `json:"median" xml:"median" yaml:"median"` // 50th percentile
+	P25    float64 `json:"p25" xml:"p25" yaml:"p25"`          // 25th percentile
+	P75    float64 `json:"p75" xml:"p75" yaml:"p75"`          // 75th percentile
+	P90    float64 `json:"p90" xml:"p90" yaml:"p90"`          // 90th percentile
+	P95    float64 `json:"p95" xml:"p95" yaml:"p95"`          // 95th percentile
+	Max    float64 `json:"max" xml:"max" yaml:"max"`          // Largest value
+}
+
+// HistogramBucket is a range of loan amounts with the number of calculations within it. From is inclusive
+// and To is exclusive; the last bucket has no upper bound and omits To.
+type HistogramBucket struct {
+	From  int32 `json:"from" xml:"from" yaml:"from"`                         // Lower bound of the range, inclusive
+	To    int32 `json:"to,omitempty" xml:"to,omitempty" yaml:"to,omitempty"` // Upper bound of the range, exclusive
+	Count int   `json:"count" xml:"count" yaml:"count"`                      // Number of calculations within the range
+}
+
+// Webhook types of the events sent to the webhook endpoints.
+const (
+	WebhookCalculationCreated = "calculation.created" // A new calculation was stored
+)
+
+// Sources of the webhook endpoints.
+const (
+	WebhookSourceConfig = "config" // Listed in the configuration file
+	WebhookSourceAPI    = "api"    // Registered with POST /admin/webhooks
+)
+
+// WebhookEvent is the body of the requests sent to the webhook endpoints.
+type WebhookEvent struct {
+	ID          string             `json:"id"`          // Unique identifier of the event, the same in every attempt and for every endpoint
+	Type        string             `json:"type"`        // Type of the event, such as WebhookCalculationCreated
+	CreatedAt   time.Time          `json:"created_at"`  // Time the event occurred
+	Calculation CacheStorageFormat `json:"calculation"` // The stored calculation
+}
+
+// Webhook describes an endpoint receiving the webhook events. The secret is only returned when the endpoint is
+// registered through the API.
+type Webhook struct {
+	XMLName  xml.Name `json:"-" xml:"webhook" yaml:"-"`                                                 // Root element of XML documents
+	ID       string   `json:"id" xml:"id" yaml:"id"`                                                    // Unique identifier of the endpoint
+	URL      string   `json:"url" xml:"url" yaml:"url"`                                                 // URL the events are posted to
+	ClientID string   `json:"client_id,omitempty" xml:"client_id,omitempty" yaml:"client_id,omitempty"` // Client whose calculations are sent, all clients when empty
+	Secret   string   `json:"secret,omitempty" xml:"secret,omitempty" yaml:"secret,omitempty"`          // Key of the HMAC signatures of the events
+	Source   string   `json:"source" xml:"source" yaml:"source"`                                        // Where the endpoint was registered, WebhookSourceConfig or WebhookSourceAPI
+	Pending  int      `json:"pending" xml:"pending" yaml:"pending"`                                     // Number of events waiting to be delivered
+}
+
+// WebhookRequest is the body of the request registering a webhook endpoint. A secret is generated when it is
+// not given.
+type WebhookRequest struct {
+	URL      string `json:"url" xml:"url" yaml:"url"`                                                 // URL the events are posted to
+	ClientID string `json:"client_id,omitempty" xml:"client_id,omitempty" yaml:"client_id,omitempty"` // Client whose calculations are sent, all clients when empty
+	Secret   string `json:"secret,omitempty" xml:"secret,omitempty" yaml:"secret,omitempty"`          // Key of the HMAC signatures of the events
+}
+
+// WebhooksResponse is the structure for returning the webhook endpoints in XML documents, which need a single
+// root element. JSON and YAML documents contain the list itself.
+type WebhooksResponse struct {
+	XMLName  xml.Name  `xml:"webhooks"` // Root element of XML documents
+	Webhooks []Webhook `xml:"webhook"`  // Registered webhook endpoints
+}
+
+// Snapshot is a dump of the cache, restored to carry the stored calculations over a redeployment.
+type Snapshot struct {
+	Version   int                  `json:"version"`    // Version of the snapshot format
+	CreatedAt time.Time            `json:"created_at"` // Time the snapshot was taken
+	IDCounter int32                `json:"id_counter"` // ID of the next entry to be stored, greater than every entry ID
+	Entries   []CacheStorageFormat `json:"entries"`    // Stored calculations ordered by ID
+}
+
+// Modes of restoring a snapshot into the cache.
+const (
+	RestoreMerge   = "merge"   // Add the entries of the snapshot to the stored ones
+	RestoreReplace = "replace" // Replace the stored entries with the ones of the snapshot
+)
+
+// SnapshotImport describes the outcome of restoring a snapshot into the cache.
+type SnapshotImport struct {
+	XMLName    xml.Name `json:"-" xml:"import" yaml:"-"`                       // Root element of XML documents
+	Mode       string   `json:"mode" xml:"mode" yaml:"mode"`                   // RestoreMerge or RestoreReplace
+	Imported   int      `json:"imported" xml:"imported" yaml:"imported"`       // Number of entries stored with their own ID
+	Renumbered int      `json:"renumbered" xml:"renumbered" yaml:"renumbered"` // Number of entries stored with a new ID, since their ID was taken
+	Skipped    int      `json:"skipped" xml:"skipped" yaml:"skipped"`          // Number of entries already stored under their ID
+	IDCounter  int32    `json:"id_counter" xml:"id_counter" yaml:"id_counter"` // ID of the next entry to be stored
+}
+
+// CacheResponse is the structure for returning a list of cached mortgage calculations in XML documents,
+// which need a single root element. JSON and YAML documents contain the list itself.
 type CacheResponse struct {
-	Results []CacheStorageFormat // List of cached mortgage calculations
+	XMLName xml.Name             `xml:"calculations"` // Root element of XML documents
+	Results []CacheStorageFormat `xml:"calculation"`  // List of cached mortgage calculations
+}
+
+// Payment represents a single monthly payment of the amortization schedule of a mortgage.
+// The amounts are rounded to whole currency units; the last payment settles the remaining balance.
+type Payment struct {
+	Date      string `json:"date" xml:"date" yaml:"date"`                // Payment date in the YYYY-MM-DD format
+	Number    int32  `json:"number" xml:"number" yaml:"number"`          // Sequence number of the payment, starting at 1
+	Amount    int32  `json:"amount" xml:"amount" yaml:"amount"`          // Total payment amount
+	Principal int32  `json:"principal" xml:"principal" yaml:"principal"` // Part of the payment that repays the loan
+	Interest  int32  `json:"interest" xml:"interest" yaml:"interest"`    // Part of the payment that pays the interest
+	Balance   int32  `json:"balance" xml:"balance" yaml:"balance"`       // Remaining loan balance after the payment
+}
+
+// StatusMessage represents a status message returned by the health and readiness probes.
+type StatusMessage struct {
+	Status string `json:"status" xml:"status" yaml:"status"` // The status of the probed component
 }
 
 // ErrorMessage represents an error message returned by the API.
 type ErrorMessage struct {
-	Error string `json:"error"` // The error message
+	XMLName xml.Name `json:"-" xml:"error" yaml:"-"`             // Root element of XML documents
+	Error   string   `json:"error" xml:",chardata" yaml:"error"` // The error message
 }
 
 // The MarshalJSON methods preserves the alignment of fields in the underlying CacheStorageFormat structure,
@@ -89,15 +263,77 @@ func (c CacheStorageFormat) MarshalJSON() ([]byte, error) {
 	type Alias CacheStorageFormat
 	return json.Marshal(&struct {
 		ID         int32      `json:"id"`
+		ClientID   string     `json:"client_id,omitempty"`
+		CreatedAt  string     `json:"created_at,omitempty"`
+		Source     string     `json:"source,omitempty"`
+		RequestID  string     `json:"request_id,omitempty"`
+		UserAgent  string     `json:"user_agent,omitempty"`
 		Params     Params     `json:"params"`
 		Program    Program    `json:"program"`
 		Aggregates Aggregates `json:"aggregates"`
+		Hits       int32      `json:"hits,omitempty"`
 		*Alias
 	}{
 		ID:         c.ID,
+		ClientID:   c.ClientID,
+		CreatedAt:  formatTime(c.CreatedAt),
+		Source:     c.Source,
+		RequestID:  c.RequestID,
+		UserAgent:  c.UserAgent,
 		Params:     c.Params,
 		Program:    c.Program,
 		Aggregates: c.Aggregates,
+		Hits:       c.Hits,
 		Alias:      (*Alias)(&c),
 	})
 }
+
+// orderedCacheStorageFormat lists the fields of CacheStorageFormat in the order required for storage.
+type orderedCacheStorageFormat struct {
+	ID         int32      `xml:"id" yaml:"id"`
+	ClientID   string     `xml:"client_id,omitempty" yaml:"client_id,omitempty"`
+	CreatedAt  string     `xml:"created_at,omitempty" yaml:"created_at,omitempty"`
+	Source     string     `xml:"source,omitempty" yaml:"source,omitempty"`
+	RequestID  string     `xml:"request_id,omitempty" yaml:"request_id,omitempty"`
+	UserAgent  string     `xml:"user_agent,omitempty" yaml:"user_agent,omitempty"`
+	Params     Params     `xml:"params" yaml:"params"`
+	Program    Program    `xml:"program" yaml:"program"`
+	Aggregates Aggregates `xml:"aggregates" yaml:"aggregates"`
+	Hits       int32      `xml:"hits,omitempty" yaml:"hits,omitempty"`
+}
+
+// ordered returns the fields of the entry in the order required for storage.
+func (c CacheStorageFormat) ordered() orderedCacheStorageFormat {
+	return orderedCacheStorageFormat{
+		ID:         c.ID,
+		ClientID:   c.ClientID,
+		CreatedAt:  formatTime(c.CreatedAt),
+		Source:     c.Source,
+		RequestID:  c.RequestID,
+		UserAgent:  c.UserAgent,
+		Params:     c.Params,
+		Program:    c.Program,
+		Aggregates: c.Aggregates,
+		Hits:       c.Hits,
+	}
+}
+
+// formatTime formats the time in RFC 3339 with fractional seconds, or returns an empty string for the zero
+// time, so that entries stored without metadata omit it.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// MarshalXML encodes the entry as a calculation element with the fields in the same order as MarshalJSON.
+func (c CacheStorageFormat) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "calculation"}
+	return e.EncodeElement(c.ordered(), start)
+}
+
+// MarshalYAML encodes the entry with the fields in the same order as MarshalJSON.
+func (c CacheStorageFormat) MarshalYAML() (any, error) {
+	return c.ordered(), nil
+}
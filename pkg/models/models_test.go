@@ -2,7 +2,11 @@ package models
 
 import (
 	"encoding/json"
+	"encoding/xml"
+	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestCacheStorageFormat_MarshalJSON(t *testing.T) {
@@ -77,3 +81,46 @@ func jsonEqual(a, b map[string]interface{}) bool {
 	bBytes, _ := json.Marshal(b)
 	return string(aBytes) == string(bBytes)
 }
+
+func TestCacheStorageFormat_MarshalXMLAndYAML(t *testing.T) {
+	data := CacheStorageFormat{
+		ID:         1,
+		ClientID:   "bank",
+		Params:     Params{ObjectCost: 5000000, InitialPayment: 1000000, Months: 240},
+		Program:    Program{Salary: true},
+		Aggregates: Aggregates{Rate: 8, LoanSum: 4000000, MonthlyPayment: 33458, Overpayment: 4029920, LastPaymentDate: "2044-02-18"},
+	}
+
+	// XML elements follow the storage order
+	resultXML, err := xml.Marshal(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectedXML := "<calculation><id>1</id><client_id>bank</client_id>" +
+		"<params><object_cost>5000000</object_cost><initial_payment>1000000</initial_payment><months>240</months></params>" +
+		"<program><salary>true</salary></program>" +
+		"<aggregates><last_payment_date>2044-02-18</last_payment_date><rate>8</rate><loan_sum>4000000</loan_sum>" +
+		"<monthly_payment>33458</monthly_payment><overpayment>4029920</overpayment></aggregates></calculation>"
+	if string(resultXML) != expectedXML {
+		t.Errorf("Mismatch in XML output.\nExpected: %s\nGot: %s", expectedXML, resultXML)
+	}
+
+	// YAML keys follow the storage order
+	resultYAML, err := yaml.Marshal(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(string(resultYAML), "id: 1\nclient_id: bank\nparams:\n") {
+		t.Errorf("Mismatch in YAML output:\n%s", resultYAML)
+	}
+}
+
+func TestErrorMessage_MarshalXML(t *testing.T) {
+	result, err := xml.Marshal(ErrorMessage{Error: "choose program"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(result) != "<error>choose program</error>" {
+		t.Errorf("Unexpected XML output %s", result)
+	}
+}
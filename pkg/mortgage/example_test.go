@@ -0,0 +1,91 @@
+package mortgage_test
+
+import (
+	"errors"
+	"fmt"
+	errs "sber/pkg/errors"
+	"sber/pkg/mortgage"
+	"time"
+)
+
+func ExampleCalculator_Calculate() {
+	calc := mortgage.New(mortgage.DefaultRates(), mortgage.WithClock(func() time.Time {
+		return time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	}))
+
+	result, err := calc.Calculate(mortgage.Input{
+		ObjectCost:     5000000,
+		InitialPayment: 1000000,
+		Months:         240,
+		Program:        mortgage.Salary,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(result.Rate, result.LoanSum, result.MonthlyPayment, result.Overpayment)
+	fmt.Println(result.LastPaymentDate.Format(time.DateOnly))
+	// Output:
+	// 8 4000000 33458 4029920
+	// 2044-01-15
+}
+
+func ExampleCalculator_Calculate_validation() {
+	_, err := mortgage.New(mortgage.DefaultRates()).Calculate(mortgage.Input{
+		ObjectCost:     5000000,
+		InitialPayment: 500000,
+		Months:         240,
+		Program:        mortgage.Base,
+	})
+
+	var validationErr *mortgage.ValidationError
+	if errors.As(err, &validationErr) {
+		fmt.Println(validationErr.Field, "-", validationErr.Message)
+	}
+	fmt.Println(errors.Is(err, errs.ErrInitalPaymentIsTooSmall))
+	// Output:
+	// initial_payment - the initial payment should be more
+	// true
+}
+
+func ExampleCalculator_Compare() {
+	results, err := mortgage.New(mortgage.Rates{Base: 10, Military: 9, Salary: 8}).Compare(mortgage.Input{
+		ObjectCost:     3000000,
+		InitialPayment: 600000,
+		Months:         120,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, result := range results {
+		fmt.Println(result.Program, result.Rate, result.MonthlyPayment)
+	}
+	// Output:
+	// base 10 31717
+	// military 9 30403
+	// salary 8 29119
+}
+
+func ExampleResult_Schedule() {
+	result, err := mortgage.New(mortgage.DefaultRates(), mortgage.WithClock(func() time.Time {
+		return time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	})).Calculate(mortgage.Input{ObjectCost: 100000, InitialPayment: 40000, Months: 3, Program: mortgage.Base})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	schedule, err := result.Schedule()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, p := range schedule {
+		fmt.Println(p.Number, p.Date, p.Amount, p.Principal, p.Interest, p.Balance)
+	}
+	// Output:
+	// 1 2024-02-29 20335 19835 500 40165
+	// 2 2024-03-30 20335 20000 335 20165
+	// 3 2024-04-30 20333 20165 168 0
+}
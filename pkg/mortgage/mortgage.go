@@ -0,0 +1,266 @@
+// Package mortgage is the calculation engine of the mortgage calculation service: the loan programs and their
+// rates, the validation rules, the annuity payment formula and the amortization schedule. It has no HTTP
+// dependencies, so that other Go services can import the calculator directly.
+//
+// A calculation takes an Input and returns a Result, or a *ValidationError when the input breaks the rules:
+// the initial payment must be at least 20% of the object cost, the term must be between 1 and MaxMonths,
+// exactly one program with a positive rate must be selected, and the monthly payment and the overpayment must
+// fit in int32.
+//
+// Types and Functions:
+//   - Program: A loan program (Base, Military or Salary).
+//   - Rates: The annual interest rates of the programs.
+//   - Calculator: Calculates mortgages with the given rates, created with New.
+//   - Input: The parameters of a calculation.
+//   - Result: The outcome of a calculation, convertible to and from the API models.
+//   - ValidationError: The typed error returned for invalid input.
+//   - InputFromRequest: Builds the input of an /execute request.
+//   - Result.Schedule: Builds the amortization schedule of a calculation.
+package mortgage
+
+import (
+	"fmt"
+	"math"
+	errs "sber/pkg/errors"
+	"sber/pkg/models"
+	"time"
+)
+
+// Program is a loan program.
+type Program string
+
+// The available loan programs.
+const (
+	Base     Program = "base"     // Base program
+	Military Program = "military" // Military mortgage
+	Salary   Program = "salary"   // Salary (corporate) program
+)
+
+// MaxMonths is the longest loan term in months (50 years), which keeps the schedule small enough to build. The
+// amounts are limited separately: a calculation whose monthly payment or overpayment exceeds math.MaxInt32 is
+// rejected with errs.ErrLoanTooLarge.
+const MaxMonths = 600
+
+// Programs lists the loan programs in the order they are compared.
+var Programs = []Program{Base, Military, Salary}
+
+// flags returns the program flags of the API models.
+func (p Program) flags() models.Program {
+	return models.Program{Base: p == Base, Military: p == Military, Salary: p == Salary}
+}
+
+// Rates contains the annual interest rates of the loan programs in percent.
+type Rates struct {
+	Base     uint8 // Rate of the base program
+	Military uint8 // Rate of the military program
+	Salary   uint8 // Rate of the salary program
+}
+
+// DefaultRates returns the rates used when no others are configured.
+func DefaultRates() Rates {
+	return Rates{Base: 10, Military: 9, Salary: 8}
+}
+
+// Of returns the rate of the program, or zero for an unknown program.
+func (r Rates) Of(p Program) uint8 {
+	switch p {
+	case Base:
+		return r.Base
+	case Military:
+		return r.Military
+	case Salary:
+		return r.Salary
+	default:
+		return 0
+	}
+}
+
+// Input contains the parameters of a calculation.
+type Input struct {
+	ObjectCost     int32   // Cost of the object being purchased
+	InitialPayment int32   // Initial payment, at least 20% of the object cost
+	Months         int32   // Loan term in months
+	Program        Program // Selected loan program
+}
+
+// Result is the outcome of a calculation.
+type Result struct {
+	Input                     // The parameters of the calculation
+	Rate            uint8     // Annual interest rate of the program in percent
+	LoanSum         int32     // Loan amount: the object cost minus the initial payment
+	MonthlyPayment  int32     // Annuity monthly payment, rounded up
+	Overpayment     int32     // Total interest paid over the term, rounded up
+	LastPaymentDate time.Time // Date of the last payment
+}
+
+// Calculator calculates mortgages with the given program rates.
+type Calculator struct {
+	rates Rates            // Rates of the loan programs
+	now   func() time.Time // Clock used to date the payments
+}
+
+// Option configures a Calculator.
+type Option func(c *Calculator)
+
+// WithClock makes the calculator date the payments from the given clock instead of the current time.
+func WithClock(now func() time.Time) Option {
+	return func(c *Calculator) {
+		c.now = now
+	}
+}
+
+// New creates a calculator with the given program rates.
+func New(rates Rates, opts ...Option) *Calculator {
+	c := &Calculator{rates: rates, now: time.Now}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Calculate validates the input and calculates the mortgage. It returns a *ValidationError if the input
+// breaks the rules, the rate of the program is not positive or the amounts do not fit in int32.
+func (c *Calculator) Calculate(in Input) (Result, error) {
+	if err := validate(in); err != nil {
+		return Result{}, err
+	}
+
+	// A zero rate would divide zero by zero in the payment formula
+	rate := c.rates.Of(in.Program)
+	if rate == 0 {
+		return Result{}, &ValidationError{Field: "program", Message: fmt.Sprintf("the rate of the %s program should be positive", in.Program), Err: errs.ErrInvalidRate}
+	}
+
+	// Calculate monthly payment and overpayment with the rate of the selected program
+	loanSum := in.ObjectCost - in.InitialPayment
+	monthlyPayment, overpayment, err := monthlyPaymentCalculator(float64(loanSum), float64(rate), in.Months)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Input:           in,
+		Rate:            rate,
+		LoanSum:         loanSum,
+		MonthlyPayment:  monthlyPayment,
+		Overpayment:     overpayment,
+		LastPaymentDate: dateOf(addMonths(c.now(), int(in.Months))),
+	}, nil
+}
+
+// Compare calculates the mortgage for every program with the same parameters, in the order of Programs.
+// The program of the input is ignored.
+func (c *Calculator) Compare(in Input) ([]Result, error) {
+	results := make([]Result, 0, len(Programs))
+	for _, p := range Programs {
+		in.Program = p
+		result, err := c.Calculate(in)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// monthlyPaymentCalculator calculates the monthly payment and overpayment based on the loan amount,
+// interest rate, and number of months for the mortgage. The rate must be positive. It returns a
+// *ValidationError if the payment or the overpayment do not fit in int32.
+func monthlyPaymentCalculator(loanSum, loanRate float64, months int32) (monthlyPayment, overpayment int32, err error) {
+	// Calculate the monthly interest rate
+	monthlyRate := loanRate / (100 * 12)
+
+	// Calculate the factor for the loan formula
+	factor := math.Pow((1 + monthlyRate), float64(months))
+
+	// Calculate the monthly payment and the overpayment in float64, so that they are checked before conversion
+	payment := math.Ceil(loanSum * (monthlyRate * factor) / (factor - 1))
+	total := math.Ceil(payment*float64(months) - loanSum)
+	if !fitsInt32(payment) || !fitsInt32(total) {
+		return 0, 0, &ValidationError{Field: "loan_sum", Message: "the loan is too large", Err: errs.ErrLoanTooLarge}
+	}
+
+	// Return the calculated values as integers
+	return int32(payment), int32(total), nil
+}
+
+// fitsInt32 reports whether the value is a number in the range of int32.
+func fitsInt32(v float64) bool {
+	return v >= math.MinInt32 && v <= math.MaxInt32
+}
+
+// Model returns the result in the representation of the HTTP API.
+func (r Result) Model() models.Result {
+	return models.Result{
+		Params: models.Params{
+			ObjectCost:     r.ObjectCost,
+			InitialPayment: r.InitialPayment,
+			Months:         r.Months,
+		},
+		Program: r.Program.flags(),
+		Aggregates: models.Aggregates{
+			Rate:            r.Rate,
+			LoanSum:         r.LoanSum,
+			MonthlyPayment:  r.MonthlyPayment,
+			Overpayment:     r.Overpayment,
+			LastPaymentDate: r.LastPaymentDate.Format(time.DateOnly),
+		},
+	}
+}
+
+// ResultFromModel restores a result from its representation in the HTTP API, e.g. a stored calculation.
+// It returns an error if the program or the last payment date are invalid.
+func ResultFromModel(m models.Result) (Result, error) {
+	program, err := programValidator(m.Program)
+	if err != nil {
+		return Result{}, err
+	}
+	lastDate, err := time.Parse(time.DateOnly, m.Aggregates.LastPaymentDate)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid last payment date %q: %w", m.Aggregates.LastPaymentDate, err)
+	}
+
+	return Result{
+		Input: Input{
+			ObjectCost:     m.Params.ObjectCost,
+			InitialPayment: m.Params.InitialPayment,
+			Months:         m.Params.Months,
+			Program:        program,
+		},
+		Rate:            m.Aggregates.Rate,
+		LoanSum:         m.Aggregates.LoanSum,
+		MonthlyPayment:  m.Aggregates.MonthlyPayment,
+		Overpayment:     m.Aggregates.Overpayment,
+		LastPaymentDate: lastDate,
+	}, nil
+}
+
+// validate checks the input against the rules of the calculation.
+func validate(in Input) error {
+	// Validate the initial payment (should be at least 20% of the object cost)
+	if !initialPaymentValidator(in.ObjectCost, in.InitialPayment) {
+		return &ValidationError{Field: "initial_payment", Message: "the initial payment should be more", Err: errs.ErrInitalPaymentIsTooSmall}
+	}
+
+	// Validate the loan term
+	if in.Months < 1 {
+		return &ValidationError{Field: "months", Message: "the loan term should be positive", Err: errs.ErrInvalidTerm}
+	}
+	if in.Months > MaxMonths {
+		return &ValidationError{Field: "months", Message: fmt.Sprintf("the loan term should be at most %d months", MaxMonths), Err: errs.ErrInvalidTerm}
+	}
+
+	// Validate the selected loan program
+	if in.Program == "" {
+		return &ValidationError{Field: "program", Message: "choose program", Err: errs.ErrNoTrueValues}
+	}
+	if in.Program != Base && in.Program != Military && in.Program != Salary {
+		return &ValidationError{Field: "program", Message: fmt.Sprintf("unknown program %q", in.Program), Err: errs.ErrUnknownProgram}
+	}
+	return nil
+}
+
+// dateOf truncates the time to midnight of its day.
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
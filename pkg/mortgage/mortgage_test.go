@@ -0,0 +1,226 @@
+package mortgage
+
+import (
+	"errors"
+	"math"
+	errs "sber/pkg/errors"
+	"sber/pkg/models"
+	"testing"
+	"time"
+)
+
+// fixedClock returns a clock that always reports the given date.
+func fixedClock(date string) func() time.Time {
+	t, _ := time.Parse(time.DateOnly, date)
+	return func() time.Time { return t.Add(15 * time.Hour) }
+}
+
+func TestCalculate(t *testing.T) {
+	calc := New(DefaultRates(), WithClock(fixedClock("2024-01-31")))
+
+	result, err := calc.Calculate(Input{ObjectCost: 5000000, InitialPayment: 1000000, Months: 240, Program: Salary})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Rate != 8 || result.LoanSum != 4000000 || result.MonthlyPayment != 33458 || result.Overpayment != 4029920 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if got := result.LastPaymentDate.Format(time.DateOnly); got != "2044-01-31" {
+		t.Errorf("Expected last payment date 2044-01-31, got %s", got)
+	}
+	if !result.LastPaymentDate.Equal(time.Date(2044, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the last payment date to be midnight, got %v", result.LastPaymentDate)
+	}
+
+	// The day of the last payment is clamped to the end of the month
+	result, err = calc.Calculate(Input{ObjectCost: 100000, InitialPayment: 20000, Months: 1, Program: Base})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := result.LastPaymentDate.Format(time.DateOnly); got != "2024-02-29" {
+		t.Errorf("Expected last payment date 2024-02-29, got %s", got)
+	}
+}
+
+func TestCalculateValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   Input
+		err     error
+		field   string
+		message string
+	}{
+		{"Initial payment too small", Input{ObjectCost: 100000, InitialPayment: 19000, Months: 12, Program: Base},
+			errs.ErrInitalPaymentIsTooSmall, "initial_payment", "the initial payment should be more"},
+		{"Zero term", Input{ObjectCost: 100000, InitialPayment: 20000, Months: 0, Program: Base},
+			errs.ErrInvalidTerm, "months", "the loan term should be positive"},
+		{"Negative term", Input{ObjectCost: 100000, InitialPayment: 20000, Months: -12, Program: Base},
+			errs.ErrInvalidTerm, "months", "the loan term should be positive"},
+		{"Term too long", Input{ObjectCost: 100000, InitialPayment: 20000, Months: MaxMonths + 1, Program: Base},
+			errs.ErrInvalidTerm, "months", "the loan term should be at most 600 months"},
+		{"Huge term", Input{ObjectCost: 100000, InitialPayment: 20000, Months: math.MaxInt32, Program: Base},
+			errs.ErrInvalidTerm, "months", "the loan term should be at most 600 months"},
+		{"No program", Input{ObjectCost: 100000, InitialPayment: 20000, Months: 12},
+			errs.ErrNoTrueValues, "program", "choose program"},
+		{"Unknown program", Input{ObjectCost: 100000, InitialPayment: 20000, Months: 12, Program: "family"},
+			errs.ErrUnknownProgram, "program", `unknown program "family"`},
+		{"Overpayment above int32", Input{ObjectCost: 732262365, InitialPayment: 200000000, Months: MaxMonths, Program: Base},
+			errs.ErrLoanTooLarge, "loan_sum", "the loan is too large"},
+		{"Large loan for the longest term", Input{ObjectCost: 2000000000, InitialPayment: 400000000, Months: MaxMonths, Program: Base},
+			errs.ErrLoanTooLarge, "loan_sum", "the loan is too large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(DefaultRates()).Calculate(tt.input)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected *ValidationError, got %T", err)
+			}
+			if validationErr.Field != tt.field || err.Error() != tt.message {
+				t.Errorf("Expected %s: %q, got %s: %q", tt.field, tt.message, validationErr.Field, err.Error())
+			}
+		})
+	}
+}
+
+// TestCalculateLimits verifies the amounts at the edge of int32 and that a program without a rate is rejected
+// instead of dividing zero by zero.
+func TestCalculateLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		rates       Rates
+		input       Input
+		err         error
+		overpayment int32
+	}{
+		{"Largest overpayment", DefaultRates(), Input{ObjectCost: 732262364, InitialPayment: 200000000, Months: MaxMonths, Program: Base},
+			nil, 2147483436},
+		{"Overpayment above int32", DefaultRates(), Input{ObjectCost: 732262365, InitialPayment: 200000000, Months: MaxMonths, Program: Base},
+			errs.ErrLoanTooLarge, 0},
+		{"Largest loan for one month", DefaultRates(), Input{ObjectCost: math.MaxInt32, InitialPayment: math.MaxInt32/5 + 1, Months: 1, Program: Base},
+			nil, 14316558},
+		{"Zero rates", Rates{}, Input{ObjectCost: 100000, InitialPayment: 20000, Months: 12, Program: Base},
+			errs.ErrInvalidRate, 0},
+		{"Unset program rate", Rates{Base: 10}, Input{ObjectCost: 100000, InitialPayment: 20000, Months: 12, Program: Salary},
+			errs.ErrInvalidRate, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New(tt.rates).Calculate(tt.input)
+			if tt.err != nil {
+				var validationErr *ValidationError
+				if !errors.Is(err, tt.err) || !errors.As(err, &validationErr) {
+					t.Fatalf("Expected *ValidationError wrapping %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Overpayment != tt.overpayment || result.MonthlyPayment <= 0 {
+				t.Errorf("Expected overpayment %d and a positive payment, got %d and %d", tt.overpayment, result.Overpayment, result.MonthlyPayment)
+			}
+		})
+	}
+}
+
+func TestCalculateMaxTerm(t *testing.T) {
+	// The longest term is accepted and gives a valid payment and schedule
+	result, err := New(DefaultRates()).Calculate(Input{ObjectCost: 5000000, InitialPayment: 1000000, Months: MaxMonths, Program: Base})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.MonthlyPayment <= 0 {
+		t.Errorf("Expected a positive monthly payment, got %d", result.MonthlyPayment)
+	}
+	schedule, err := result.Schedule()
+	if err != nil {
+		t.Fatalf("unexpected schedule error: %v", err)
+	}
+	if len(schedule) != MaxMonths || schedule[MaxMonths-1].Balance != 0 {
+		t.Errorf("Expected %d payments ending with a zero balance, got %d", MaxMonths, len(schedule))
+	}
+}
+
+func TestCompare(t *testing.T) {
+	rates := Rates{Base: 12, Military: 7, Salary: 5}
+	results, err := New(rates).Compare(Input{ObjectCost: 1000000, InitialPayment: 200000, Months: 120, Program: Base})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != len(Programs) {
+		t.Fatalf("Expected %d results, got %d", len(Programs), len(results))
+	}
+	for i, result := range results {
+		if result.Program != Programs[i] || result.Rate != rates.Of(Programs[i]) {
+			t.Errorf("Unexpected result %d: %+v", i, result)
+		}
+	}
+
+	// Invalid parameters are reported once for all programs
+	if _, err = New(rates).Compare(Input{ObjectCost: 1000000, InitialPayment: 100, Months: 120}); !errors.Is(err, errs.ErrInitalPaymentIsTooSmall) {
+		t.Errorf("Expected ErrInitalPaymentIsTooSmall, got %v", err)
+	}
+}
+
+func TestInputFromRequest(t *testing.T) {
+	in, err := InputFromRequest(models.ExecuteReqeust{
+		ObjectCost: 100000, InitialPayment: 20000, Months: 12, Program: models.Program{Military: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if in != (Input{ObjectCost: 100000, InitialPayment: 20000, Months: 12, Program: Military}) {
+		t.Errorf("Unexpected input %+v", in)
+	}
+
+	_, err = InputFromRequest(models.ExecuteReqeust{Program: models.Program{Base: true, Salary: true}})
+	if !errors.Is(err, errs.ErrMoreThanOneTrue) || err.Error() != "choose only 1 program" {
+		t.Errorf("Expected ErrMoreThanOneTrue, got %v", err)
+	}
+}
+
+func TestResultModel(t *testing.T) {
+	result, err := New(DefaultRates(), WithClock(fixedClock("2024-03-15"))).
+		Calculate(Input{ObjectCost: 100000, InitialPayment: 20000, Months: 12, Program: Military})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	model := result.Model()
+	expected := models.Result{
+		Params:  models.Params{ObjectCost: 100000, InitialPayment: 20000, Months: 12},
+		Program: models.Program{Military: true},
+		Aggregates: models.Aggregates{
+			Rate: 9, LoanSum: 80000, MonthlyPayment: 6997, Overpayment: 3964, LastPaymentDate: "2025-03-15",
+		},
+	}
+	if model != expected {
+		t.Errorf("Expected %+v, got %+v", expected, model)
+	}
+
+	// The model converts back to the same result
+	restored, err := ResultFromModel(model)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restored.Model() != model {
+		t.Errorf("Expected %+v, got %+v", model, restored.Model())
+	}
+
+	// Models without a valid program or date are rejected
+	model.Program = models.Program{}
+	if _, err = ResultFromModel(model); !errors.Is(err, errs.ErrNoTrueValues) {
+		t.Errorf("Expected ErrNoTrueValues, got %v", err)
+	}
+	model.Program = models.Program{Military: true}
+	model.Aggregates.LastPaymentDate = "15.03.2025"
+	if _, err = ResultFromModel(model); err == nil {
+		t.Error("Expected error for invalid last payment date")
+	}
+}
@@ -0,0 +1,59 @@
+package mortgage
+
+import (
+	"fmt"
+	"math"
+	"sber/pkg/models"
+	"time"
+)
+
+// Schedule builds the amortization schedule of the calculation. The payment dates are counted back from
+// the last payment date month by month, so that the schedule ends exactly on that date. Each payment covers
+// the interest accrued on the remaining balance, the rest repays the loan, and the last payment settles the
+// remaining balance, so it may be smaller than the monthly payment. Terms outside 1 to MaxMonths are rejected, as
+// results restored with ResultFromModel are not validated.
+func (r Result) Schedule() ([]models.Payment, error) {
+	months := r.Months
+	if months <= 0 || months > MaxMonths {
+		return nil, fmt.Errorf("invalid loan term %d", months)
+	}
+
+	// Calculate the monthly interest rate
+	monthlyRate := float64(r.Rate) / (100 * 12)
+
+	schedule := make([]models.Payment, months)
+	balance := float64(r.LoanSum)
+	for i := int32(1); i <= months; i++ {
+		interest := math.Round(balance * monthlyRate)
+		principal := float64(r.MonthlyPayment) - interest
+
+		// The last payment (or an overpaying one) settles the remaining balance
+		if i == months || principal > balance {
+			principal = balance
+		}
+		balance -= principal
+
+		schedule[i-1] = models.Payment{
+			Number:    i,
+			Date:      addMonths(r.LastPaymentDate, int(i-months)).Format(time.DateOnly),
+			Amount:    int32(principal + interest),
+			Principal: int32(principal),
+			Interest:  int32(interest),
+			Balance:   int32(balance),
+		}
+	}
+
+	return schedule, nil
+}
+
+// addMonths adds n months to t, clamping the day to the last day of the resulting month
+// (e.g. March 31 minus one month is February 28 or 29, not March 3).
+func addMonths(t time.Time, n int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, n, 0)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,78 @@
+package mortgage
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	lastDate, _ := time.Parse(time.DateOnly, "2044-02-29")
+	result := Result{
+		Input:           Input{ObjectCost: 5000000, InitialPayment: 1000000, Months: 240, Program: Salary},
+		Rate:            8,
+		LoanSum:         4000000,
+		MonthlyPayment:  33458,
+		Overpayment:     4029920,
+		LastPaymentDate: lastDate,
+	}
+
+	schedule, err := result.Schedule()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(schedule) != 240 {
+		t.Fatalf("Expected 240 payments, got %d", len(schedule))
+	}
+
+	// The schedule ends on the last payment date and repays the whole loan
+	last := schedule[len(schedule)-1]
+	if last.Date != "2044-02-29" || last.Balance != 0 {
+		t.Errorf("Unexpected last payment %+v", last)
+	}
+	var principal int64
+	for _, p := range schedule {
+		principal += int64(p.Principal)
+	}
+	if principal != int64(result.LoanSum) {
+		t.Errorf("Expected principal payments to sum to %d, got %d", result.LoanSum, principal)
+	}
+	if first := schedule[0]; first.Date != "2024-03-29" || first.Interest != 26667 {
+		t.Errorf("Unexpected first payment %+v", first)
+	}
+
+	// Results without a term have no schedule
+	result.Months = 0
+	if _, err := result.Schedule(); err == nil {
+		t.Error("Expected error for invalid loan term")
+	}
+
+	// Neither do results with a term too long to build, e.g. restored from a tampered record
+	result.Months = math.MaxInt32
+	if _, err := result.Schedule(); err == nil {
+		t.Error("Expected error for a loan term above MaxMonths")
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		date     string
+		months   int
+		expected string
+	}{
+		{"2024-01-15", 1, "2024-02-15"},
+		{"2024-01-31", 1, "2024-02-29"},
+		{"2023-03-31", -1, "2023-02-28"},
+		{"2024-12-31", 2, "2025-02-28"},
+		{"2024-05-31", -12, "2023-05-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			date, _ := time.Parse(time.DateOnly, tt.date)
+			if got := addMonths(date, tt.months).Format(time.DateOnly); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package mortgage
+
+import (
+	errs "sber/pkg/errors"
+	"sber/pkg/models"
+)
+
+// ValidationError is returned when the input of a calculation breaks the rules. It wraps one of the
+// errors of sber/pkg/errors, so that callers can match it with errors.Is, and carries the message
+// reported to clients.
+type ValidationError struct {
+	Field   string // Name of the invalid input field: initial_payment, months, program or loan_sum
+	Message string // Message reported to clients
+	Err     error  // The underlying error from sber/pkg/errors
+}
+
+// Error returns the message reported to clients.
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying error, so that errors.Is matches it.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// InputFromRequest builds the input of an /execute request. It returns a *ValidationError unless exactly
+// one program flag is set.
+func InputFromRequest(req models.ExecuteReqeust) (Input, error) {
+	program, err := programValidator(req.Program)
+	if err != nil {
+		return Input{}, err
+	}
+	return Input{
+		ObjectCost:     req.ObjectCost,
+		InitialPayment: req.InitialPayment,
+		Months:         req.Months,
+		Program:        program,
+	}, nil
+}
+
+// programValidator validates the loan program flags.
+// It checks if exactly one program flag is set to true, and returns an error if any validation fails.
+func programValidator(flags models.Program) (Program, error) {
+	// Declare variables for error handling and to hold the result of validation checks.
+	var (
+		countTrue     int     // Tracks how many program flags are set to true
+		lastTrueField Program // Holds the last program whose flag was set to true
+	)
+
+	// Check each program flag manually
+	if flags.Base {
+		countTrue++
+		lastTrueField = Base
+	}
+	if flags.Military {
+		countTrue++
+		lastTrueField = Military
+	}
+	if flags.Salary {
+		countTrue++
+		lastTrueField = Salary
+	}
+
+	// Return errors if no flags or more than one flag are set to true
+	if countTrue == 0 {
+		return "", &ValidationError{Field: "program", Message: "choose program", Err: errs.ErrNoTrueValues}
+	}
+	if countTrue > 1 {
+		return "", &ValidationError{Field: "program", Message: "choose only 1 program", Err: errs.ErrMoreThanOneTrue}
+	}
+
+	// Return the program whose flag was set to true
+	return lastTrueField, nil
+}
+
+// initialPaymentValidator validates the initial payment based on the object cost.
+// The initial payment must be more than zero and at least 20% of the object cost.
+func initialPaymentValidator(objectCost, initialPayment int32) bool {
+	if initialPayment > objectCost {
+		return false
+	}
+	// If both object cost and initial payment are zero, return false
+	if objectCost == 0 && initialPayment == 0 {
+		return false
+	}
+	// If the initial payment is zero, return false
+	if initialPayment == 0 {
+		return false
+	}
+	// If the initial payment is less than 20% of the object cost, return false
+	if int64(initialPayment)*5 < int64(objectCost) {
+		return false
+	}
+
+	// If all conditions are satisfied, return true
+	return true
+}
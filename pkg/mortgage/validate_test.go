@@ -1,4 +1,4 @@
-package handlers
+package mortgage
 
 import (
 	"errors"
@@ -36,7 +36,7 @@ func TestProgramValidator(t *testing.T) {
 		name        string
 		program     models.Program
 		expectError error
-		expectName  string
+		expectName  Program
 	}{
 		{"No program selected", models.Program{}, errs.ErrNoTrueValues, ""},
 		{"Multiple programs",
@@ -48,12 +48,13 @@ func TestProgramValidator(t *testing.T) {
 			models.Program{Base: true}, nil, "base"},
 		{"Valid military program",
 			models.Program{Military: true}, nil, "military"},
+		{"Valid salary program",
+			models.Program{Salary: true}, nil, "salary"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := models.ExecuteReqeust{Program: tt.program}
-			name, err := programValidator(req)
+			name, err := programValidator(tt.program)
 
 			if !errors.Is(err, tt.expectError) {
 				t.Errorf("Expected error %v, got %v", tt.expectError, err)
@@ -68,7 +69,7 @@ func TestProgramValidator(t *testing.T) {
 func TestMonthlyPaymentCalculator(t *testing.T) {
 	tests := []struct {
 		name            string
-		objectCost      float64
+		loanSum         float64
 		loanRate        float64
 		months          int32
 		expectedPayment int32
@@ -76,7 +77,7 @@ func TestMonthlyPaymentCalculator(t *testing.T) {
 	}{
 		{
 			name:            "Basic calculation",
-			objectCost:      100000,
+			loanSum:         100000,
 			loanRate:        10,
 			months:          12,
 			expectedPayment: 8792,
@@ -84,7 +85,7 @@ func TestMonthlyPaymentCalculator(t *testing.T) {
 		},
 		{
 			name:            "Short term loan",
-			objectCost:      50000,
+			loanSum:         50000,
 			loanRate:        5,
 			months:          6,
 			expectedPayment: 8456,
@@ -92,7 +93,7 @@ func TestMonthlyPaymentCalculator(t *testing.T) {
 		},
 		{
 			name:            "Long term loan",
-			objectCost:      200000,
+			loanSum:         200000,
 			loanRate:        7.5,
 			months:          240,
 			expectedPayment: 1612,
@@ -100,7 +101,7 @@ func TestMonthlyPaymentCalculator(t *testing.T) {
 		},
 		{
 			name:            "Small loan amount",
-			objectCost:      1000,
+			loanSum:         1000,
 			loanRate:        5,
 			months:          12,
 			expectedPayment: 86,
@@ -108,7 +109,7 @@ func TestMonthlyPaymentCalculator(t *testing.T) {
 		},
 		{
 			name:            "High interest rate",
-			objectCost:      100000,
+			loanSum:         100000,
 			loanRate:        20,
 			months:          12,
 			expectedPayment: 9264,
@@ -116,7 +117,7 @@ func TestMonthlyPaymentCalculator(t *testing.T) {
 		},
 		{
 			name:            "One month term",
-			objectCost:      10000,
+			loanSum:         10000,
 			loanRate:        10,
 			months:          1,
 			expectedPayment: 10084,
@@ -126,7 +127,10 @@ func TestMonthlyPaymentCalculator(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			payment, overpay := monthlyPaymentCalculator(tt.objectCost, tt.loanRate, tt.months)
+			payment, overpay, err := monthlyPaymentCalculator(tt.loanSum, tt.loanRate, tt.months)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 
 			if payment != tt.expectedPayment {
 				t.Errorf("Expected monthly payment %d, got %d", tt.expectedPayment, payment)
@@ -138,48 +142,46 @@ func TestMonthlyPaymentCalculator(t *testing.T) {
 	}
 }
 
-func TestGetLoanRateAndProgram(t *testing.T) {
+func TestRatesOf(t *testing.T) {
 	tests := []struct {
-		name            string
-		loanProgram     string
-		expectedRate    uint8
-		expectedProgram models.Program
+		name          string
+		program       Program
+		expectedRate  uint8
+		expectedFlags models.Program
 	}{
 		{
-			name:            "Base program",
-			loanProgram:     "base",
-			expectedRate:    10,
-			expectedProgram: models.Program{Base: true},
+			name:          "Base program",
+			program:       Base,
+			expectedRate:  10,
+			expectedFlags: models.Program{Base: true},
 		},
 		{
-			name:            "Military program",
-			loanProgram:     "military",
-			expectedRate:    9,
-			expectedProgram: models.Program{Military: true},
+			name:          "Military program",
+			program:       Military,
+			expectedRate:  9,
+			expectedFlags: models.Program{Military: true},
 		},
 		{
-			name:            "Salary program",
-			loanProgram:     "salary",
-			expectedRate:    8,
-			expectedProgram: models.Program{Salary: true},
+			name:          "Salary program",
+			program:       Salary,
+			expectedRate:  8,
+			expectedFlags: models.Program{Salary: true},
 		},
 		{
-			name:            "Unknown program",
-			loanProgram:     "unknown",
-			expectedRate:    0,
-			expectedProgram: models.Program{}, // Пустая структура, так как программа неизвестна
+			name:          "Unknown program",
+			program:       "unknown",
+			expectedRate:  0,
+			expectedFlags: models.Program{}, // Пустая структура, так как программа неизвестна
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			rate, program := getLoanRateAndProgram(tt.loanProgram)
-
-			if rate != tt.expectedRate {
+			if rate := DefaultRates().Of(tt.program); rate != tt.expectedRate {
 				t.Errorf("got rate %d, want %d", rate, tt.expectedRate)
 			}
-			if !reflect.DeepEqual(program, tt.expectedProgram) {
-				t.Errorf("got program %+v, want %+v", program, tt.expectedProgram)
+			if flags := tt.program.flags(); !reflect.DeepEqual(flags, tt.expectedFlags) {
+				t.Errorf("got program %+v, want %+v", flags, tt.expectedFlags)
 			}
 		})
 	}